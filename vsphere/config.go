@@ -57,6 +57,22 @@ func (c *VSphereClient) TagsClient() (*tags.RestClient, error) {
 	return c.tagsClient, nil
 }
 
+// ContentLibraryClient returns a client for the content library API, after
+// determining if the connection is eligible. The content library API shares
+// the CIS REST session of the tags client, so the same requirements as
+// TagsClient apply, in addition to the minimum version for content libraries.
+func (c *VSphereClient) ContentLibraryClient() (*contentLibraryClient, error) {
+	rest, err := c.TagsClient()
+	if err != nil {
+		return nil, err
+	}
+	clientVer := parseVersionFromClient(c.vimClient)
+	if !clientVer.ProductEqual(contentLibraryMinVersion) || clientVer.Older(contentLibraryMinVersion) {
+		return nil, fmt.Errorf("content libraries require %s or higher", contentLibraryMinVersion)
+	}
+	return newContentLibraryClient(c.vimClient, rest), nil
+}
+
 // Config holds the provider configuration, and delivers a populated
 // VSphereClient based off the contained settings.
 type Config struct {
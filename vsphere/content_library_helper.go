@@ -0,0 +1,665 @@
+package vsphere
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/vmware/govmomi"
+	"github.com/vmware/govmomi/vim25/progress"
+	"github.com/vmware/vic/pkg/vsphere/tags"
+)
+
+const (
+	// contentLibraryTypeLocal is the type of a local content library.
+	contentLibraryTypeLocal = "LOCAL"
+
+	// contentLibraryTypeSubscribed is the type of a subscribed content library.
+	contentLibraryTypeSubscribed = "SUBSCRIBED"
+
+	// contentLibraryItemTypeOVF is the type of a library item containing an OVF
+	// package.
+	contentLibraryItemTypeOVF = "ovf"
+
+	// contentLibraryItemTypeISO is the type of a library item containing an ISO
+	// image.
+	contentLibraryItemTypeISO = "iso"
+
+	// contentLibrarySessionCookie is the name of the cookie that holds the CIS
+	// REST session ID.
+	contentLibrarySessionCookie = "vmware-api-session-id"
+
+	// contentLibraryUpdateSessionPollInterval is the interval at which the
+	// state of an update session is checked after it has been completed.
+	contentLibraryUpdateSessionPollInterval = 5 * time.Second
+
+	// contentLibraryUpdateSessionTimeout is the time to wait for the library
+	// to process the files of an update session after it has been completed.
+	contentLibraryUpdateSessionTimeout = 30 * time.Minute
+
+	// contentLibraryDeployTimeout is the time to wait for a deployment of a
+	// library item to complete. Deployments copy the disks of the item, so
+	// this is much longer than defaultAPITimeout.
+	contentLibraryDeployTimeout = 60 * time.Minute
+)
+
+// contentLibraryMinVersion is the minimum vSphere version required for
+// content libraries.
+var contentLibraryMinVersion = vSphereVersion{
+	product: "VMware vCenter Server",
+	major:   6,
+	minor:   5,
+}
+
+// contentLibraryClient is a thin client for the content library API. The API
+// is served by the same CIS REST endpoint that is used for tags, so the
+// session of the tags client is shared, as the tags SDK does not cover
+// content libraries.
+type contentLibraryClient struct {
+	rest     *tags.RestClient
+	endpoint *url.URL
+}
+
+// newContentLibraryClient returns a contentLibraryClient for the CIS REST
+// endpoint on the server that the supplied client is connected to.
+func newContentLibraryClient(client *govmomi.Client, rest *tags.RestClient) *contentLibraryClient {
+	u := *client.URL()
+	u.Path = tags.RestPrefix
+	u.User = nil
+	return &contentLibraryClient{
+		rest:     rest,
+		endpoint: &u,
+	}
+}
+
+// contentLibraryError is returned when the content library API responds with
+// an error.
+type contentLibraryError struct {
+	// The HTTP status code of the response.
+	status int
+
+	// The body of the response.
+	body string
+}
+
+// Error implements error for contentLibraryError.
+func (e contentLibraryError) Error() string {
+	return fmt.Sprintf("error response from content library API (%d %s): %s", e.status, http.StatusText(e.status), e.body)
+}
+
+// isContentLibraryNotFoundError returns true if the supplied error is an error
+// from the content library API for an object that does not exist.
+func isContentLibraryNotFoundError(err error) bool {
+	if e, ok := err.(contentLibraryError); ok {
+		return e.status == http.StatusNotFound
+	}
+	return false
+}
+
+// do sends a request to the content library API, encoding in as the JSON
+// request body if it is not nil, and decoding the value of the response into
+// out if it is not nil. The request is retried once after logging in again if
+// the session has expired.
+func (c *contentLibraryClient) do(method, p string, in, out interface{}) error {
+	return c.doTimeout(defaultAPITimeout, method, p, in, out)
+}
+
+// doTimeout works like do, but with the supplied timeout.
+func (c *contentLibraryClient) doTimeout(timeout time.Duration, method, p string, in, out interface{}) error {
+	var body []byte
+	if in != nil {
+		var err error
+		body, err = json.Marshal(in)
+		if err != nil {
+			return err
+		}
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	resp, err := c.send(ctx, method, p, body)
+	if err == nil && resp.StatusCode == http.StatusUnauthorized {
+		resp.Body.Close()
+		log.Printf("[DEBUG] CIS REST session expired, logging in again")
+		if err := c.rest.Login(ctx); err != nil {
+			return err
+		}
+		resp, err = c.send(ctx, method, p, body)
+	}
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	b, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("error reading response: %s", err)
+	}
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusBadRequest {
+		return contentLibraryError{
+			status: resp.StatusCode,
+			body:   string(bytes.TrimSpace(b)),
+		}
+	}
+	if out == nil {
+		return nil
+	}
+	var v struct {
+		Value json.RawMessage `json:"value"`
+	}
+	if err := json.Unmarshal(b, &v); err != nil {
+		return fmt.Errorf("error decoding response: %s", err)
+	}
+	return json.Unmarshal(v.Value, out)
+}
+
+// send sends a single request to the content library API.
+func (c *contentLibraryClient) send(ctx context.Context, method, p string, body []byte) (*http.Response, error) {
+	req, err := http.NewRequest(method, c.endpoint.String()+p, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	return c.rest.HTTP.Do(req)
+}
+
+// sessionID returns the ID of the current CIS REST session.
+func (c *contentLibraryClient) sessionID() string {
+	if c.rest.HTTP.Jar == nil {
+		return ""
+	}
+	for _, cookie := range c.rest.HTTP.Jar.Cookies(c.endpoint) {
+		if cookie.Name == contentLibrarySessionCookie {
+			return cookie.Value
+		}
+	}
+	return ""
+}
+
+// contentLibraryStorageBacking describes the storage of a content library.
+type contentLibraryStorageBacking struct {
+	Type        string `json:"type"`
+	DatastoreID string `json:"datastore_id,omitempty"`
+}
+
+// contentLibraryPublishInfo describes how a local content library is
+// published.
+type contentLibraryPublishInfo struct {
+	Published            bool   `json:"published"`
+	AuthenticationMethod string `json:"authentication_method,omitempty"`
+	PublishURL           string `json:"publish_url,omitempty"`
+}
+
+// contentLibrarySubscriptionInfo describes how a subscribed content library
+// is kept in sync with the library it subscribes to.
+type contentLibrarySubscriptionInfo struct {
+	SubscriptionURL      string `json:"subscription_url"`
+	AuthenticationMethod string `json:"authentication_method"`
+	UserName             string `json:"user_name,omitempty"`
+	Password             string `json:"password,omitempty"`
+	AutomaticSyncEnabled bool   `json:"automatic_sync_enabled"`
+	OnDemand             bool   `json:"on_demand"`
+	SslThumbprint        string `json:"ssl_thumbprint,omitempty"`
+}
+
+// contentLibrary is a content library, as used in both create and update
+// specs and in the model returned by the API.
+type contentLibrary struct {
+	ID               string                          `json:"id,omitempty"`
+	Name             string                          `json:"name"`
+	Description      string                          `json:"description"`
+	Type             string                          `json:"type,omitempty"`
+	StorageBackings  []contentLibraryStorageBacking  `json:"storage_backings,omitempty"`
+	PublishInfo      *contentLibraryPublishInfo      `json:"publish_info,omitempty"`
+	SubscriptionInfo *contentLibrarySubscriptionInfo `json:"subscription_info,omitempty"`
+}
+
+// contentLibraryPath returns the API path for managing a content library of
+// the supplied type.
+func contentLibraryPath(libraryType string) string {
+	if libraryType == contentLibraryTypeSubscribed {
+		return "/com/vmware/content/subscribed-library"
+	}
+	return "/com/vmware/content/local-library"
+}
+
+// createContentLibrary creates a content library from the supplied spec and
+// returns its ID. The type of the library is taken from the spec.
+func createContentLibrary(c *contentLibraryClient, spec contentLibrary) (string, error) {
+	var id string
+	req := map[string]interface{}{"create_spec": spec}
+	if err := c.do(http.MethodPost, contentLibraryPath(spec.Type), req, &id); err != nil {
+		return "", err
+	}
+	return id, nil
+}
+
+// getContentLibrary fetches the content library with the supplied ID.
+func getContentLibrary(c *contentLibraryClient, id string) (*contentLibrary, error) {
+	var lib contentLibrary
+	if err := c.do(http.MethodGet, "/com/vmware/content/library/id:"+url.PathEscape(id), nil, &lib); err != nil {
+		return nil, err
+	}
+	return &lib, nil
+}
+
+// updateContentLibrary updates the content library with the ID and type in
+// the supplied spec.
+func updateContentLibrary(c *contentLibraryClient, spec contentLibrary) error {
+	p := contentLibraryPath(spec.Type) + "/id:" + url.PathEscape(spec.ID)
+	spec.ID = ""
+	return c.do(http.MethodPatch, p, map[string]interface{}{"update_spec": spec}, nil)
+}
+
+// deleteContentLibrary deletes the content library with the supplied ID and
+// type, along with all of its items.
+func deleteContentLibrary(c *contentLibraryClient, id, libraryType string) error {
+	return c.do(http.MethodDelete, contentLibraryPath(libraryType)+"/id:"+url.PathEscape(id), nil, nil)
+}
+
+// contentLibraryItem is an item in a content library, as used in both create
+// and update specs and in the model returned by the API.
+type contentLibraryItem struct {
+	ID          string `json:"id,omitempty"`
+	LibraryID   string `json:"library_id,omitempty"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Type        string `json:"type,omitempty"`
+	Size        int64  `json:"size,omitempty"`
+}
+
+// createContentLibraryItem creates an empty library item from the supplied
+// spec and returns its ID.
+func createContentLibraryItem(c *contentLibraryClient, spec contentLibraryItem) (string, error) {
+	var id string
+	req := map[string]interface{}{"create_spec": spec}
+	if err := c.do(http.MethodPost, "/com/vmware/content/library/item", req, &id); err != nil {
+		return "", err
+	}
+	return id, nil
+}
+
+// getContentLibraryItem fetches the library item with the supplied ID.
+func getContentLibraryItem(c *contentLibraryClient, id string) (*contentLibraryItem, error) {
+	var item contentLibraryItem
+	if err := c.do(http.MethodGet, "/com/vmware/content/library/item/id:"+url.PathEscape(id), nil, &item); err != nil {
+		return nil, err
+	}
+	return &item, nil
+}
+
+// updateContentLibraryItem updates the name and description of the library
+// item with the ID in the supplied spec.
+func updateContentLibraryItem(c *contentLibraryClient, spec contentLibraryItem) error {
+	req := map[string]interface{}{
+		"update_spec": contentLibraryItem{
+			Name:        spec.Name,
+			Description: spec.Description,
+		},
+	}
+	return c.do(http.MethodPatch, "/com/vmware/content/library/item/id:"+url.PathEscape(spec.ID), req, nil)
+}
+
+// deleteContentLibraryItem deletes the library item with the supplied ID.
+func deleteContentLibraryItem(c *contentLibraryClient, id string) error {
+	return c.do(http.MethodDelete, "/com/vmware/content/library/item/id:"+url.PathEscape(id), nil, nil)
+}
+
+// contentLibraryItemByName returns the item with the supplied name in the
+// content library with the supplied ID.
+func contentLibraryItemByName(c *contentLibraryClient, libraryID, name string) (*contentLibraryItem, error) {
+	var ids []string
+	req := map[string]interface{}{
+		"spec": map[string]string{
+			"library_id": libraryID,
+			"name":       name,
+		},
+	}
+	if err := c.do(http.MethodPost, "/com/vmware/content/library/item?~action=find", req, &ids); err != nil {
+		return nil, err
+	}
+	switch {
+	case len(ids) < 1:
+		return nil, fmt.Errorf("no library item named %q found in library %q", name, libraryID)
+	case len(ids) > 1:
+		return nil, fmt.Errorf("multiple library items named %q found in library %q", name, libraryID)
+	}
+	return getContentLibraryItem(c, ids[0])
+}
+
+// contentLibraryUploadFile is a local file to be uploaded to a library item.
+type contentLibraryUploadFile struct {
+	// The name of the file in the library item.
+	name string
+
+	// Opens the file, returning it and its size.
+	open func() (io.ReadCloser, int64, error)
+}
+
+// contentLibraryFilesForPath returns the files that need to be uploaded to a
+// library item of the supplied type for the local file at the supplied path.
+// ISO images are uploaded as is, while OVF packages and OVA archives are
+// uploaded as the OVF descriptor and the files referenced by it.
+func contentLibraryFilesForPath(p, itemType string) ([]contentLibraryUploadFile, error) {
+	if itemType != contentLibraryItemTypeOVF {
+		return []contentLibraryUploadFile{
+			{
+				name: filepath.Base(p),
+				open: func() (io.ReadCloser, int64, error) {
+					f, err := os.Open(p)
+					if err != nil {
+						return nil, 0, err
+					}
+					s, err := f.Stat()
+					if err != nil {
+						f.Close()
+						return nil, 0, err
+					}
+					return f, s.Size(), nil
+				},
+			},
+		}, nil
+	}
+
+	src := newLocalOVFSource(p)
+	descriptor, err := src.Descriptor()
+	if err != nil {
+		return nil, err
+	}
+	var envelope struct {
+		Files []struct {
+			Href string `xml:"href,attr"`
+		} `xml:"References>File"`
+	}
+	if err := xml.Unmarshal([]byte(descriptor), &envelope); err != nil {
+		return nil, fmt.Errorf("error parsing OVF descriptor from %q: %s", p, err)
+	}
+	files := []contentLibraryUploadFile{
+		{
+			name: strings.TrimSuffix(filepath.Base(p), filepath.Ext(p)) + ".ovf",
+			open: func() (io.ReadCloser, int64, error) {
+				return ioutil.NopCloser(strings.NewReader(descriptor)), int64(len(descriptor)), nil
+			},
+		},
+	}
+	for _, f := range envelope.Files {
+		href := f.Href
+		files = append(files, contentLibraryUploadFile{
+			name: href,
+			open: func() (io.ReadCloser, int64, error) {
+				return src.Open(href)
+			},
+		})
+	}
+	return files, nil
+}
+
+// contentLibraryUpdateSession is the state of an update session for a library
+// item.
+type contentLibraryUpdateSession struct {
+	State        string `json:"state"`
+	ErrorMessage *struct {
+		DefaultMessage string `json:"default_message"`
+	} `json:"error_message,omitempty"`
+}
+
+// uploadContentLibraryItemFiles uploads the supplied files to the library item
+// with the supplied ID in a single update session, and waits for the library
+// to process them. If any step fails, the update session is failed so that
+// the item is left unchanged.
+func uploadContentLibraryItemFiles(c *contentLibraryClient, itemID string, files []contentLibraryUploadFile) error {
+	var sessionID string
+	req := map[string]interface{}{
+		"create_spec": map[string]string{"library_item_id": itemID},
+	}
+	if err := c.do(http.MethodPost, "/com/vmware/content/library/item/update-session", req, &sessionID); err != nil {
+		return fmt.Errorf("error creating update session: %s", err)
+	}
+	sessionPath := "/com/vmware/content/library/item/update-session/id:" + url.PathEscape(sessionID)
+	defer func() {
+		if err := c.do(http.MethodDelete, sessionPath, nil, nil); err != nil {
+			log.Printf("[WARN] Error deleting update session %q: %s", sessionID, err)
+		}
+	}()
+
+	if err := uploadContentLibraryUpdateSessionFiles(c, sessionID, files); err != nil {
+		req := map[string]string{"client_error_message": err.Error()}
+		if ferr := c.do(http.MethodPost, sessionPath+"?~action=fail", req, nil); ferr != nil {
+			log.Printf("[WARN] Error failing update session %q: %s", sessionID, ferr)
+		}
+		return err
+	}
+	if err := c.do(http.MethodPost, sessionPath+"?~action=complete", nil, nil); err != nil {
+		return fmt.Errorf("error completing update session: %s", err)
+	}
+	deadline := time.Now().Add(contentLibraryUpdateSessionTimeout)
+	for {
+		var session contentLibraryUpdateSession
+		if err := c.do(http.MethodGet, sessionPath, nil, &session); err != nil {
+			return fmt.Errorf("error checking update session: %s", err)
+		}
+		switch session.State {
+		case "DONE":
+			return nil
+		case "ERROR", "CANCELED":
+			msg := session.State
+			if session.ErrorMessage != nil {
+				msg = session.ErrorMessage.DefaultMessage
+			}
+			return fmt.Errorf("update session failed: %s", msg)
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("timeout waiting for update session %q to complete, last state: %s", sessionID, session.State)
+		}
+		time.Sleep(contentLibraryUpdateSessionPollInterval)
+	}
+}
+
+// uploadContentLibraryUpdateSessionFiles adds the supplied files to an update
+// session, uploads them, and validates the result.
+func uploadContentLibraryUpdateSessionFiles(c *contentLibraryClient, sessionID string, files []contentLibraryUploadFile) error {
+	filePath := "/com/vmware/content/library/item/updatesession/file/id:" + url.PathEscape(sessionID)
+	for _, f := range files {
+		r, size, err := f.open()
+		if err != nil {
+			return fmt.Errorf("error opening %q: %s", f.name, err)
+		}
+		var info struct {
+			UploadEndpoint struct {
+				URI string `json:"uri"`
+			} `json:"upload_endpoint"`
+		}
+		req := map[string]interface{}{
+			"file_spec": map[string]interface{}{
+				"name":        f.name,
+				"source_type": "PUSH",
+				"size":        size,
+			},
+		}
+		if err := c.do(http.MethodPost, filePath+"?~action=add", req, &info); err != nil {
+			r.Close()
+			return fmt.Errorf("error adding %q to update session: %s", f.name, err)
+		}
+		err = uploadContentLibraryFile(c, f.name, info.UploadEndpoint.URI, r, size)
+		r.Close()
+		if err != nil {
+			return fmt.Errorf("error uploading %q: %s", f.name, err)
+		}
+	}
+
+	var validation struct {
+		HasErrors    bool     `json:"has_errors"`
+		MissingFiles []string `json:"missing_files"`
+		InvalidFiles []struct {
+			Name         string `json:"name"`
+			ErrorMessage struct {
+				DefaultMessage string `json:"default_message"`
+			} `json:"error_message"`
+		} `json:"invalid_files"`
+	}
+	if err := c.do(http.MethodPost, filePath+"?~action=validate", nil, &validation); err != nil {
+		return fmt.Errorf("error validating update session: %s", err)
+	}
+	if !validation.HasErrors {
+		return nil
+	}
+	var errs []string
+	for _, f := range validation.MissingFiles {
+		errs = append(errs, fmt.Sprintf("%s: file is missing", f))
+	}
+	for _, f := range validation.InvalidFiles {
+		errs = append(errs, fmt.Sprintf("%s: %s", f.Name, f.ErrorMessage.DefaultMessage))
+	}
+	return fmt.Errorf("library item files failed validation: %s", strings.Join(errs, ", "))
+}
+
+// uploadContentLibraryFile streams the supplied reader to an upload endpoint
+// of an update session, logging progress as it goes.
+func uploadContentLibraryFile(c *contentLibraryClient, name, uri string, r io.Reader, size int64) error {
+	pr := progress.NewReader(newUploadProgressLogger(name), r, size)
+	req, err := http.NewRequest(http.MethodPut, uri, pr)
+	if err != nil {
+		pr.Done(err)
+		return err
+	}
+	req.ContentLength = size
+	req.Header.Set("Content-Type", "application/octet-stream")
+	req.Header.Set(contentLibrarySessionCookie, c.sessionID())
+	// Uploads are not bound by a timeout, as files can be large.
+	resp, err := c.rest.HTTP.Do(req)
+	if err == nil {
+		defer resp.Body.Close()
+		if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusBadRequest {
+			b, _ := ioutil.ReadAll(resp.Body)
+			err = contentLibraryError{
+				status: resp.StatusCode,
+				body:   string(bytes.TrimSpace(b)),
+			}
+		}
+	}
+	pr.Done(err)
+	return err
+}
+
+// contentLibraryItemTypeForPath returns the library item type for the local
+// file at the supplied path, based on its extension.
+func contentLibraryItemTypeForPath(p string) (string, error) {
+	switch strings.ToLower(filepath.Ext(p)) {
+	case ".ovf", ".ova":
+		return contentLibraryItemTypeOVF, nil
+	case ".iso":
+		return contentLibraryItemTypeISO, nil
+	}
+	return "", fmt.Errorf("cannot determine library item type for %q: file must be an OVF, OVA, or ISO file", p)
+}
+
+// contentLibraryDeploymentTarget describes where a library item is deployed.
+type contentLibraryDeploymentTarget struct {
+	ResourcePoolID string `json:"resource_pool_id"`
+	HostID         string `json:"host_id,omitempty"`
+	FolderID       string `json:"folder_id,omitempty"`
+}
+
+// contentLibraryMapEntry is an entry in a map, as maps are encoded as lists of
+// key/value pairs by the CIS REST API.
+type contentLibraryMapEntry struct {
+	Key   string      `json:"key"`
+	Value interface{} `json:"value"`
+}
+
+// contentLibraryDeploymentSpec describes how an OVF package in a library item
+// is deployed.
+type contentLibraryDeploymentSpec struct {
+	Name                string                   `json:"name"`
+	AcceptAllEULA       bool                     `json:"accept_all_EULA"`
+	DefaultDatastoreID  string                   `json:"default_datastore_id,omitempty"`
+	StorageProvisioning string                   `json:"storage_provisioning,omitempty"`
+	NetworkMappings     []contentLibraryMapEntry `json:"network_mappings,omitempty"`
+}
+
+// contentLibraryDiskProvisioning returns the disk provisioning type of the
+// OVF deployment API for the supplied vsphere_virtual_machine disk type.
+func contentLibraryDiskProvisioning(diskType string) string {
+	switch diskType {
+	case "thin":
+		return "thin"
+	case "lazy":
+		return "thick"
+	}
+	return "eagerZeroedThick"
+}
+
+// contentLibraryItemOVFPath returns the API path for the OVF package in the
+// library item with the supplied ID.
+func contentLibraryItemOVFPath(itemID string) string {
+	return "/com/vmware/vcenter/ovf/library-item/id:" + url.PathEscape(itemID)
+}
+
+// contentLibraryItemNetworks returns the names of the networks in the OVF
+// package in the library item with the supplied ID.
+func contentLibraryItemNetworks(c *contentLibraryClient, itemID string, target contentLibraryDeploymentTarget) ([]string, error) {
+	var res struct {
+		Networks []string `json:"networks"`
+	}
+	req := map[string]interface{}{"target": target}
+	if err := c.do(http.MethodPost, contentLibraryItemOVFPath(itemID)+"?~action=filter", req, &res); err != nil {
+		return nil, err
+	}
+	return res.Networks, nil
+}
+
+// deployContentLibraryItem deploys the OVF package in the library item with
+// the supplied ID, and returns the managed object ID of the virtual machine
+// that was created.
+func deployContentLibraryItem(c *contentLibraryClient, itemID string, target contentLibraryDeploymentTarget, spec contentLibraryDeploymentSpec) (string, error) {
+	type deploymentMessage struct {
+		Error struct {
+			DefaultMessage string `json:"default_message"`
+		} `json:"error"`
+	}
+	var res struct {
+		Succeeded  bool `json:"succeeded"`
+		ResourceID struct {
+			Type string `json:"type"`
+			ID   string `json:"id"`
+		} `json:"resource_id"`
+		Error struct {
+			Errors   []deploymentMessage `json:"errors"`
+			Warnings []deploymentMessage `json:"warnings"`
+		} `json:"error"`
+	}
+	req := map[string]interface{}{
+		"target":          target,
+		"deployment_spec": spec,
+	}
+	if err := c.doTimeout(contentLibraryDeployTimeout, http.MethodPost, contentLibraryItemOVFPath(itemID)+"?~action=deploy", req, &res); err != nil {
+		return "", err
+	}
+	for _, w := range res.Error.Warnings {
+		log.Printf("[WARN] Deploying library item %q: %s", itemID, w.Error.DefaultMessage)
+	}
+	if !res.Succeeded {
+		var errs []string
+		for _, e := range res.Error.Errors {
+			errs = append(errs, e.Error.DefaultMessage)
+		}
+		return "", fmt.Errorf("deployment failed: %s", strings.Join(errs, ", "))
+	}
+	if res.ResourceID.Type != "VirtualMachine" {
+		return "", fmt.Errorf("library item deployed to a %s, only virtual machines are supported", res.ResourceID.Type)
+	}
+	return res.ResourceID.ID, nil
+}
@@ -0,0 +1,57 @@
+package vsphere
+
+import "github.com/hashicorp/terraform/helper/schema"
+
+func dataSourceVSphereContentLibraryItem() *schema.Resource {
+	return &schema.Resource{
+		Read: dataSourceVSphereContentLibraryItemRead,
+		Schema: map[string]*schema.Schema{
+			"name": {
+				Type:        schema.TypeString,
+				Description: "The name of the library item.",
+				Required:    true,
+			},
+			"library_id": {
+				Type:        schema.TypeString,
+				Description: "The ID of the content library the item is in.",
+				Required:    true,
+			},
+			"description": {
+				Type:        schema.TypeString,
+				Description: "The description of the library item.",
+				Computed:    true,
+			},
+			"type": {
+				Type:        schema.TypeString,
+				Description: "The type of the library item, such as ovf or iso.",
+				Computed:    true,
+			},
+			"size": {
+				Type:        schema.TypeInt,
+				Description: "The size of the content of the library item, in bytes.",
+				Computed:    true,
+			},
+		},
+	}
+}
+
+func dataSourceVSphereContentLibraryItemRead(d *schema.ResourceData, meta interface{}) error {
+	clc, err := meta.(*VSphereClient).ContentLibraryClient()
+	if err != nil {
+		return err
+	}
+
+	name := d.Get("name").(string)
+	libraryID := d.Get("library_id").(string)
+
+	item, err := contentLibraryItemByName(clc, libraryID, name)
+	if err != nil {
+		return err
+	}
+
+	d.SetId(item.ID)
+	d.Set("description", item.Description)
+	d.Set("type", item.Type)
+	d.Set("size", item.Size)
+	return nil
+}
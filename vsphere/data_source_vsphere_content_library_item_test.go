@@ -0,0 +1,63 @@
+package vsphere
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/hashicorp/terraform/helper/resource"
+)
+
+func TestAccDataSourceVSphereContentLibraryItem(t *testing.T) {
+	var tp *testing.T
+	testAccDataSourceVSphereContentLibraryItemCases := []struct {
+		name     string
+		testCase resource.TestCase
+	}{
+		{
+			"basic",
+			resource.TestCase{
+				PreCheck: func() {
+					testAccPreCheck(tp)
+					testAccResourceVSphereContentLibraryItemPreCheck(tp)
+				},
+				Providers: testAccProviders,
+				Steps: []resource.TestStep{
+					{
+						Config: testAccDataSourceVSphereContentLibraryItemConfig(),
+						Check: resource.ComposeTestCheckFunc(
+							resource.TestCheckResourceAttrPair(
+								"data.vsphere_content_library_item.item", "id",
+								"vsphere_content_library_item.item", "id",
+							),
+							resource.TestCheckResourceAttr(
+								"data.vsphere_content_library_item.item",
+								"type",
+								contentLibraryItemTypeOVF,
+							),
+						),
+					},
+				},
+			},
+		},
+	}
+
+	for _, tc := range testAccDataSourceVSphereContentLibraryItemCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tp = t
+			resource.Test(t, tc.testCase)
+		})
+	}
+}
+
+func testAccDataSourceVSphereContentLibraryItemConfig() string {
+	return fmt.Sprintf(`
+%s
+
+data "vsphere_content_library_item" "item" {
+  name       = "${vsphere_content_library_item.item.name}"
+  library_id = "${vsphere_content_library.library.id}"
+}
+`,
+		testAccResourceVSphereContentLibraryItemConfig("terraform-test-item"),
+	)
+}
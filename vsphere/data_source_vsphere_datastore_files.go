@@ -0,0 +1,294 @@
+package vsphere
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"time"
+
+	"github.com/hashicorp/terraform/helper/schema"
+	"github.com/hashicorp/terraform/helper/validation"
+	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+const (
+	datastoreFileTypeFile           = "file"
+	datastoreFileTypeFolder         = "folder"
+	datastoreFileTypeFloppyImage    = "floppy_image"
+	datastoreFileTypeIsoImage       = "iso_image"
+	datastoreFileTypeTemplateConfig = "template_config"
+	datastoreFileTypeVMConfig       = "vm_config"
+	datastoreFileTypeVMDisk         = "vm_disk"
+	datastoreFileTypeVMLog          = "vm_log"
+	datastoreFileTypeVMNvram        = "vm_nvram"
+
+	datastoreFilesSortByPath             = "path"
+	datastoreFilesSortByModificationTime = "modification_time"
+	datastoreFilesSortBySize             = "size"
+)
+
+var datastoreFileTypeAllowedValues = []string{
+	datastoreFileTypeFile,
+	datastoreFileTypeFolder,
+	datastoreFileTypeFloppyImage,
+	datastoreFileTypeIsoImage,
+	datastoreFileTypeTemplateConfig,
+	datastoreFileTypeVMConfig,
+	datastoreFileTypeVMDisk,
+	datastoreFileTypeVMLog,
+	datastoreFileTypeVMNvram,
+}
+
+var datastoreFilesSortByAllowedValues = []string{
+	datastoreFilesSortByPath,
+	datastoreFilesSortByModificationTime,
+	datastoreFilesSortBySize,
+}
+
+func dataSourceVSphereDatastoreFiles() *schema.Resource {
+	return &schema.Resource{
+		Read: dataSourceVSphereDatastoreFilesRead,
+
+		Schema: map[string]*schema.Schema{
+			"datastore": &schema.Schema{
+				Type:        schema.TypeString,
+				Description: "The name or path of the datastore to search.",
+				Required:    true,
+			},
+			"datacenter_id": &schema.Schema{
+				Type:        schema.TypeString,
+				Description: "The managed object ID of the datacenter the datastore is in. This is required if the supplied path is not an absolute path containing a datacenter and there are multiple datacenters in your infrastructure.",
+				Optional:    true,
+			},
+			"path": &schema.Schema{
+				Type:        schema.TypeString,
+				Description: "The path of the folder on the datastore to search in. Defaults to the root of the datastore.",
+				Optional:    true,
+			},
+			"recursive": &schema.Schema{
+				Type:        schema.TypeBool,
+				Description: "Search all sub-folders of path as well.",
+				Optional:    true,
+			},
+			"match_patterns": &schema.Schema{
+				Type:        schema.TypeList,
+				Description: "Glob patterns to match file names against, such as *.iso. Files matching any of the patterns are returned.",
+				Optional:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+			},
+			"file_types": &schema.Schema{
+				Type:        schema.TypeList,
+				Description: "The types of files to search for. If not defined, all files are returned.",
+				Optional:    true,
+				Elem: &schema.Schema{
+					Type:         schema.TypeString,
+					ValidateFunc: validation.StringInSlice(datastoreFileTypeAllowedValues, false),
+				},
+			},
+			"sort_by": &schema.Schema{
+				Type:         schema.TypeString,
+				Description:  "The attribute to sort the files by. Can be one of path, modification_time, or size.",
+				Optional:     true,
+				Default:      datastoreFilesSortByPath,
+				ValidateFunc: validation.StringInSlice(datastoreFilesSortByAllowedValues, false),
+			},
+			"sort_descending": &schema.Schema{
+				Type:        schema.TypeBool,
+				Description: "Sort the files in descending order.",
+				Optional:    true,
+			},
+			"files": &schema.Schema{
+				Type:        schema.TypeList,
+				Description: "The files found by the search.",
+				Computed:    true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"path": &schema.Schema{
+							Type:        schema.TypeString,
+							Description: "The path of the file, relative to the root of the datastore.",
+							Computed:    true,
+						},
+						"datastore_path": &schema.Schema{
+							Type:        schema.TypeString,
+							Description: "The full path of the file, in [datastore] path format.",
+							Computed:    true,
+						},
+						"type": &schema.Schema{
+							Type:        schema.TypeString,
+							Description: "The type of the file.",
+							Computed:    true,
+						},
+						"size": &schema.Schema{
+							Type:        schema.TypeInt,
+							Description: "The size of the file, in bytes.",
+							Computed:    true,
+						},
+						"modification_time": &schema.Schema{
+							Type:        schema.TypeString,
+							Description: "The time the file was last modified, in RFC3339 format.",
+							Computed:    true,
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// datastoreFile represents a single file found in a datastore search.
+type datastoreFile struct {
+	path         object.DatastorePath
+	fileType     string
+	size         int64
+	modification time.Time
+}
+
+func dataSourceVSphereDatastoreFilesRead(d *schema.ResourceData, meta interface{}) error {
+	client := meta.(*VSphereClient).vimClient
+	var dc *object.Datacenter
+	if dcID, ok := d.GetOk("datacenter_id"); ok {
+		var err error
+		dc, err = datacenterFromID(client, dcID.(string))
+		if err != nil {
+			return fmt.Errorf("cannot locate datacenter: %s", err)
+		}
+	}
+	ds, err := datastoreFromPath(client, d.Get("datastore").(string), dc)
+	if err != nil {
+		return fmt.Errorf("error fetching datastore: %s", err)
+	}
+
+	spec := &types.HostDatastoreBrowserSearchSpec{
+		MatchPattern: sliceInterfacesToStrings(d.Get("match_patterns").([]interface{})),
+		Details: &types.FileQueryFlags{
+			FileType:     true,
+			FileSize:     true,
+			Modification: true,
+			FileOwner:    boolPtr(false),
+		},
+	}
+	for _, t := range sliceInterfacesToStrings(d.Get("file_types").([]interface{})) {
+		spec.Query = append(spec.Query, datastoreFileQuery(t))
+	}
+
+	results, err := searchDatastore(ds, ds.Path(d.Get("path").(string)), spec, d.Get("recursive").(bool))
+	if err != nil {
+		return fmt.Errorf("error searching datastore: %s", err)
+	}
+
+	var files []datastoreFile
+	for _, result := range results {
+		var folder object.DatastorePath
+		if !folder.FromString(result.FolderPath) {
+			return fmt.Errorf("could not parse datastore folder path %q", result.FolderPath)
+		}
+		for _, bfi := range result.File {
+			fi := bfi.GetFileInfo()
+			f := datastoreFile{
+				path: object.DatastorePath{
+					Datastore: folder.Datastore,
+					Path:      path.Join(folder.Path, fi.Path),
+				},
+				fileType: datastoreFileType(bfi),
+				size:     fi.FileSize,
+			}
+			if fi.Modification != nil {
+				f.modification = *fi.Modification
+			}
+			files = append(files, f)
+		}
+	}
+	sortDatastoreFiles(files, d.Get("sort_by").(string), d.Get("sort_descending").(bool))
+
+	d.SetId(time.Now().UTC().String())
+
+	var flattened []map[string]interface{}
+	for _, f := range files {
+		m := map[string]interface{}{
+			"path":           f.path.Path,
+			"datastore_path": f.path.String(),
+			"type":           f.fileType,
+			"size":           int(f.size),
+		}
+		if !f.modification.IsZero() {
+			m["modification_time"] = f.modification.UTC().Format(time.RFC3339)
+		}
+		flattened = append(flattened, m)
+	}
+	if err := d.Set("files", flattened); err != nil {
+		return fmt.Errorf("error saving results to state: %s", err)
+	}
+
+	return nil
+}
+
+// datastoreFileQuery returns the FileQuery for the supplied file type.
+func datastoreFileQuery(t string) types.BaseFileQuery {
+	switch t {
+	case datastoreFileTypeFolder:
+		return &types.FolderFileQuery{}
+	case datastoreFileTypeFloppyImage:
+		return &types.FloppyImageFileQuery{}
+	case datastoreFileTypeIsoImage:
+		return &types.IsoImageFileQuery{}
+	case datastoreFileTypeTemplateConfig:
+		return &types.TemplateConfigFileQuery{}
+	case datastoreFileTypeVMConfig:
+		return &types.VmConfigFileQuery{}
+	case datastoreFileTypeVMDisk:
+		return &types.VmDiskFileQuery{}
+	case datastoreFileTypeVMLog:
+		return &types.VmLogFileQuery{}
+	case datastoreFileTypeVMNvram:
+		return &types.VmNvramFileQuery{}
+	}
+	return &types.FileQuery{}
+}
+
+// datastoreFileType returns the file type for the supplied FileInfo, based
+// off its concrete type.
+func datastoreFileType(fi types.BaseFileInfo) string {
+	switch fi.(type) {
+	case *types.FolderFileInfo:
+		return datastoreFileTypeFolder
+	case *types.FloppyImageFileInfo:
+		return datastoreFileTypeFloppyImage
+	case *types.IsoImageFileInfo:
+		return datastoreFileTypeIsoImage
+	case *types.TemplateConfigFileInfo:
+		return datastoreFileTypeTemplateConfig
+	case *types.VmConfigFileInfo:
+		return datastoreFileTypeVMConfig
+	case *types.VmDiskFileInfo:
+		return datastoreFileTypeVMDisk
+	case *types.VmLogFileInfo:
+		return datastoreFileTypeVMLog
+	case *types.VmNvramFileInfo:
+		return datastoreFileTypeVMNvram
+	}
+	return datastoreFileTypeFile
+}
+
+// sortDatastoreFiles sorts the supplied files by the supplied attribute. Ties
+// are broken by path so that the order is stable across reads.
+func sortDatastoreFiles(files []datastoreFile, by string, descending bool) {
+	less := func(i, j int) bool {
+		a, b := files[i], files[j]
+		if descending {
+			a, b = b, a
+		}
+		switch by {
+		case datastoreFilesSortByModificationTime:
+			if !a.modification.Equal(b.modification) {
+				return a.modification.Before(b.modification)
+			}
+		case datastoreFilesSortBySize:
+			if a.size != b.size {
+				return a.size < b.size
+			}
+		}
+		return a.path.String() < b.path.String()
+	}
+	sort.SliceStable(files, less)
+}
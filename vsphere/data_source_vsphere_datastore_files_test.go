@@ -0,0 +1,125 @@
+package vsphere
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/hashicorp/terraform/helper/resource"
+)
+
+const testAccDataSourceVSphereDatastoreFilesSourceFile = "/tmp/tf_test_datastore_files.iso"
+
+func TestAccDataSourceVSphereDatastoreFiles(t *testing.T) {
+	var tp *testing.T
+	testAccDataSourceVSphereDatastoreFilesCases := []struct {
+		name     string
+		testCase resource.TestCase
+	}{
+		{
+			"basic",
+			resource.TestCase{
+				PreCheck: func() {
+					testAccPreCheck(tp)
+					testAccDataSourceVSphereDatastoreFilesPreCheck(tp)
+				},
+				Providers: testAccProviders,
+				Steps: []resource.TestStep{
+					{
+						Config: testAccDataSourceVSphereDatastoreFilesConfig(),
+						Check: resource.ComposeTestCheckFunc(
+							resource.TestCheckOutput("found", "true"),
+						),
+					},
+				},
+			},
+		},
+		{
+			"with pattern and type",
+			resource.TestCase{
+				PreCheck: func() {
+					testAccPreCheck(tp)
+					testAccDataSourceVSphereDatastoreFilesPreCheck(tp)
+				},
+				Providers: testAccProviders,
+				Steps: []resource.TestStep{
+					{
+						Config: testAccDataSourceVSphereDatastoreFilesConfigPattern(),
+						Check: resource.ComposeTestCheckFunc(
+							resource.TestCheckResourceAttr("data.vsphere_datastore_files.files", "files.#", "1"),
+							resource.TestCheckResourceAttr("data.vsphere_datastore_files.files", "files.0.path", "tf_test_datastore_files.iso"),
+							resource.TestCheckResourceAttr("data.vsphere_datastore_files.files", "files.0.type", "iso_image"),
+						),
+					},
+				},
+			},
+		},
+	}
+
+	if err := ioutil.WriteFile(testAccDataSourceVSphereDatastoreFilesSourceFile, []byte("tf_test\n"), 0644); err != nil {
+		t.Fatalf("error writing test file: %s", err)
+	}
+	defer os.Remove(testAccDataSourceVSphereDatastoreFilesSourceFile)
+
+	for _, tc := range testAccDataSourceVSphereDatastoreFilesCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tp = t
+			resource.Test(t, tc.testCase)
+		})
+	}
+}
+
+func testAccDataSourceVSphereDatastoreFilesPreCheck(t *testing.T) {
+	if os.Getenv("VSPHERE_DATACENTER") == "" {
+		t.Skip("set VSPHERE_DATACENTER to run vsphere_datastore_files acceptance tests")
+	}
+	if os.Getenv("VSPHERE_DATASTORE") == "" {
+		t.Skip("set VSPHERE_DATASTORE to run vsphere_datastore_files acceptance tests")
+	}
+}
+
+func testAccDataSourceVSphereDatastoreFilesConfig() string {
+	return fmt.Sprintf(`
+data "vsphere_datacenter" "datacenter" {
+  name = "%s"
+}
+
+data "vsphere_datastore_files" "files" {
+  datacenter_id = "${data.vsphere_datacenter.datacenter.id}"
+  datastore     = "%s"
+  file_types    = ["folder"]
+}
+
+output "found" {
+  value = "${length(data.vsphere_datastore_files.files.files) > 0 ? "true" : "false" }"
+}
+`, os.Getenv("VSPHERE_DATACENTER"), os.Getenv("VSPHERE_DATASTORE"))
+}
+
+func testAccDataSourceVSphereDatastoreFilesConfigPattern() string {
+	return fmt.Sprintf(`
+variable "datastore" {
+  type    = "string"
+  default = "%s"
+}
+
+data "vsphere_datacenter" "datacenter" {
+  name = "%s"
+}
+
+resource "vsphere_file" "file" {
+  datacenter       = "${data.vsphere_datacenter.datacenter.name}"
+  datastore        = "${var.datastore}"
+  source_file      = "%s"
+  destination_file = "tf_test_datastore_files.iso"
+}
+
+data "vsphere_datastore_files" "files" {
+  datacenter_id  = "${data.vsphere_datacenter.datacenter.id}"
+  datastore      = "${var.datastore}"
+  match_patterns = ["${vsphere_file.file.destination_file}"]
+  file_types     = ["iso_image"]
+}
+`, os.Getenv("VSPHERE_DATASTORE"), os.Getenv("VSPHERE_DATACENTER"), testAccDataSourceVSphereDatastoreFilesSourceFile)
+}
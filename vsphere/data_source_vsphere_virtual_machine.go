@@ -0,0 +1,193 @@
+package vsphere
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/terraform/helper/schema"
+	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+func dataSourceVSphereVirtualMachine() *schema.Resource {
+	return &schema.Resource{
+		Read: dataSourceVSphereVirtualMachineRead,
+
+		Schema: map[string]*schema.Schema{
+			"name": &schema.Schema{
+				Type:        schema.TypeString,
+				Description: "The name or path of the virtual machine or template.",
+				Required:    true,
+			},
+			"datacenter_id": &schema.Schema{
+				Type:        schema.TypeString,
+				Description: "The managed object ID of the datacenter the virtual machine is in. This is not required when using ESXi directly, or if there is only one datacenter in your infrastructure.",
+				Optional:    true,
+			},
+			"uuid": &schema.Schema{
+				Type:        schema.TypeString,
+				Description: "The UUID of the virtual machine.",
+				Computed:    true,
+			},
+			"template": &schema.Schema{
+				Type:        schema.TypeBool,
+				Description: "True if the virtual machine is a template.",
+				Computed:    true,
+			},
+			"guest_id": &schema.Schema{
+				Type:        schema.TypeString,
+				Description: "The guest ID of the virtual machine.",
+				Computed:    true,
+			},
+			"firmware": &schema.Schema{
+				Type:        schema.TypeString,
+				Description: "The firmware type of the virtual machine. Either bios or efi.",
+				Computed:    true,
+			},
+			"num_cpus": &schema.Schema{
+				Type:        schema.TypeInt,
+				Description: "The number of virtual CPUs of the virtual machine.",
+				Computed:    true,
+			},
+			"num_cores_per_socket": &schema.Schema{
+				Type:        schema.TypeInt,
+				Description: "The number of cores per virtual CPU socket of the virtual machine.",
+				Computed:    true,
+			},
+			"memory": &schema.Schema{
+				Type:        schema.TypeInt,
+				Description: "The amount of memory of the virtual machine, in MB.",
+				Computed:    true,
+			},
+			"scsi_type": &schema.Schema{
+				Type:        schema.TypeString,
+				Description: "The type of the SCSI controllers of the virtual machine. Can be one of lsilogic, lsilogic-sas, pvscsi, buslogic, or mixed.",
+				Computed:    true,
+			},
+			"network_interface_types": &schema.Schema{
+				Type:        schema.TypeList,
+				Description: "The adapter types of the network interfaces of the virtual machine, in the order they appear in the configuration of the virtual machine.",
+				Computed:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+			},
+			"disks": &schema.Schema{
+				Type:        schema.TypeList,
+				Description: "The virtual disks of the virtual machine, in the order they appear in the configuration of the virtual machine.",
+				Computed:    true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"label": &schema.Schema{
+							Type:        schema.TypeString,
+							Description: "The label of the disk.",
+							Computed:    true,
+						},
+						"size": &schema.Schema{
+							Type:        schema.TypeInt,
+							Description: "The size of the disk, in GB.",
+							Computed:    true,
+						},
+						"eagerly_scrub": &schema.Schema{
+							Type:        schema.TypeBool,
+							Description: "True if the disk is eager zeroed.",
+							Computed:    true,
+						},
+						"thin_provisioned": &schema.Schema{
+							Type:        schema.TypeBool,
+							Description: "True if the disk is thin provisioned.",
+							Computed:    true,
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func dataSourceVSphereVirtualMachineRead(d *schema.ResourceData, meta interface{}) error {
+	client := meta.(*VSphereClient).vimClient
+	var dc *object.Datacenter
+	if dcID, ok := d.GetOk("datacenter_id"); ok {
+		var err error
+		dc, err = datacenterFromID(client, dcID.(string))
+		if err != nil {
+			return fmt.Errorf("cannot locate datacenter: %s", err)
+		}
+	}
+	vm, err := virtualMachineFromPath(client, d.Get("name").(string), dc)
+	if err != nil {
+		return fmt.Errorf("error fetching virtual machine: %s", err)
+	}
+	props, err := virtualMachineProperties(vm)
+	if err != nil {
+		return fmt.Errorf("error fetching virtual machine properties: %s", err)
+	}
+	if props.Config == nil {
+		return fmt.Errorf("virtual machine %q has no configuration", vm.InventoryPath)
+	}
+
+	d.SetId(props.Config.Uuid)
+	d.Set("uuid", props.Config.Uuid)
+	d.Set("template", props.Config.Template)
+	d.Set("guest_id", props.Config.GuestId)
+	d.Set("firmware", props.Config.Firmware)
+	d.Set("num_cpus", props.Config.Hardware.NumCPU)
+	d.Set("num_cores_per_socket", props.Config.Hardware.NumCoresPerSocket)
+	d.Set("memory", props.Config.Hardware.MemoryMB)
+
+	devices := object.VirtualDeviceList(props.Config.Hardware.Device)
+	d.Set("scsi_type", virtualMachineSCSIType(devices))
+	var nics []string
+	for _, device := range devices.SelectByType((*types.VirtualEthernetCard)(nil)) {
+		nics = append(nics, virtualEthernetCardType(device))
+	}
+	if err := d.Set("network_interface_types", nics); err != nil {
+		return fmt.Errorf("error setting network interface types: %s", err)
+	}
+	if err := d.Set("disks", flattenVirtualMachineDisks(devices)); err != nil {
+		return fmt.Errorf("error setting disks: %s", err)
+	}
+	return nil
+}
+
+// virtualMachineSCSIType returns the type of the SCSI controllers in the
+// supplied device list, or "mixed" if there is more than one type. An empty
+// string is returned if there are no SCSI controllers.
+func virtualMachineSCSIType(devices object.VirtualDeviceList) string {
+	var scsiType string
+	for _, device := range devices.SelectByType((*types.VirtualSCSIController)(nil)) {
+		t := devices.Type(device)
+		switch {
+		case scsiType == "":
+			scsiType = t
+		case scsiType != t:
+			return "mixed"
+		}
+	}
+	return scsiType
+}
+
+// flattenVirtualMachineDisks converts the virtual disks in the supplied device
+// list to the disks attribute of the vsphere_virtual_machine data source.
+func flattenVirtualMachineDisks(devices object.VirtualDeviceList) []interface{} {
+	var disks []interface{}
+	for _, device := range devices.SelectByType((*types.VirtualDisk)(nil)) {
+		disk := device.(*types.VirtualDisk)
+		m := map[string]interface{}{
+			"size":             int(disk.CapacityInKB / 1024 / 1024),
+			"eagerly_scrub":    false,
+			"thin_provisioned": false,
+		}
+		if info := disk.GetVirtualDevice().DeviceInfo; info != nil {
+			m["label"] = info.GetDescription().Label
+		}
+		if backing, ok := disk.Backing.(*types.VirtualDiskFlatVer2BackingInfo); ok {
+			if backing.EagerlyScrub != nil {
+				m["eagerly_scrub"] = *backing.EagerlyScrub
+			}
+			if backing.ThinProvisioned != nil {
+				m["thin_provisioned"] = *backing.ThinProvisioned
+			}
+		}
+		disks = append(disks, m)
+	}
+	return disks
+}
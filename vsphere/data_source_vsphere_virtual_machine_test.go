@@ -0,0 +1,76 @@
+package vsphere
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"testing"
+
+	"github.com/hashicorp/terraform/helper/resource"
+)
+
+func TestAccDataSourceVSphereVirtualMachine(t *testing.T) {
+	var tp *testing.T
+	testAccDataSourceVSphereVirtualMachineCases := []struct {
+		name     string
+		testCase resource.TestCase
+	}{
+		{
+			"basic",
+			resource.TestCase{
+				PreCheck: func() {
+					testAccPreCheck(tp)
+					testAccDataSourceVSphereVirtualMachinePreCheck(tp)
+				},
+				Providers: testAccProviders,
+				Steps: []resource.TestStep{
+					{
+						Config: testAccDataSourceVSphereVirtualMachineConfig(),
+						Check: resource.ComposeTestCheckFunc(
+							resource.TestMatchResourceAttr("data.vsphere_virtual_machine.template", "uuid", regexp.MustCompile("[0-9a-f]{8}-([0-9a-f]{4}-){3}[0-9a-f]{12}")),
+							resource.TestCheckResourceAttrSet("data.vsphere_virtual_machine.template", "guest_id"),
+							resource.TestCheckResourceAttrSet("data.vsphere_virtual_machine.template", "firmware"),
+							resource.TestCheckResourceAttrSet("data.vsphere_virtual_machine.template", "num_cpus"),
+							resource.TestCheckResourceAttrSet("data.vsphere_virtual_machine.template", "memory"),
+							resource.TestCheckResourceAttrSet("data.vsphere_virtual_machine.template", "scsi_type"),
+							resource.TestCheckResourceAttrSet("data.vsphere_virtual_machine.template", "network_interface_types.#"),
+							resource.TestCheckResourceAttrSet("data.vsphere_virtual_machine.template", "disks.0.size"),
+						),
+					},
+				},
+			},
+		},
+	}
+
+	for _, tc := range testAccDataSourceVSphereVirtualMachineCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tp = t
+			resource.Test(t, tc.testCase)
+		})
+	}
+}
+
+func testAccDataSourceVSphereVirtualMachinePreCheck(t *testing.T) {
+	if os.Getenv("VSPHERE_DATACENTER") == "" {
+		t.Skip("set VSPHERE_DATACENTER to run vsphere_virtual_machine data source acceptance tests")
+	}
+	if os.Getenv("VSPHERE_TEMPLATE") == "" {
+		t.Skip("set VSPHERE_TEMPLATE to run vsphere_virtual_machine data source acceptance tests")
+	}
+}
+
+func testAccDataSourceVSphereVirtualMachineConfig() string {
+	return fmt.Sprintf(`
+data "vsphere_datacenter" "dc" {
+  name = "%s"
+}
+
+data "vsphere_virtual_machine" "template" {
+  name          = "%s"
+  datacenter_id = "${data.vsphere_datacenter.dc.id}"
+}
+`,
+		os.Getenv("VSPHERE_DATACENTER"),
+		os.Getenv("VSPHERE_TEMPLATE"),
+	)
+}
@@ -9,8 +9,6 @@ import (
 
 	"github.com/hashicorp/terraform/helper/schema"
 	"github.com/hashicorp/terraform/helper/validation"
-	"github.com/vmware/govmomi/vim25/mo"
-	"github.com/vmware/govmomi/vim25/types"
 )
 
 func dataSourceVSphereVmfsDisks() *schema.Resource {
@@ -60,21 +58,17 @@ func dataSourceVSphereVmfsDisksRead(d *schema.ResourceData, meta interface{}) er
 		}
 	}
 
-	var hss mo.HostStorageSystem
-	ctx, cancel := context.WithTimeout(context.Background(), defaultAPITimeout)
-	defer cancel()
-	if err := ss.Properties(ctx, ss.Reference(), nil, &hss); err != nil {
-		return fmt.Errorf("error querying storage system properties: %s", err)
+	scsiDisks, err := hostScsiDisks(ss)
+	if err != nil {
+		return err
 	}
 
 	d.SetId(time.Now().UTC().String())
 
 	var disks []string
-	for _, sl := range hss.StorageDeviceInfo.ScsiLun {
-		if hsd, ok := sl.(*types.HostScsiDisk); ok {
-			if matched, _ := regexp.MatchString(d.Get("filter").(string), hsd.CanonicalName); matched {
-				disks = append(disks, hsd.CanonicalName)
-			}
+	for _, hsd := range scsiDisks {
+		if matched, _ := regexp.MatchString(d.Get("filter").(string), hsd.CanonicalName); matched {
+			disks = append(disks, hsd.CanonicalName)
 		}
 	}
 
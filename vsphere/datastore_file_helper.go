@@ -0,0 +1,193 @@
+package vsphere
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
+	"net/url"
+	"os"
+	"time"
+
+	"github.com/vmware/govmomi"
+	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/vim25/progress"
+	"github.com/vmware/govmomi/vim25/soap"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+const (
+	// datastoreFileUploadRetries is the number of times an upload to a
+	// datastore is retried after a failure before giving up.
+	datastoreFileUploadRetries = 3
+
+	// datastoreFileUploadRetryWait is the base amount of time to wait between
+	// upload retries. The wait is multiplied by the number of the attempt.
+	datastoreFileUploadRetryWait = 5 * time.Second
+
+	// datastoreFileUploadProgressInterval is the percentage interval at which
+	// upload progress is logged.
+	datastoreFileUploadProgressInterval = 10
+)
+
+// fileSHA256 returns the hex-encoded SHA-256 checksum of the local file at
+// the supplied path.
+func fileSHA256(name string) (string, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// uploadDatastoreFile streams the local file at the supplied path to the
+// supplied datastore URL, logging progress as it goes. Failed uploads are
+// retried up to datastoreFileUploadRetries times. The SHA-256 checksum of the
+// uploaded data is returned on success.
+func uploadDatastoreFile(client *govmomi.Client, name string, u *url.URL) (string, error) {
+	var err error
+	for attempt := 0; attempt <= datastoreFileUploadRetries; attempt++ {
+		if attempt > 0 {
+			wait := datastoreFileUploadRetryWait * time.Duration(attempt)
+			log.Printf("[WARN] Upload of %q failed: %s. Retrying in %s (attempt %d of %d)", name, err, wait, attempt, datastoreFileUploadRetries)
+			time.Sleep(wait)
+		}
+		var sum string
+		sum, err = uploadDatastoreFileOnce(client, name, u)
+		if err == nil {
+			return sum, nil
+		}
+		if os.IsNotExist(err) || os.IsPermission(err) {
+			// Local errors will not go away on a retry.
+			break
+		}
+	}
+	return "", fmt.Errorf("error uploading %q: %s", name, err)
+}
+
+// uploadDatastoreFileOnce performs a single upload attempt for
+// uploadDatastoreFile.
+func uploadDatastoreFileOnce(client *govmomi.Client, name string, u *url.URL) (string, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	s, err := f.Stat()
+	if err != nil {
+		return "", err
+	}
+
+	h := sha256.New()
+	p := soap.DefaultUpload
+	p.ContentLength = s.Size()
+	p.Progress = newUploadProgressLogger(name)
+	log.Printf("[DEBUG] Uploading %q (%d bytes) to %q", name, s.Size(), u.Path)
+	if err := client.Client.Upload(io.TeeReader(f, h), u, &p); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// newUploadProgressLogger returns a progress.Sinker that logs the progress of
+// an upload of the supplied file every datastoreFileUploadProgressInterval
+// percent.
+func newUploadProgressLogger(name string) progress.Sinker {
+	return progress.SinkFunc(func() chan<- progress.Report {
+		ch := make(chan progress.Report)
+		go func() {
+			next := float32(datastoreFileUploadProgressInterval)
+			for r := range ch {
+				if r.Error() != nil {
+					continue
+				}
+				if pct := r.Percentage(); pct >= next {
+					log.Printf("[INFO] Uploading %q: %.0f%% complete (%s)", name, pct, r.Detail())
+					for next <= pct {
+						next += datastoreFileUploadProgressInterval
+					}
+				}
+			}
+		}()
+		return ch
+	})
+}
+
+// datastoreDirectoryExists checks to see if a directory exists at the
+// supplied path on the supplied datastore. An error is returned if the path
+// exists, but is not a directory.
+func datastoreDirectoryExists(ds *object.Datastore, name string) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), defaultAPITimeout)
+	defer cancel()
+	fi, err := ds.Stat(ctx, name)
+	if err != nil {
+		switch err.(type) {
+		case object.DatastoreNoSuchFileError, object.DatastoreNoSuchDirectoryError:
+			return false, nil
+		}
+		return false, err
+	}
+	if _, ok := fi.(*types.FolderFileInfo); !ok {
+		return false, fmt.Errorf("%s exists, but is not a directory", ds.Path(name))
+	}
+	return true, nil
+}
+
+// datastoreDirectoryIsEmpty returns true if the directory at the supplied path
+// on the supplied datastore has no files or sub-directories in it.
+func datastoreDirectoryIsEmpty(ds *object.Datastore, name string) (bool, error) {
+	results, err := searchDatastore(ds, ds.Path(name), &types.HostDatastoreBrowserSearchSpec{}, false)
+	if err != nil {
+		return false, err
+	}
+	for _, result := range results {
+		if len(result.File) > 0 {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// makeDatastoreDirectory creates a directory at the supplied path on the
+// supplied datastore, creating any missing parent directories as well.
+func makeDatastoreDirectory(client *govmomi.Client, ds *object.Datastore, dc *object.Datacenter, name string) error {
+	fm := object.NewFileManager(client.Client)
+	ctx, cancel := context.WithTimeout(context.Background(), defaultAPITimeout)
+	defer cancel()
+	return fm.MakeDirectory(ctx, ds.Path(name), dc, true)
+}
+
+// moveDatastoreFile moves a file or directory on the supplied datastore from
+// one path to another, and waits for the operation to complete.
+func moveDatastoreFile(client *govmomi.Client, ds *object.Datastore, dc *object.Datacenter, oldName, newName string) error {
+	fm := object.NewFileManager(client.Client)
+	ctx, cancel := context.WithTimeout(context.Background(), defaultAPITimeout)
+	defer cancel()
+	task, err := fm.MoveDatastoreFile(ctx, ds.Path(oldName), dc, ds.Path(newName), dc, false)
+	if err != nil {
+		return err
+	}
+	return task.Wait(ctx)
+}
+
+// deleteDatastoreFile deletes a file or directory on the supplied datastore,
+// and waits for the operation to complete. Directories are deleted along with
+// all of their contents.
+func deleteDatastoreFile(client *govmomi.Client, ds *object.Datastore, dc *object.Datacenter, name string) error {
+	fm := object.NewFileManager(client.Client)
+	ctx, cancel := context.WithTimeout(context.Background(), defaultAPITimeout)
+	defer cancel()
+	task, err := fm.DeleteDatastoreFile(ctx, ds.Path(name), dc)
+	if err != nil {
+		return err
+	}
+	return task.Wait(ctx)
+}
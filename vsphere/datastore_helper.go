@@ -2,14 +2,25 @@ package vsphere
 
 import (
 	"context"
+	"fmt"
+	"log"
+	"time"
 
+	"github.com/hashicorp/terraform/helper/schema"
 	"github.com/vmware/govmomi"
 	"github.com/vmware/govmomi/find"
 	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/property"
+	"github.com/vmware/govmomi/view"
+	"github.com/vmware/govmomi/vim25/methods"
 	"github.com/vmware/govmomi/vim25/mo"
 	"github.com/vmware/govmomi/vim25/types"
 )
 
+// defaultDatastoreMaintenanceModeTimeout is the default time, in minutes, to
+// wait for a datastore to enter or exit maintenance mode.
+const defaultDatastoreMaintenanceModeTimeout = 30
+
 // datastoreFromID locates a Datastore by its managed object reference ID.
 func datastoreFromID(client *govmomi.Client, id string) (*object.Datastore, error) {
 	finder := find.NewFinder(client.Client, false)
@@ -69,3 +80,250 @@ func moveDatastoreToFolderRelativeHostSystemID(client *govmomi.Client, ds *objec
 	}
 	return moveObjectToFolder(ds.Reference(), folder)
 }
+
+// datastoreInMaintenanceMode returns true if the supplied datastore summary
+// denotes that the datastore is in, or is entering, maintenance mode.
+func datastoreInMaintenanceMode(summary *types.DatastoreSummary) bool {
+	switch types.DatastoreSummaryMaintenanceModeState(summary.MaintenanceMode) {
+	case types.DatastoreSummaryMaintenanceModeStateEnteringMaintenance, types.DatastoreSummaryMaintenanceModeStateInMaintenance:
+		return true
+	}
+	return false
+}
+
+// enterDatastoreMaintenanceMode puts a datastore into maintenance mode and
+// waits for the operation to complete, up to the supplied timeout. Any
+// evacuation recommendations that come back from Storage DRS are applied
+// first.
+//
+// The maintenance mode task only completes when all virtual machines and
+// templates have been evacuated from the datastore, so the timeout here needs
+// to account for evacuation.
+func enterDatastoreMaintenanceMode(client *govmomi.Client, ds *object.Datastore, timeout time.Duration) error {
+	req := types.DatastoreEnterMaintenanceMode{
+		This: ds.Reference(),
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), defaultAPITimeout)
+	defer cancel()
+	res, err := methods.DatastoreEnterMaintenanceMode(ctx, client.Client, &req)
+	if err != nil {
+		return err
+	}
+
+	result := res.Returnval
+	if result.DrsFault != nil && len(result.DrsFault.FaultsByVm) > 0 {
+		return fmt.Errorf("Storage DRS could not generate recommendations to evacuate %d virtual machine(s)", len(result.DrsFault.FaultsByVm))
+	}
+	if len(result.Recommendations) > 0 {
+		var keys []string
+		for _, r := range result.Recommendations {
+			keys = append(keys, r.Key)
+		}
+		log.Printf("[DEBUG] Applying %d Storage DRS recommendation(s) to evacuate datastore %q", len(keys), ds.Name())
+		srm := object.NewStorageResourceManager(client.Client)
+		actx, acancel := context.WithTimeout(context.Background(), defaultAPITimeout)
+		defer acancel()
+		task, err := srm.ApplyStorageDrsRecommendation(actx, keys)
+		if err != nil {
+			return fmt.Errorf("error applying Storage DRS recommendations: %s", err)
+		}
+		tctx, tcancel := context.WithTimeout(context.Background(), timeout)
+		defer tcancel()
+		if err := task.Wait(tctx); err != nil {
+			return fmt.Errorf("error applying Storage DRS recommendations: %s", err)
+		}
+	}
+	if result.Task == nil {
+		return nil
+	}
+
+	log.Printf("[DEBUG] Waiting for datastore %q to enter maintenance mode", ds.Name())
+	t := object.NewTask(client.Client, *result.Task)
+	tctx, tcancel := context.WithTimeout(context.Background(), timeout)
+	defer tcancel()
+	return t.Wait(tctx)
+}
+
+// exitDatastoreMaintenanceMode takes a datastore out of maintenance mode and
+// waits for the operation to complete, up to the supplied timeout.
+func exitDatastoreMaintenanceMode(client *govmomi.Client, ds *object.Datastore, timeout time.Duration) error {
+	req := types.DatastoreExitMaintenanceMode_Task{
+		This: ds.Reference(),
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), defaultAPITimeout)
+	defer cancel()
+	res, err := methods.DatastoreExitMaintenanceMode_Task(ctx, client.Client, &req)
+	if err != nil {
+		return err
+	}
+
+	t := object.NewTask(client.Client, res.Returnval)
+	tctx, tcancel := context.WithTimeout(context.Background(), timeout)
+	defer tcancel()
+	return t.Wait(tctx)
+}
+
+// configureDatastoreIORM applies the supplied Storage I/O Control
+// configuration to a datastore. This is only supported on vCenter.
+func configureDatastoreIORM(client *govmomi.Client, ds *object.Datastore, spec *types.StorageIORMConfigSpec) error {
+	if err := validateVirtualCenter(client); err != nil {
+		return err
+	}
+	srm := object.NewStorageResourceManager(client.Client)
+	ctx, cancel := context.WithTimeout(context.Background(), defaultAPITimeout)
+	defer cancel()
+	task, err := srm.ConfigureDatastoreIORM(ctx, ds, *spec, "")
+	if err != nil {
+		return err
+	}
+	tctx, tcancel := context.WithTimeout(context.Background(), defaultAPITimeout)
+	defer tcancel()
+	return task.Wait(tctx)
+}
+
+// processDatastoreIORMDiff applies any pending Storage I/O Control changes in
+// the supplied ResourceData to the datastore.
+func processDatastoreIORMDiff(client *govmomi.Client, d *schema.ResourceData, ds *object.Datastore) error {
+	if !storageIORMConfigSpecChanged(d) {
+		return nil
+	}
+	if err := configureDatastoreIORM(client, ds, expandStorageIORMConfigSpec(d)); err != nil {
+		return fmt.Errorf("error configuring Storage I/O Control: %s", err)
+	}
+	return nil
+}
+
+// processDatastoreMaintenanceModeDiff enters or exits maintenance mode on the
+// datastore if maintenance_mode_enabled has changed in the supplied
+// ResourceData.
+func processDatastoreMaintenanceModeDiff(client *govmomi.Client, d *schema.ResourceData, ds *object.Datastore) error {
+	if !d.HasChange("maintenance_mode_enabled") {
+		return nil
+	}
+	timeout := time.Minute * time.Duration(d.Get("maintenance_mode_timeout").(int))
+	if d.Get("maintenance_mode_enabled").(bool) {
+		if err := enterDatastoreMaintenanceMode(client, ds, timeout); err != nil {
+			return fmt.Errorf("error entering maintenance mode: %s", err)
+		}
+		return nil
+	}
+	if err := exitDatastoreMaintenanceMode(client, ds, timeout); err != nil {
+		return fmt.Errorf("error exiting maintenance mode: %s", err)
+	}
+	return nil
+}
+
+// datastoreFromPath loads a datastore via its name or path.
+//
+// Datacenter is optional here - if not provided, it's expected that the path
+// is sufficient enough for finder to determine the datacenter required.
+func datastoreFromPath(client *govmomi.Client, name string, dc *object.Datacenter) (*object.Datastore, error) {
+	finder := find.NewFinder(client.Client, false)
+	if dc != nil {
+		finder.SetDatacenter(dc)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), defaultAPITimeout)
+	defer cancel()
+	return finder.Datastore(ctx, name)
+}
+
+// searchDatastore runs a datastore browser search on the supplied datastore
+// path, which should be in the [datastore] path format. If recursive is true,
+// all sub-folders of the path are searched as well.
+func searchDatastore(ds *object.Datastore, path string, spec *types.HostDatastoreBrowserSearchSpec, recursive bool) ([]types.HostDatastoreBrowserSearchResults, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), defaultAPITimeout)
+	defer cancel()
+	browser, err := ds.Browser(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error loading datastore browser: %s", err)
+	}
+
+	var task *object.Task
+	if recursive {
+		task, err = browser.SearchDatastoreSubFolders(ctx, path, spec)
+	} else {
+		task, err = browser.SearchDatastore(ctx, path, spec)
+	}
+	if err != nil {
+		return nil, err
+	}
+	info, err := task.WaitForResult(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	switch r := info.Result.(type) {
+	case types.ArrayOfHostDatastoreBrowserSearchResults:
+		return r.HostDatastoreBrowserSearchResults, nil
+	case types.HostDatastoreBrowserSearchResults:
+		return []types.HostDatastoreBrowserSearchResults{r}, nil
+	}
+	return nil, fmt.Errorf("unexpected search result type %T", info.Result)
+}
+
+// datastoreFromName locates a datastore by its name alone, searching all
+// datacenters. An error is returned if the name matches more than one
+// datastore.
+func datastoreFromName(client *govmomi.Client, name string) (*object.Datastore, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), defaultAPITimeout)
+	defer cancel()
+	m := view.NewManager(client.Client)
+	v, err := m.CreateContainerView(ctx, client.ServiceContent.RootFolder, []string{"Datastore"}, true)
+	if err != nil {
+		return nil, err
+	}
+	defer v.Destroy(ctx)
+
+	refs, err := v.Find(ctx, []string{"Datastore"}, property.Filter{"name": name})
+	if err != nil {
+		return nil, err
+	}
+	switch len(refs) {
+	case 0:
+		return nil, fmt.Errorf("datastore %q not found", name)
+	case 1:
+		return datastoreFromID(client, refs[0].Value)
+	}
+	return nil, fmt.Errorf("datastore name %q is ambiguous, found %d datastores with this name", name, len(refs))
+}
+
+// datastoreDatacenter returns the datacenter that the supplied datastore is
+// in, based off of the datastore's inventory path.
+func datastoreDatacenter(client *govmomi.Client, ds *object.Datastore) (*object.Datacenter, error) {
+	dcPath, err := rootPathParticleDatastore.SplitDatacenter(ds.InventoryPath)
+	if err != nil {
+		return nil, err
+	}
+	finder := find.NewFinder(client.Client, false)
+	ctx, cancel := context.WithTimeout(context.Background(), defaultAPITimeout)
+	defer cancel()
+	return finder.Datacenter(ctx, dcPath)
+}
+
+// datastoreFromResourceData locates the datastore and datacenter for resources
+// that take the name or path of a datastore in the datastore attribute, and an
+// optional datacenter ID in the datacenter_id attribute.
+func datastoreFromResourceData(d *schema.ResourceData, client *govmomi.Client) (*object.Datastore, *object.Datacenter, error) {
+	var dc *object.Datacenter
+	if dcID, ok := d.GetOk("datacenter_id"); ok {
+		var err error
+		dc, err = datacenterFromID(client, dcID.(string))
+		if err != nil {
+			return nil, nil, fmt.Errorf("cannot locate datacenter: %s", err)
+		}
+	}
+	ds, err := datastoreFromPath(client, d.Get("datastore").(string), dc)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error fetching datastore: %s", err)
+	}
+	if dc == nil {
+		dc, err = datastoreDatacenter(client, ds)
+		if err != nil {
+			return nil, nil, fmt.Errorf("error locating datacenter for datastore %q: %s", ds.Name(), err)
+		}
+	}
+	return ds, dc, nil
+}
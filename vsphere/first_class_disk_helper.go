@@ -0,0 +1,241 @@
+package vsphere
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/vmware/govmomi"
+	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/vim25/methods"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// The functions in this file are stop-gap methods that implement the
+// VStorageObjectManager API for First Class Disks, and the related
+// VirtualMachine methods to attach and detach them. They will be removed once
+// govmomi has native support for these objects.
+
+// errVStorageObjectManagerUnavailable is returned when the connected endpoint
+// does not expose a VStorageObjectManager.
+var errVStorageObjectManagerUnavailable = errors.New("first class disks are not supported on this endpoint (vSphere 6.5 or higher required)")
+
+// vStorageObjectManager returns the reference to the VStorageObjectManager for
+// the supplied client.
+func vStorageObjectManager(client *govmomi.Client) (types.ManagedObjectReference, error) {
+	if client.ServiceContent.VStorageObjectManager == nil {
+		return types.ManagedObjectReference{}, errVStorageObjectManagerUnavailable
+	}
+	return *client.ServiceContent.VStorageObjectManager, nil
+}
+
+// createFirstClassDisk creates a First Class Disk with the supplied spec and
+// returns the new disk once the task has completed.
+func createFirstClassDisk(client *govmomi.Client, spec types.VslmCreateSpec) (*types.VStorageObject, error) {
+	ref, err := vStorageObjectManager(client)
+	if err != nil {
+		return nil, err
+	}
+	req := types.CreateDisk_Task{
+		This: ref,
+		Spec: spec,
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), defaultAPITimeout)
+	defer cancel()
+	res, err := methods.CreateDisk_Task(ctx, client.Client, &req)
+	if err != nil {
+		return nil, err
+	}
+
+	// The wait is not bound by a timeout, as creating large eagerly zeroed disks
+	// can take a long time.
+	t := object.NewTask(client.Client, res.Returnval)
+	info, err := t.WaitForResult(context.Background(), nil)
+	if err != nil {
+		return nil, err
+	}
+	obj, ok := info.Result.(types.VStorageObject)
+	if !ok {
+		return nil, fmt.Errorf("unexpected result type %T when creating first class disk", info.Result)
+	}
+	return &obj, nil
+}
+
+// retrieveFirstClassDisk fetches the First Class Disk with the supplied ID on
+// the supplied datastore.
+func retrieveFirstClassDisk(client *govmomi.Client, id string, ds *object.Datastore) (*types.VStorageObject, error) {
+	ref, err := vStorageObjectManager(client)
+	if err != nil {
+		return nil, err
+	}
+	req := types.RetrieveVStorageObject{
+		This:      ref,
+		Id:        types.ID{Id: id},
+		Datastore: ds.Reference(),
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), defaultAPITimeout)
+	defer cancel()
+	res, err := methods.RetrieveVStorageObject(ctx, client.Client, &req)
+	if err != nil {
+		return nil, err
+	}
+	return &res.Returnval, nil
+}
+
+// extendFirstClassDisk grows the First Class Disk with the supplied ID to the
+// supplied capacity, and waits for the operation to complete.
+func extendFirstClassDisk(client *govmomi.Client, id string, ds *object.Datastore, capacityMB int64) error {
+	ref, err := vStorageObjectManager(client)
+	if err != nil {
+		return err
+	}
+	req := types.ExtendDisk_Task{
+		This:            ref,
+		Id:              types.ID{Id: id},
+		Datastore:       ds.Reference(),
+		NewCapacityInMB: capacityMB,
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), defaultAPITimeout)
+	defer cancel()
+	res, err := methods.ExtendDisk_Task(ctx, client.Client, &req)
+	if err != nil {
+		return err
+	}
+	t := object.NewTask(client.Client, res.Returnval)
+	return t.Wait(context.Background())
+}
+
+// renameFirstClassDisk renames the First Class Disk with the supplied ID.
+func renameFirstClassDisk(client *govmomi.Client, id string, ds *object.Datastore, name string) error {
+	ref, err := vStorageObjectManager(client)
+	if err != nil {
+		return err
+	}
+	req := types.RenameVStorageObject{
+		This:      ref,
+		Id:        types.ID{Id: id},
+		Datastore: ds.Reference(),
+		Name:      name,
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), defaultAPITimeout)
+	defer cancel()
+	_, err = methods.RenameVStorageObject(ctx, client.Client, &req)
+	return err
+}
+
+// deleteFirstClassDisk deletes the First Class Disk with the supplied ID, and
+// waits for the operation to complete.
+func deleteFirstClassDisk(client *govmomi.Client, id string, ds *object.Datastore) error {
+	ref, err := vStorageObjectManager(client)
+	if err != nil {
+		return err
+	}
+	req := types.DeleteVStorageObject_Task{
+		This:      ref,
+		Id:        types.ID{Id: id},
+		Datastore: ds.Reference(),
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), defaultAPITimeout)
+	defer cancel()
+	res, err := methods.DeleteVStorageObject_Task(ctx, client.Client, &req)
+	if err != nil {
+		return err
+	}
+	t := object.NewTask(client.Client, res.Returnval)
+	return t.Wait(ctx)
+}
+
+// firstClassDiskTags returns the category and tag names of all of the tags
+// attached to the First Class Disk with the supplied ID.
+func firstClassDiskTags(client *govmomi.Client, id string) ([]types.VslmTagEntry, error) {
+	ref, err := vStorageObjectManager(client)
+	if err != nil {
+		return nil, err
+	}
+	req := types.ListTagsAttachedToVStorageObject{
+		This: ref,
+		Id:   types.ID{Id: id},
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), defaultAPITimeout)
+	defer cancel()
+	res, err := methods.ListTagsAttachedToVStorageObject(ctx, client.Client, &req)
+	if err != nil {
+		return nil, err
+	}
+	return res.Returnval, nil
+}
+
+// attachFirstClassDiskTag attaches the tag with the supplied category and tag
+// names to the First Class Disk with the supplied ID.
+func attachFirstClassDiskTag(client *govmomi.Client, id string, tag types.VslmTagEntry) error {
+	ref, err := vStorageObjectManager(client)
+	if err != nil {
+		return err
+	}
+	req := types.AttachTagToVStorageObject{
+		This:     ref,
+		Id:       types.ID{Id: id},
+		Category: tag.ParentCategoryName,
+		Tag:      tag.TagName,
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), defaultAPITimeout)
+	defer cancel()
+	_, err = methods.AttachTagToVStorageObject(ctx, client.Client, &req)
+	return err
+}
+
+// detachFirstClassDiskTag detaches the tag with the supplied category and tag
+// names from the First Class Disk with the supplied ID.
+func detachFirstClassDiskTag(client *govmomi.Client, id string, tag types.VslmTagEntry) error {
+	ref, err := vStorageObjectManager(client)
+	if err != nil {
+		return err
+	}
+	req := types.DetachTagFromVStorageObject{
+		This:     ref,
+		Id:       types.ID{Id: id},
+		Category: tag.ParentCategoryName,
+		Tag:      tag.TagName,
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), defaultAPITimeout)
+	defer cancel()
+	_, err = methods.DetachTagFromVStorageObject(ctx, client.Client, &req)
+	return err
+}
+
+// attachFirstClassDisk attaches the First Class Disk with the supplied ID to
+// the supplied virtual machine, on the supplied controller and unit number.
+func attachFirstClassDisk(vm *object.VirtualMachine, id string, ds *object.Datastore, controllerKey int32, unitNumber *int32) error {
+	req := types.AttachDisk_Task{
+		This:          vm.Reference(),
+		DiskId:        types.ID{Id: id},
+		Datastore:     ds.Reference(),
+		ControllerKey: controllerKey,
+		UnitNumber:    unitNumber,
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), defaultAPITimeout)
+	defer cancel()
+	res, err := methods.AttachDisk_Task(ctx, vm.Client(), &req)
+	if err != nil {
+		return err
+	}
+	t := object.NewTask(vm.Client(), res.Returnval)
+	return t.Wait(ctx)
+}
+
+// detachFirstClassDisk detaches the First Class Disk with the supplied ID from
+// the supplied virtual machine. The disk itself is not deleted.
+func detachFirstClassDisk(vm *object.VirtualMachine, id string) error {
+	req := types.DetachDisk_Task{
+		This:   vm.Reference(),
+		DiskId: types.ID{Id: id},
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), defaultAPITimeout)
+	defer cancel()
+	res, err := methods.DetachDisk_Task(ctx, vm.Client(), &req)
+	if err != nil {
+		return err
+	}
+	t := object.NewTask(vm.Client(), res.Returnval)
+	return t.Wait(ctx)
+}
@@ -163,6 +163,26 @@ func testPowerOffVM(s *terraform.State, resourceName string) error {
 	return nil
 }
 
+// testPowerOnVM powers on a virtual machine resource.
+func testPowerOnVM(s *terraform.State, resourceName string) error {
+	vm, err := testGetVirtualMachine(s, resourceName)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), defaultAPITimeout)
+	defer cancel()
+	task, err := vm.PowerOn(ctx)
+	if err != nil {
+		return fmt.Errorf("error powering on VM: %s", err)
+	}
+	tctx, tcancel := context.WithTimeout(context.Background(), defaultAPITimeout)
+	defer tcancel()
+	if err := task.Wait(tctx); err != nil {
+		return fmt.Errorf("error waiting for power on: %s", err)
+	}
+	return nil
+}
+
 // testGetTagCategory gets a tag category by name.
 func testGetTagCategory(s *terraform.State, resourceName string) (*tags.Category, error) {
 	tVars, err := testClientVariablesForResource(s, fmt.Sprintf("vsphere_tag_category.%s", resourceName))
@@ -353,3 +373,55 @@ func testGetDVPortgroupProperties(s *terraform.State, resourceName string) (*mo.
 	}
 	return dvPortgroupProperties(dvs)
 }
+
+// testAccResourceVSphereDatastoreCheckSIOC is a check to ensure that the
+// supplied datastore has Storage I/O Control enabled or disabled, with the
+// supplied manual congestion threshold.
+//
+// The full datastore resource address is needed as this functions across
+// multiple datastore resource types.
+func testAccResourceVSphereDatastoreCheckSIOC(dsResAddr string, enabled bool, threshold int32) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		ds, err := testGetDatastore(s, dsResAddr)
+		if err != nil {
+			return err
+		}
+		props, err := datastoreProperties(ds)
+		if err != nil {
+			return err
+		}
+		if props.IormConfiguration == nil {
+			return fmt.Errorf("datastore %q has no Storage I/O Control configuration", ds.Reference().Value)
+		}
+		if props.IormConfiguration.Enabled != enabled {
+			return fmt.Errorf("expected Storage I/O Control enabled to be %t, got %t", enabled, props.IormConfiguration.Enabled)
+		}
+		if props.IormConfiguration.CongestionThreshold != threshold {
+			return fmt.Errorf("expected congestion threshold to be %d, got %d", threshold, props.IormConfiguration.CongestionThreshold)
+		}
+		return nil
+	}
+}
+
+// testAccResourceVSphereDatastoreCheckMaintenanceMode is a check to ensure
+// that the supplied datastore is, or is not, in maintenance mode.
+//
+// The full datastore resource address is needed as this functions across
+// multiple datastore resource types.
+func testAccResourceVSphereDatastoreCheckMaintenanceMode(dsResAddr string, expected bool) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		ds, err := testGetDatastore(s, dsResAddr)
+		if err != nil {
+			return err
+		}
+		props, err := datastoreProperties(ds)
+		if err != nil {
+			return err
+		}
+		actual := datastoreInMaintenanceMode(&props.Summary)
+		if expected != actual {
+			return fmt.Errorf("expected maintenance mode to be %t, got %t (state: %s)", expected, actual, props.Summary.MaintenanceMode)
+		}
+		return nil
+	}
+}
@@ -0,0 +1,136 @@
+package vsphere
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/vmware/govmomi"
+	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/vim25/methods"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// hostActiveDirectoryAuthenticationFromHostSystemID locates the
+// HostActiveDirectoryAuthentication store of a specified HostSystem managed
+// object ID and returns its properties.
+func hostActiveDirectoryAuthenticationFromHostSystemID(client *govmomi.Client, hsID string) (*mo.HostActiveDirectoryAuthentication, error) {
+	hs, err := hostSystemFromID(client, hsID)
+	if err != nil {
+		return nil, err
+	}
+	var hsProps mo.HostSystem
+	ctx, cancel := context.WithTimeout(context.Background(), defaultAPITimeout)
+	defer cancel()
+	if err := hs.Properties(ctx, hs.Reference(), []string{"configManager.authenticationManager"}, &hsProps); err != nil {
+		return nil, fmt.Errorf("error fetching host properties: %s", err)
+	}
+	if hsProps.ConfigManager.AuthenticationManager == nil {
+		return nil, fmt.Errorf("host %q does not support authentication management", hs.Name())
+	}
+
+	pc := client.PropertyCollector()
+	var am mo.HostAuthenticationManager
+	if err := pc.RetrieveOne(ctx, *hsProps.ConfigManager.AuthenticationManager, []string{"supportedStore"}, &am); err != nil {
+		return nil, fmt.Errorf("error fetching authentication manager properties: %s", err)
+	}
+	for _, ref := range am.SupportedStore {
+		if ref.Type != "HostActiveDirectoryAuthentication" {
+			continue
+		}
+		var ad mo.HostActiveDirectoryAuthentication
+		if err := pc.RetrieveOne(ctx, ref, nil, &ad); err != nil {
+			return nil, fmt.Errorf("error fetching Active Directory store properties: %s", err)
+		}
+		return &ad, nil
+	}
+	return nil, fmt.Errorf("host %q does not support Active Directory authentication", hs.Name())
+}
+
+// hostActiveDirectoryInfo returns the HostActiveDirectoryInfo for the
+// supplied Active Directory authentication store.
+func hostActiveDirectoryInfo(ad *mo.HostActiveDirectoryAuthentication) (*types.HostActiveDirectoryInfo, error) {
+	info, ok := ad.Info.(*types.HostActiveDirectoryInfo)
+	if !ok {
+		return nil, fmt.Errorf("unexpected authentication store info type %T", ad.Info)
+	}
+	return info, nil
+}
+
+// joinDomain is a stop-gap method that implements JoinDomain_Task and waits
+// for the task to complete. It will be removed once a higher level
+// HostActiveDirectoryAuthentication object exists in govmomi.
+func joinDomain(client *govmomi.Client, ad *mo.HostActiveDirectoryAuthentication, domain, username, password string) error {
+	req := types.JoinDomain_Task{
+		This:       ad.Reference(),
+		DomainName: domain,
+		UserName:   username,
+		Password:   password,
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), defaultAPITimeout)
+	defer cancel()
+	res, err := methods.JoinDomain_Task(ctx, client.Client, &req)
+	if err != nil {
+		return err
+	}
+
+	t := object.NewTask(client.Client, res.Returnval)
+	tctx, tcancel := context.WithTimeout(context.Background(), defaultAPITimeout)
+	defer tcancel()
+	return t.Wait(tctx)
+}
+
+// leaveCurrentDomain is a stop-gap method that implements
+// LeaveCurrentDomain_Task and waits for the task to complete. It will be
+// removed once a higher level HostActiveDirectoryAuthentication object exists
+// in govmomi.
+func leaveCurrentDomain(client *govmomi.Client, ad *mo.HostActiveDirectoryAuthentication, force bool) error {
+	req := types.LeaveCurrentDomain_Task{
+		This:  ad.Reference(),
+		Force: force,
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), defaultAPITimeout)
+	defer cancel()
+	res, err := methods.LeaveCurrentDomain_Task(ctx, client.Client, &req)
+	if err != nil {
+		return err
+	}
+
+	t := object.NewTask(client.Client, res.Returnval)
+	tctx, tcancel := context.WithTimeout(context.Background(), defaultAPITimeout)
+	defer tcancel()
+	return t.Wait(tctx)
+}
+
+// validateHostNfsKerberos checks to make sure that the host referenced by the
+// supplied HostSystem managed object ID has been joined to an Active
+// Directory domain and has NFS Kerberos credentials set, both of which are
+// prerequisites to mounting NFS v4.1 volumes with Kerberos security.
+func validateHostNfsKerberos(client *govmomi.Client, hsID string) error {
+	ad, err := hostActiveDirectoryAuthenticationFromHostSystemID(client, hsID)
+	if err != nil {
+		return err
+	}
+	info, err := hostActiveDirectoryInfo(ad)
+	if err != nil {
+		return err
+	}
+	if info.JoinedDomain == "" {
+		return fmt.Errorf("host %q is not joined to an Active Directory domain", hostSystemNameOrID(client, hsID))
+	}
+
+	ss, err := hostStorageSystemFromHostSystemID(client, hsID)
+	if err != nil {
+		return err
+	}
+	user, err := queryNFSUser(ss)
+	if err != nil {
+		return fmt.Errorf("error querying NFS user on host %q: %s", hostSystemNameOrID(client, hsID), err)
+	}
+	if user == "" {
+		return fmt.Errorf("host %q does not have NFS Kerberos credentials set", hostSystemNameOrID(client, hsID))
+	}
+	return nil
+}
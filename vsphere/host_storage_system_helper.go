@@ -2,9 +2,13 @@ package vsphere
 
 import (
 	"context"
+	"fmt"
 
 	"github.com/vmware/govmomi"
 	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/vim25/methods"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
 )
 
 // hostStorageSystemFromHostSystemID locates a HostStorageSystem from a
@@ -18,3 +22,89 @@ func hostStorageSystemFromHostSystemID(client *govmomi.Client, hsID string) (*ob
 	defer cancel()
 	return hs.ConfigManager().StorageSystem(ctx)
 }
+
+// hostScsiDisks returns the SCSI disks that the host of a HostStorageSystem
+// can see.
+func hostScsiDisks(ss *object.HostStorageSystem) ([]*types.HostScsiDisk, error) {
+	var hss mo.HostStorageSystem
+	ctx, cancel := context.WithTimeout(context.Background(), defaultAPITimeout)
+	defer cancel()
+	if err := ss.Properties(ctx, ss.Reference(), []string{"storageDeviceInfo"}, &hss); err != nil {
+		return nil, fmt.Errorf("error querying storage system properties: %s", err)
+	}
+	var disks []*types.HostScsiDisk
+	if hss.StorageDeviceInfo == nil {
+		return disks, nil
+	}
+	for _, sl := range hss.StorageDeviceInfo.ScsiLun {
+		if hsd, ok := sl.(*types.HostScsiDisk); ok {
+			disks = append(disks, hsd)
+		}
+	}
+	return disks, nil
+}
+
+// hostScsiDiskFromCanonicalName locates a SCSI disk on the host of a
+// HostStorageSystem by its canonical name, such as naa.600508b1001c3a4b.
+func hostScsiDiskFromCanonicalName(ss *object.HostStorageSystem, name string) (*types.HostScsiDisk, error) {
+	disks, err := hostScsiDisks(ss)
+	if err != nil {
+		return nil, err
+	}
+	for _, disk := range disks {
+		if disk.CanonicalName == name {
+			return disk, nil
+		}
+	}
+	return nil, fmt.Errorf("could not find disk with canonical name %q", name)
+}
+
+// setNFSUser is a stop-gap method that implements SetNFSUser. It will be
+// removed once the higher level HostStorageSystem object supports this
+// method.
+func setNFSUser(ss *object.HostStorageSystem, user, password string) error {
+	req := types.SetNFSUser{
+		This:     ss.Reference(),
+		User:     user,
+		Password: password,
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), defaultAPITimeout)
+	defer cancel()
+	_, err := methods.SetNFSUser(ctx, ss.Client(), &req)
+	return err
+}
+
+// queryNFSUser is a stop-gap method that implements QueryNFSUser. It will be
+// removed once the higher level HostStorageSystem object supports this
+// method. An empty string is returned if no NFS user is set on the host.
+func queryNFSUser(ss *object.HostStorageSystem) (string, error) {
+	req := types.QueryNFSUser{
+		This: ss.Reference(),
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), defaultAPITimeout)
+	defer cancel()
+	res, err := methods.QueryNFSUser(ctx, ss.Client(), &req)
+	if err != nil {
+		return "", err
+	}
+	if res.Returnval == nil {
+		return "", nil
+	}
+	return res.Returnval.User, nil
+}
+
+// clearNFSUser is a stop-gap method that implements ClearNFSUser. It will be
+// removed once the higher level HostStorageSystem object supports this
+// method.
+func clearNFSUser(ss *object.HostStorageSystem) error {
+	req := types.ClearNFSUser{
+		This: ss.Reference(),
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), defaultAPITimeout)
+	defer cancel()
+	_, err := methods.ClearNFSUser(ctx, ss.Client(), &req)
+	return err
+}
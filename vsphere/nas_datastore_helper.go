@@ -45,9 +45,9 @@ func (p *nasDatastoreMountProcessor) diffNewOld() []string {
 
 // diff is what diffOldNew and diffNewOld hand off to.
 func (p *nasDatastoreMountProcessor) diff(a, b []string) []string {
-	var found bool
 	c := make([]string, 0)
 	for _, v1 := range a {
+		var found bool
 		for _, v2 := range b {
 			if v1 == v2 {
 				found = true
@@ -0,0 +1,44 @@
+package vsphere
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNasDatastoreMountProcessorDiff(t *testing.T) {
+	cases := []struct {
+		Name     string
+		a        []string
+		b        []string
+		expected []string
+	}{
+		{
+			Name:     "no changes",
+			a:        []string{"host-1", "host-2"},
+			b:        []string{"host-1", "host-2"},
+			expected: []string{},
+		},
+		{
+			Name:     "all missing",
+			a:        []string{"host-1", "host-2"},
+			b:        []string{},
+			expected: []string{"host-1", "host-2"},
+		},
+		{
+			Name:     "missing after a match",
+			a:        []string{"host-1", "host-2", "host-3"},
+			b:        []string{"host-1"},
+			expected: []string{"host-2", "host-3"},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			p := &nasDatastoreMountProcessor{}
+			actual := p.diff(tc.a, tc.b)
+			if !reflect.DeepEqual(tc.expected, actual) {
+				t.Fatalf("expected %#v, got %#v", tc.expected, actual)
+			}
+		})
+	}
+}
@@ -0,0 +1,473 @@
+package vsphere
+
+import (
+	"archive/tar"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/vmware/govmomi"
+	"github.com/vmware/govmomi/nfc"
+	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/vim25/methods"
+	"github.com/vmware/govmomi/vim25/soap"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// ovfSource represents an OVF package, either as an OVF descriptor with its
+// referenced files alongside it, or as a single OVA archive. The package can
+// live on the local filesystem or on a datastore.
+type ovfSource struct {
+	// The file name of the OVF descriptor or OVA archive.
+	name string
+
+	// Opens a file in the directory that the package lives in, returning the
+	// file and its size.
+	open func(name string) (io.ReadCloser, int64, error)
+}
+
+// newLocalOVFSource returns an ovfSource for an OVF or OVA file on the local
+// filesystem.
+func newLocalOVFSource(p string) *ovfSource {
+	dir := filepath.Dir(p)
+	return &ovfSource{
+		name: filepath.Base(p),
+		open: func(name string) (io.ReadCloser, int64, error) {
+			f, err := os.Open(filepath.Join(dir, filepath.FromSlash(name)))
+			if err != nil {
+				return nil, 0, err
+			}
+			s, err := f.Stat()
+			if err != nil {
+				f.Close()
+				return nil, 0, err
+			}
+			return f, s.Size(), nil
+		},
+	}
+}
+
+// newDatastoreOVFSource returns an ovfSource for an OVF or OVA file at the
+// supplied path on a datastore. Files are streamed from the datastore as they
+// are needed.
+func newDatastoreOVFSource(ds *object.Datastore, p string) *ovfSource {
+	dir := path.Dir(p)
+	return &ovfSource{
+		name: path.Base(p),
+		open: func(name string) (io.ReadCloser, int64, error) {
+			// Downloads are not bound by a timeout, as disk files can be large.
+			return ds.Download(context.Background(), path.Join(dir, name), &soap.DefaultDownload)
+		},
+	}
+}
+
+// isOVA returns true if the source is an OVA archive.
+func (s *ovfSource) isOVA() bool {
+	return strings.ToLower(path.Ext(s.name)) == ".ova"
+}
+
+// Descriptor returns the contents of the OVF descriptor.
+func (s *ovfSource) Descriptor() (string, error) {
+	var r io.ReadCloser
+	var err error
+	if s.isOVA() {
+		r, _, err = s.openTarEntry(func(name string) bool {
+			return strings.ToLower(path.Ext(name)) == ".ovf"
+		})
+	} else {
+		r, _, err = s.open(s.name)
+	}
+	if err != nil {
+		return "", fmt.Errorf("error opening OVF descriptor from %q: %s", s.name, err)
+	}
+	defer r.Close()
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		return "", fmt.Errorf("error reading OVF descriptor from %q: %s", s.name, err)
+	}
+	return string(b), nil
+}
+
+// Open opens a file referenced by the OVF descriptor, returning the file and
+// its size.
+func (s *ovfSource) Open(name string) (io.ReadCloser, int64, error) {
+	if s.isOVA() {
+		return s.openTarEntry(func(n string) bool {
+			return path.Clean(n) == path.Clean(name)
+		})
+	}
+	return s.open(name)
+}
+
+// openTarEntry scans the OVA archive for the first entry that matches the
+// supplied function, and returns a reader for it. Closing the reader closes
+// the underlying archive.
+func (s *ovfSource) openTarEntry(match func(name string) bool) (io.ReadCloser, int64, error) {
+	f, _, err := s.open(s.name)
+	if err != nil {
+		return nil, 0, err
+	}
+	r := tar.NewReader(f)
+	for {
+		h, err := r.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			f.Close()
+			return nil, 0, err
+		}
+		if match(h.Name) {
+			return struct {
+				io.Reader
+				io.Closer
+			}{r, f}, h.Size, nil
+		}
+	}
+	f.Close()
+	return nil, 0, os.ErrNotExist
+}
+
+// ovfManager returns the reference to the OvfManager for the supplied client.
+func ovfManager(client *govmomi.Client) (types.ManagedObjectReference, error) {
+	if client.ServiceContent.OvfManager == nil {
+		return types.ManagedObjectReference{}, errors.New("OVF manager is not available on this endpoint")
+	}
+	return *client.ServiceContent.OvfManager, nil
+}
+
+// parseOVFDescriptor is a stop-gap method that implements ParseDescriptor on
+// the OvfManager. It will be removed once govmomi has an OvfManager object.
+func parseOVFDescriptor(client *govmomi.Client, descriptor string, params types.OvfParseDescriptorParams) (*types.OvfParseDescriptorResult, error) {
+	ref, err := ovfManager(client)
+	if err != nil {
+		return nil, err
+	}
+	req := types.ParseDescriptor{
+		This:          ref,
+		OvfDescriptor: descriptor,
+		Pdp:           params,
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), defaultAPITimeout)
+	defer cancel()
+	res, err := methods.ParseDescriptor(ctx, client.Client, &req)
+	if err != nil {
+		return nil, err
+	}
+	if err := ovfResultErrors(res.Returnval.Error, res.Returnval.Warning); err != nil {
+		return nil, fmt.Errorf("error parsing OVF descriptor: %s", err)
+	}
+	return &res.Returnval, nil
+}
+
+// createOVFImportSpec is a stop-gap method that implements CreateImportSpec on
+// the OvfManager. It will be removed once govmomi has an OvfManager object.
+func createOVFImportSpec(client *govmomi.Client, descriptor string, pool *object.ResourcePool, ds *object.Datastore, params types.OvfCreateImportSpecParams) (*types.OvfCreateImportSpecResult, error) {
+	ref, err := ovfManager(client)
+	if err != nil {
+		return nil, err
+	}
+	req := types.CreateImportSpec{
+		This:          ref,
+		OvfDescriptor: descriptor,
+		ResourcePool:  pool.Reference(),
+		Datastore:     ds.Reference(),
+		Cisp:          params,
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), defaultAPITimeout)
+	defer cancel()
+	res, err := methods.CreateImportSpec(ctx, client.Client, &req)
+	if err != nil {
+		return nil, err
+	}
+	if err := ovfResultErrors(res.Returnval.Error, res.Returnval.Warning); err != nil {
+		return nil, fmt.Errorf("error creating import spec: %s", err)
+	}
+	return &res.Returnval, nil
+}
+
+// ovfResultErrors logs any warnings returned by an OvfManager operation, and
+// returns the errors returned by it as a single error.
+func ovfResultErrors(errs, warnings []types.LocalizedMethodFault) error {
+	for _, w := range warnings {
+		log.Printf("[WARN] OVF: %s", w.LocalizedMessage)
+	}
+	if len(errs) < 1 {
+		return nil
+	}
+	var msgs []string
+	for _, e := range errs {
+		msgs = append(msgs, e.LocalizedMessage)
+	}
+	return errors.New(strings.Join(msgs, "; "))
+}
+
+// importOVF imports the supplied import spec into the supplied resource pool
+// and folder, uploading the files referenced by the spec from the OVF source.
+// The lease is kept alive while the files upload, and is aborted if any of
+// the uploads fail. The reference to the imported entity is returned.
+func importOVF(src *ovfSource, spec *types.OvfCreateImportSpecResult, pool *object.ResourcePool, folder *object.Folder, host *object.HostSystem) (types.ManagedObjectReference, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), defaultAPITimeout)
+	defer cancel()
+	lease, err := pool.ImportVApp(ctx, spec.ImportSpec, folder, host)
+	if err != nil {
+		return types.ManagedObjectReference{}, fmt.Errorf("error starting import: %s", err)
+	}
+	info, err := lease.Wait(ctx, spec.FileItem)
+	if err != nil {
+		return types.ManagedObjectReference{}, fmt.Errorf("error waiting on import lease: %s", err)
+	}
+
+	// Uploads are not bound by a timeout, as disk files can be large. The lease
+	// updater keeps the lease alive in the meantime.
+	uctx := context.Background()
+	updater := lease.StartUpdater(uctx, info)
+	defer updater.Done()
+	for _, item := range info.Items {
+		if err := uploadOVFItem(uctx, lease, src, item); err != nil {
+			if aerr := lease.Abort(uctx, nil); aerr != nil {
+				log.Printf("[WARN] Error aborting import lease: %s", aerr)
+			}
+			return types.ManagedObjectReference{}, fmt.Errorf("error uploading %q: %s", item.Path, err)
+		}
+	}
+	if err := lease.Complete(uctx); err != nil {
+		return types.ManagedObjectReference{}, fmt.Errorf("error completing import: %s", err)
+	}
+	return info.Entity, nil
+}
+
+// uploadOVFItem uploads a single file from the OVF source through the
+// supplied lease.
+func uploadOVFItem(ctx context.Context, lease *nfc.Lease, src *ovfSource, item nfc.FileItem) error {
+	f, size, err := src.Open(item.Path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	opts := soap.Upload{
+		ContentLength: size,
+		Progress:      newUploadProgressLogger(item.Path),
+	}
+	log.Printf("[DEBUG] Uploading %q (%d bytes) to %s", item.Path, size, item.URL)
+	return lease.Upload(ctx, item, f, opts)
+}
+
+// ovfExportLeaseProgressInterval is the interval at which export progress is
+// reported to the lease, which also keeps the lease from timing out.
+const ovfExportLeaseProgressInterval = 2 * time.Second
+
+// ovfExportFile describes a file written by exportOVF.
+type ovfExportFile struct {
+	// The file name, relative to the export directory.
+	name string
+
+	// The hex-encoded SHA-256 checksum of the file.
+	sha256 string
+}
+
+// exportVirtualMachine is a stop-gap method that implements ExportVm on a
+// VirtualMachine and returns the NFC lease for the export. It will be removed
+// once the VirtualMachine object in govmomi supports exports.
+func exportVirtualMachine(vm *object.VirtualMachine) (*nfc.Lease, error) {
+	req := types.ExportVm{
+		This: vm.Reference(),
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), defaultAPITimeout)
+	defer cancel()
+	res, err := methods.ExportVm(ctx, vm.Client(), &req)
+	if err != nil {
+		return nil, err
+	}
+	return nfc.NewLease(vm.Client(), res.Returnval), nil
+}
+
+// createOVFDescriptor is a stop-gap method that implements CreateDescriptor on
+// the OvfManager. It will be removed once govmomi has an OvfManager object.
+func createOVFDescriptor(client *govmomi.Client, obj object.Reference, params types.OvfCreateDescriptorParams) (string, error) {
+	ref, err := ovfManager(client)
+	if err != nil {
+		return "", err
+	}
+	req := types.CreateDescriptor{
+		This: ref,
+		Obj:  obj.Reference(),
+		Cdp:  params,
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), defaultAPITimeout)
+	defer cancel()
+	res, err := methods.CreateDescriptor(ctx, client.Client, &req)
+	if err != nil {
+		return "", err
+	}
+	if err := ovfResultErrors(res.Returnval.Error, res.Returnval.Warning); err != nil {
+		return "", fmt.Errorf("error creating OVF descriptor: %s", err)
+	}
+	return res.Returnval.OvfDescriptor, nil
+}
+
+// exportOVF exports the supplied virtual machine to an OVF package with the
+// supplied name in the supplied local directory. The disks are downloaded
+// through an NFC lease, after which the OVF descriptor and a manifest with the
+// SHA-256 checksums of all of the files are written.
+//
+// The files written are returned, with the descriptor first and the manifest
+// last.
+func exportOVF(client *govmomi.Client, vm *object.VirtualMachine, dir, name string) ([]ovfExportFile, error) {
+	lease, err := exportVirtualMachine(vm)
+	if err != nil {
+		return nil, fmt.Errorf("error starting export: %s", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), defaultAPITimeout)
+	defer cancel()
+	info, err := lease.Wait(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error waiting on export lease: %s", err)
+	}
+
+	// Downloads are not bound by a timeout, as disk files can be large. The
+	// lease is kept alive by reporting progress in the meantime.
+	var done int64
+	total := info.TotalDiskCapacityInKB * 1024
+	stop := make(chan struct{})
+	defer close(stop)
+	go keepOVFExportLeaseAlive(lease, &done, total, stop)
+
+	var files []ovfExportFile
+	var ovfFiles []types.OvfFile
+	for _, device := range info.DeviceUrl {
+		f, size, err := downloadOVFDevice(client, device, dir, &done)
+		if err != nil {
+			if aerr := lease.Abort(context.Background(), nil); aerr != nil {
+				log.Printf("[WARN] Error aborting export lease: %s", aerr)
+			}
+			return nil, fmt.Errorf("error downloading %q: %s", device.Url, err)
+		}
+		files = append(files, f)
+		ovfFiles = append(ovfFiles, types.OvfFile{
+			DeviceId: device.Key,
+			Path:     f.name,
+			Size:     size,
+		})
+	}
+	if err := lease.Complete(context.Background()); err != nil {
+		return nil, fmt.Errorf("error completing export: %s", err)
+	}
+
+	descriptor, err := createOVFDescriptor(client, vm, types.OvfCreateDescriptorParams{
+		Name:     name,
+		OvfFiles: ovfFiles,
+	})
+	if err != nil {
+		return nil, err
+	}
+	ovf, err := writeOVFExportFile(dir, name+".ovf", []byte(descriptor))
+	if err != nil {
+		return nil, err
+	}
+	files = append([]ovfExportFile{ovf}, files...)
+
+	var manifest []string
+	for _, f := range files {
+		manifest = append(manifest, fmt.Sprintf("SHA256(%s)= %s\n", f.name, f.sha256))
+	}
+	mf, err := writeOVFExportFile(dir, name+".mf", []byte(strings.Join(manifest, "")))
+	if err != nil {
+		return nil, err
+	}
+	return append(files, mf), nil
+}
+
+// downloadOVFDevice downloads the file for a single device in an export lease
+// to the supplied directory, adding the number of bytes downloaded to done as
+// it goes.
+func downloadOVFDevice(client *govmomi.Client, device types.HttpNfcLeaseDeviceUrl, dir string, done *int64) (ovfExportFile, int64, error) {
+	u, err := client.Client.ParseURL(device.Url)
+	if err != nil {
+		return ovfExportFile{}, 0, err
+	}
+	name := path.Base(u.Path)
+	r, _, err := client.Client.Download(u, &soap.DefaultDownload)
+	if err != nil {
+		return ovfExportFile{}, 0, err
+	}
+	defer r.Close()
+
+	f, err := os.Create(filepath.Join(dir, name))
+	if err != nil {
+		return ovfExportFile{}, 0, err
+	}
+	defer f.Close()
+
+	log.Printf("[DEBUG] Downloading %q to %q", device.Url, f.Name())
+	h := sha256.New()
+	size, err := io.Copy(io.MultiWriter(f, h, ovfExportCounter{done}), r)
+	if err != nil {
+		return ovfExportFile{}, 0, err
+	}
+	return ovfExportFile{name: name, sha256: hex.EncodeToString(h.Sum(nil))}, size, nil
+}
+
+// ovfExportCounter is an io.Writer that counts the bytes written to it.
+type ovfExportCounter struct {
+	n *int64
+}
+
+// Write implements io.Writer for ovfExportCounter.
+func (c ovfExportCounter) Write(p []byte) (int, error) {
+	atomic.AddInt64(c.n, int64(len(p)))
+	return len(p), nil
+}
+
+// keepOVFExportLeaseAlive reports the progress of an export to its lease
+// every ovfExportLeaseProgressInterval until stop is closed. Progress is
+// estimated from the total disk capacity, as the size of the exported disks is
+// not known in advance.
+func keepOVFExportLeaseAlive(lease *nfc.Lease, done *int64, total int64, stop <-chan struct{}) {
+	tick := time.NewTicker(ovfExportLeaseProgressInterval)
+	defer tick.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-tick.C:
+			var percent int32
+			if total > 0 {
+				percent = int32(100 * atomic.LoadInt64(done) / total)
+			}
+			if percent > 99 {
+				percent = 99
+			}
+			log.Printf("[INFO] Exporting: %d%% complete", percent)
+			ctx, cancel := context.WithTimeout(context.Background(), defaultAPITimeout)
+			err := lease.Progress(ctx, percent)
+			cancel()
+			if err != nil {
+				log.Printf("[WARN] Error updating export lease progress: %s", err)
+				return
+			}
+		}
+	}
+}
+
+// writeOVFExportFile writes the supplied data to a file in the supplied
+// directory, and returns its checksum.
+func writeOVFExportFile(dir, name string, data []byte) (ovfExportFile, error) {
+	if err := ioutil.WriteFile(filepath.Join(dir, name), data, 0644); err != nil {
+		return ovfExportFile{}, fmt.Errorf("error writing %q: %s", name, err)
+	}
+	sum := sha256.Sum256(data)
+	return ovfExportFile{name: name, sha256: hex.EncodeToString(sum[:])}, nil
+}
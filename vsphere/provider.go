@@ -69,14 +69,20 @@ func Provider() terraform.ResourceProvider {
 		},
 
 		ResourcesMap: map[string]*schema.Resource{
+			"vsphere_content_library":            resourceVSphereContentLibrary(),
+			"vsphere_content_library_item":       resourceVSphereContentLibraryItem(),
 			"vsphere_datacenter":                 resourceVSphereDatacenter(),
+			"vsphere_datastore_directory":        resourceVSphereDatastoreDirectory(),
 			"vsphere_distributed_port_group":     resourceVSphereDistributedPortGroup(),
 			"vsphere_distributed_virtual_switch": resourceVSphereDistributedVirtualSwitch(),
 			"vsphere_file":                       resourceVSphereFile(),
+			"vsphere_first_class_disk":           resourceVSphereFirstClassDisk(),
 			"vsphere_folder":                     resourceVSphereFolder(),
+			"vsphere_host_active_directory":      resourceVSphereHostActiveDirectory(),
 			"vsphere_host_port_group":            resourceVSphereHostPortGroup(),
 			"vsphere_host_virtual_switch":        resourceVSphereHostVirtualSwitch(),
 			"vsphere_license":                    resourceVSphereLicense(),
+			"vsphere_ovf_export":                 resourceVSphereOvfExport(),
 			"vsphere_tag":                        resourceVSphereTag(),
 			"vsphere_tag_category":               resourceVSphereTagCategory(),
 			"vsphere_virtual_disk":               resourceVSphereVirtualDisk(),
@@ -87,12 +93,15 @@ func Provider() terraform.ResourceProvider {
 		},
 
 		DataSourcesMap: map[string]*schema.Resource{
+			"vsphere_content_library_item":       dataSourceVSphereContentLibraryItem(),
 			"vsphere_datacenter":                 dataSourceVSphereDatacenter(),
+			"vsphere_datastore_files":            dataSourceVSphereDatastoreFiles(),
 			"vsphere_distributed_virtual_switch": dataSourceVSphereDistributedVirtualSwitch(),
 			"vsphere_host":                       dataSourceVSphereHost(),
 			"vsphere_network":                    dataSourceVSphereNetwork(),
 			"vsphere_tag":                        dataSourceVSphereTag(),
 			"vsphere_tag_category":               dataSourceVSphereTagCategory(),
+			"vsphere_virtual_machine":            dataSourceVSphereVirtualMachine(),
 			"vsphere_vmfs_disks":                 dataSourceVSphereVmfsDisks(),
 		},
 
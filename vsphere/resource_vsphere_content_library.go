@@ -0,0 +1,262 @@
+package vsphere
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/hashicorp/terraform/helper/schema"
+	"github.com/hashicorp/terraform/helper/validation"
+)
+
+func resourceVSphereContentLibrary() *schema.Resource {
+	return &schema.Resource{
+		Create: resourceVSphereContentLibraryCreate,
+		Read:   resourceVSphereContentLibraryRead,
+		Update: resourceVSphereContentLibraryUpdate,
+		Delete: resourceVSphereContentLibraryDelete,
+
+		Schema: map[string]*schema.Schema{
+			"name": &schema.Schema{
+				Type:         schema.TypeString,
+				Description:  "The name of the content library.",
+				Required:     true,
+				ValidateFunc: validation.NoZeroValues,
+			},
+			"description": &schema.Schema{
+				Type:        schema.TypeString,
+				Description: "The description of the content library.",
+				Optional:    true,
+			},
+			"datastore": &schema.Schema{
+				Type:        schema.TypeString,
+				Description: "The name or path of the datastore to store the content of the library on.",
+				Required:    true,
+				ForceNew:    true,
+			},
+			"datacenter_id": &schema.Schema{
+				Type:        schema.TypeString,
+				Description: "The managed object ID of the datacenter the datastore is in. This is required if the supplied datastore is not an absolute path containing a datacenter and there are multiple datacenters in your infrastructure.",
+				Optional:    true,
+				Computed:    true,
+				ForceNew:    true,
+			},
+			"published": &schema.Schema{
+				Type:          schema.TypeBool,
+				Description:   "Publish the library so that libraries on other vCenter servers can subscribe to it. Only applies to local libraries.",
+				Optional:      true,
+				Default:       false,
+				ConflictsWith: []string{"subscription"},
+			},
+			"subscription": &schema.Schema{
+				Type:        schema.TypeList,
+				Description: "The published library to subscribe to. When set, a subscribed library is created instead of a local library.",
+				Optional:    true,
+				ForceNew:    true,
+				MaxItems:    1,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"url": &schema.Schema{
+							Type:         schema.TypeString,
+							Description:  "The URL of the published library to subscribe to.",
+							Required:     true,
+							ForceNew:     true,
+							ValidateFunc: validation.NoZeroValues,
+						},
+						"authentication_method": &schema.Schema{
+							Type:         schema.TypeString,
+							Description:  "The method used to authenticate to the published library. Can be one of NONE or BASIC.",
+							Optional:     true,
+							ForceNew:     true,
+							Default:      "NONE",
+							ValidateFunc: validation.StringInSlice([]string{"NONE", "BASIC"}, false),
+						},
+						"username": &schema.Schema{
+							Type:        schema.TypeString,
+							Description: "The user name to authenticate to the published library with when authentication_method is BASIC.",
+							Optional:    true,
+							ForceNew:    true,
+						},
+						"password": &schema.Schema{
+							Type:        schema.TypeString,
+							Description: "The password to authenticate to the published library with when authentication_method is BASIC.",
+							Optional:    true,
+							ForceNew:    true,
+							Sensitive:   true,
+						},
+						"automatic_sync": &schema.Schema{
+							Type:        schema.TypeBool,
+							Description: "Synchronize the library with the published library automatically.",
+							Optional:    true,
+							ForceNew:    true,
+							Default:     true,
+						},
+						"on_demand": &schema.Schema{
+							Type:        schema.TypeBool,
+							Description: "Only download the content of library items when they are used, rather than when the library is synchronized.",
+							Optional:    true,
+							ForceNew:    true,
+							Default:     false,
+						},
+						"ssl_thumbprint": &schema.Schema{
+							Type:        schema.TypeString,
+							Description: "The SHA-1 thumbprint of the SSL certificate of the server hosting the published library.",
+							Optional:    true,
+							ForceNew:    true,
+						},
+					},
+				},
+			},
+			"type": &schema.Schema{
+				Type:        schema.TypeString,
+				Description: "The type of the library. Either LOCAL or SUBSCRIBED.",
+				Computed:    true,
+			},
+			"publish_url": &schema.Schema{
+				Type:        schema.TypeString,
+				Description: "The URL that other libraries can subscribe to this library with, when published.",
+				Computed:    true,
+			},
+		},
+	}
+}
+
+func resourceVSphereContentLibraryCreate(d *schema.ResourceData, meta interface{}) error {
+	client := meta.(*VSphereClient).vimClient
+	clc, err := meta.(*VSphereClient).ContentLibraryClient()
+	if err != nil {
+		return err
+	}
+	ds, dc, err := datastoreFromResourceData(d, client)
+	if err != nil {
+		return err
+	}
+
+	spec := contentLibrary{
+		Name:        d.Get("name").(string),
+		Description: d.Get("description").(string),
+		Type:        contentLibraryTypeLocal,
+		StorageBackings: []contentLibraryStorageBacking{
+			{
+				Type:        "DATASTORE",
+				DatastoreID: ds.Reference().Value,
+			},
+		},
+	}
+	if sub, ok := d.GetOk("subscription.0"); ok {
+		spec.Type = contentLibraryTypeSubscribed
+		spec.SubscriptionInfo = expandContentLibrarySubscriptionInfo(sub.(map[string]interface{}))
+	} else {
+		spec.PublishInfo = expandContentLibraryPublishInfo(d)
+	}
+
+	log.Printf("[DEBUG] Creating %s content library %q on datastore %q", spec.Type, spec.Name, ds.Name())
+	id, err := createContentLibrary(clc, spec)
+	if err != nil {
+		return fmt.Errorf("error creating content library: %s", err)
+	}
+	d.SetId(id)
+	d.Set("datacenter_id", dc.Reference().Value)
+	return resourceVSphereContentLibraryRead(d, meta)
+}
+
+func resourceVSphereContentLibraryRead(d *schema.ResourceData, meta interface{}) error {
+	clc, err := meta.(*VSphereClient).ContentLibraryClient()
+	if err != nil {
+		return err
+	}
+	lib, err := getContentLibrary(clc, d.Id())
+	if err != nil {
+		if isContentLibraryNotFoundError(err) {
+			log.Printf("[DEBUG] Content library %q not found, marking resource as gone", d.Id())
+			d.SetId("")
+			return nil
+		}
+		return fmt.Errorf("error fetching content library: %s", err)
+	}
+	d.Set("name", lib.Name)
+	d.Set("description", lib.Description)
+	d.Set("type", lib.Type)
+	if lib.PublishInfo != nil {
+		d.Set("published", lib.PublishInfo.Published)
+		d.Set("publish_url", lib.PublishInfo.PublishURL)
+	}
+	if lib.SubscriptionInfo != nil {
+		if err := d.Set("subscription", flattenContentLibrarySubscriptionInfo(d, lib.SubscriptionInfo)); err != nil {
+			return fmt.Errorf("error setting subscription: %s", err)
+		}
+	}
+	return nil
+}
+
+func resourceVSphereContentLibraryUpdate(d *schema.ResourceData, meta interface{}) error {
+	clc, err := meta.(*VSphereClient).ContentLibraryClient()
+	if err != nil {
+		return err
+	}
+	spec := contentLibrary{
+		ID:          d.Id(),
+		Name:        d.Get("name").(string),
+		Description: d.Get("description").(string),
+		Type:        d.Get("type").(string),
+	}
+	if spec.Type == contentLibraryTypeLocal && d.HasChange("published") {
+		spec.PublishInfo = expandContentLibraryPublishInfo(d)
+	}
+	log.Printf("[DEBUG] Updating content library %q", d.Id())
+	if err := updateContentLibrary(clc, spec); err != nil {
+		return fmt.Errorf("error updating content library: %s", err)
+	}
+	return resourceVSphereContentLibraryRead(d, meta)
+}
+
+func resourceVSphereContentLibraryDelete(d *schema.ResourceData, meta interface{}) error {
+	clc, err := meta.(*VSphereClient).ContentLibraryClient()
+	if err != nil {
+		return err
+	}
+	log.Printf("[DEBUG] Deleting content library %q", d.Id())
+	if err := deleteContentLibrary(clc, d.Id(), d.Get("type").(string)); err != nil {
+		return fmt.Errorf("error deleting content library: %s", err)
+	}
+	return nil
+}
+
+// expandContentLibraryPublishInfo reads the publish settings for a local
+// content library from the supplied ResourceData.
+func expandContentLibraryPublishInfo(d *schema.ResourceData) *contentLibraryPublishInfo {
+	return &contentLibraryPublishInfo{
+		Published:            d.Get("published").(bool),
+		AuthenticationMethod: "NONE",
+	}
+}
+
+// expandContentLibrarySubscriptionInfo reads the subscription settings for a
+// subscribed content library from the supplied subscription block.
+func expandContentLibrarySubscriptionInfo(m map[string]interface{}) *contentLibrarySubscriptionInfo {
+	return &contentLibrarySubscriptionInfo{
+		SubscriptionURL:      m["url"].(string),
+		AuthenticationMethod: m["authentication_method"].(string),
+		UserName:             m["username"].(string),
+		Password:             m["password"].(string),
+		AutomaticSyncEnabled: m["automatic_sync"].(bool),
+		OnDemand:             m["on_demand"].(bool),
+		SslThumbprint:        m["ssl_thumbprint"].(string),
+	}
+}
+
+// flattenContentLibrarySubscriptionInfo converts the subscription settings of
+// a subscribed content library into a subscription block. The password is not
+// returned by the API, so it is carried over from the current state.
+func flattenContentLibrarySubscriptionInfo(d *schema.ResourceData, info *contentLibrarySubscriptionInfo) []interface{} {
+	return []interface{}{
+		map[string]interface{}{
+			"url":                   info.SubscriptionURL,
+			"authentication_method": info.AuthenticationMethod,
+			"username":              info.UserName,
+			"password":              d.Get("subscription.0.password").(string),
+			"automatic_sync":        info.AutomaticSyncEnabled,
+			"on_demand":             info.OnDemand,
+			"ssl_thumbprint":        info.SslThumbprint,
+		},
+	}
+}
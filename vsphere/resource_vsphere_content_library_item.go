@@ -0,0 +1,155 @@
+package vsphere
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/hashicorp/terraform/helper/schema"
+	"github.com/hashicorp/terraform/helper/validation"
+)
+
+func resourceVSphereContentLibraryItem() *schema.Resource {
+	return &schema.Resource{
+		Create: resourceVSphereContentLibraryItemCreate,
+		Read:   resourceVSphereContentLibraryItemRead,
+		Update: resourceVSphereContentLibraryItemUpdate,
+		Delete: resourceVSphereContentLibraryItemDelete,
+
+		Schema: map[string]*schema.Schema{
+			"library_id": &schema.Schema{
+				Type:         schema.TypeString,
+				Description:  "The ID of the content library to create the item in.",
+				Required:     true,
+				ForceNew:     true,
+				ValidateFunc: validation.NoZeroValues,
+			},
+			"name": &schema.Schema{
+				Type:         schema.TypeString,
+				Description:  "The name of the library item.",
+				Required:     true,
+				ValidateFunc: validation.NoZeroValues,
+			},
+			"description": &schema.Schema{
+				Type:        schema.TypeString,
+				Description: "The description of the library item.",
+				Optional:    true,
+			},
+			"file_path": &schema.Schema{
+				Type:         schema.TypeString,
+				Description:  "The path to the local OVF, OVA, or ISO file to upload to the library item.",
+				Required:     true,
+				ForceNew:     true,
+				ValidateFunc: validation.NoZeroValues,
+			},
+			"type": &schema.Schema{
+				Type:         schema.TypeString,
+				Description:  "The type of the library item. Can be one of ovf or iso. Default: determined from the extension of file_path.",
+				Optional:     true,
+				Computed:     true,
+				ForceNew:     true,
+				ValidateFunc: validation.StringInSlice([]string{contentLibraryItemTypeOVF, contentLibraryItemTypeISO}, false),
+			},
+			"size": &schema.Schema{
+				Type:        schema.TypeInt,
+				Description: "The size of the content of the library item, in bytes.",
+				Computed:    true,
+			},
+		},
+	}
+}
+
+func resourceVSphereContentLibraryItemCreate(d *schema.ResourceData, meta interface{}) error {
+	clc, err := meta.(*VSphereClient).ContentLibraryClient()
+	if err != nil {
+		return err
+	}
+	p := d.Get("file_path").(string)
+	itemType := d.Get("type").(string)
+	if itemType == "" {
+		if itemType, err = contentLibraryItemTypeForPath(p); err != nil {
+			return err
+		}
+	}
+	// Read the files before creating the item so that we fail early if the
+	// package is unreadable.
+	files, err := contentLibraryFilesForPath(p, itemType)
+	if err != nil {
+		return err
+	}
+
+	spec := contentLibraryItem{
+		LibraryID:   d.Get("library_id").(string),
+		Name:        d.Get("name").(string),
+		Description: d.Get("description").(string),
+		Type:        itemType,
+	}
+	log.Printf("[DEBUG] Creating library item %q in content library %q", spec.Name, spec.LibraryID)
+	id, err := createContentLibraryItem(clc, spec)
+	if err != nil {
+		return fmt.Errorf("error creating library item: %s", err)
+	}
+	d.SetId(id)
+
+	log.Printf("[DEBUG] Uploading %q to library item %q", p, id)
+	if err := uploadContentLibraryItemFiles(clc, id, files); err != nil {
+		// Remove the empty item so that the next apply starts from scratch.
+		if derr := deleteContentLibraryItem(clc, id); derr != nil {
+			log.Printf("[WARN] Error deleting library item %q after failed upload: %s", id, derr)
+		} else {
+			d.SetId("")
+		}
+		return fmt.Errorf("error uploading %q to library item: %s", p, err)
+	}
+	return resourceVSphereContentLibraryItemRead(d, meta)
+}
+
+func resourceVSphereContentLibraryItemRead(d *schema.ResourceData, meta interface{}) error {
+	clc, err := meta.(*VSphereClient).ContentLibraryClient()
+	if err != nil {
+		return err
+	}
+	item, err := getContentLibraryItem(clc, d.Id())
+	if err != nil {
+		if isContentLibraryNotFoundError(err) {
+			log.Printf("[DEBUG] Library item %q not found, marking resource as gone", d.Id())
+			d.SetId("")
+			return nil
+		}
+		return fmt.Errorf("error fetching library item: %s", err)
+	}
+	d.Set("library_id", item.LibraryID)
+	d.Set("name", item.Name)
+	d.Set("description", item.Description)
+	d.Set("type", item.Type)
+	d.Set("size", item.Size)
+	return nil
+}
+
+func resourceVSphereContentLibraryItemUpdate(d *schema.ResourceData, meta interface{}) error {
+	clc, err := meta.(*VSphereClient).ContentLibraryClient()
+	if err != nil {
+		return err
+	}
+	spec := contentLibraryItem{
+		ID:          d.Id(),
+		Name:        d.Get("name").(string),
+		Description: d.Get("description").(string),
+	}
+	log.Printf("[DEBUG] Updating library item %q", d.Id())
+	if err := updateContentLibraryItem(clc, spec); err != nil {
+		return fmt.Errorf("error updating library item: %s", err)
+	}
+	return resourceVSphereContentLibraryItemRead(d, meta)
+}
+
+func resourceVSphereContentLibraryItemDelete(d *schema.ResourceData, meta interface{}) error {
+	clc, err := meta.(*VSphereClient).ContentLibraryClient()
+	if err != nil {
+		return err
+	}
+	log.Printf("[DEBUG] Deleting library item %q", d.Id())
+	if err := deleteContentLibraryItem(clc, d.Id()); err != nil {
+		return fmt.Errorf("error deleting library item: %s", err)
+	}
+	return nil
+}
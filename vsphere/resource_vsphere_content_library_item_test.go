@@ -0,0 +1,124 @@
+package vsphere
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/hashicorp/terraform/helper/resource"
+	"github.com/hashicorp/terraform/terraform"
+)
+
+func TestAccResourceVSphereContentLibraryItem(t *testing.T) {
+	var tp *testing.T
+	testAccResourceVSphereContentLibraryItemCases := []struct {
+		name     string
+		testCase resource.TestCase
+	}{
+		{
+			"basic",
+			resource.TestCase{
+				PreCheck: func() {
+					testAccPreCheck(tp)
+					testAccResourceVSphereContentLibraryItemPreCheck(tp)
+				},
+				Providers:    testAccProviders,
+				CheckDestroy: testAccResourceVSphereContentLibraryItemExists(false),
+				Steps: []resource.TestStep{
+					{
+						Config: testAccResourceVSphereContentLibraryItemConfig("terraform-test-item"),
+						Check: resource.ComposeTestCheckFunc(
+							testAccResourceVSphereContentLibraryItemExists(true),
+							resource.TestCheckResourceAttr("vsphere_content_library_item.item", "type", contentLibraryItemTypeOVF),
+							resource.TestCheckResourceAttrSet("vsphere_content_library_item.item", "size"),
+						),
+					},
+				},
+			},
+		},
+		{
+			"rename",
+			resource.TestCase{
+				PreCheck: func() {
+					testAccPreCheck(tp)
+					testAccResourceVSphereContentLibraryItemPreCheck(tp)
+				},
+				Providers:    testAccProviders,
+				CheckDestroy: testAccResourceVSphereContentLibraryItemExists(false),
+				Steps: []resource.TestStep{
+					{
+						Config: testAccResourceVSphereContentLibraryItemConfig("terraform-test-item"),
+						Check: resource.ComposeTestCheckFunc(
+							testAccResourceVSphereContentLibraryItemExists(true),
+						),
+					},
+					{
+						Config: testAccResourceVSphereContentLibraryItemConfig("terraform-test-item-renamed"),
+						Check: resource.ComposeTestCheckFunc(
+							testAccResourceVSphereContentLibraryItemExists(true),
+							resource.TestCheckResourceAttr("vsphere_content_library_item.item", "name", "terraform-test-item-renamed"),
+						),
+					},
+				},
+			},
+		},
+	}
+
+	for _, tc := range testAccResourceVSphereContentLibraryItemCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tp = t
+			resource.Test(t, tc.testCase)
+		})
+	}
+}
+
+func testAccResourceVSphereContentLibraryItemPreCheck(t *testing.T) {
+	testAccResourceVSphereContentLibraryPreCheck(t)
+	if os.Getenv("VSPHERE_OVF_FILE") == "" {
+		t.Skip("set VSPHERE_OVF_FILE to run vsphere_content_library_item acceptance tests")
+	}
+}
+
+func testAccResourceVSphereContentLibraryItemExists(expected bool) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		rs, ok := s.RootModule().Resources["vsphere_content_library_item.item"]
+		if !ok {
+			if expected {
+				return errors.New("vsphere_content_library_item.item not found in state")
+			}
+			return nil
+		}
+		clc, err := testAccProvider.Meta().(*VSphereClient).ContentLibraryClient()
+		if err != nil {
+			return err
+		}
+		_, err = getContentLibraryItem(clc, rs.Primary.ID)
+		if err != nil {
+			if isContentLibraryNotFoundError(err) && !expected {
+				return nil
+			}
+			return err
+		}
+		if !expected {
+			return fmt.Errorf("expected library item %q to be missing", rs.Primary.ID)
+		}
+		return nil
+	}
+}
+
+func testAccResourceVSphereContentLibraryItemConfig(name string) string {
+	return fmt.Sprintf(`
+%s
+
+resource "vsphere_content_library_item" "item" {
+  name       = "%s"
+  library_id = "${vsphere_content_library.library.id}"
+  file_path  = "%s"
+}
+`,
+		testAccResourceVSphereContentLibraryConfig("terraform-test-library", false),
+		name,
+		os.Getenv("VSPHERE_OVF_FILE"),
+	)
+}
@@ -0,0 +1,176 @@
+package vsphere
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/hashicorp/terraform/helper/resource"
+	"github.com/hashicorp/terraform/terraform"
+)
+
+func TestAccResourceVSphereContentLibrary(t *testing.T) {
+	var tp *testing.T
+	testAccResourceVSphereContentLibraryCases := []struct {
+		name     string
+		testCase resource.TestCase
+	}{
+		{
+			"basic",
+			resource.TestCase{
+				PreCheck: func() {
+					testAccPreCheck(tp)
+					testAccResourceVSphereContentLibraryPreCheck(tp)
+				},
+				Providers:    testAccProviders,
+				CheckDestroy: testAccResourceVSphereContentLibraryExists(false),
+				Steps: []resource.TestStep{
+					{
+						Config: testAccResourceVSphereContentLibraryConfig("terraform-test-library", false),
+						Check: resource.ComposeTestCheckFunc(
+							testAccResourceVSphereContentLibraryExists(true),
+							resource.TestCheckResourceAttr("vsphere_content_library.library", "type", contentLibraryTypeLocal),
+							resource.TestCheckResourceAttr("vsphere_content_library.library", "published", "false"),
+						),
+					},
+				},
+			},
+		},
+		{
+			"rename and publish",
+			resource.TestCase{
+				PreCheck: func() {
+					testAccPreCheck(tp)
+					testAccResourceVSphereContentLibraryPreCheck(tp)
+				},
+				Providers:    testAccProviders,
+				CheckDestroy: testAccResourceVSphereContentLibraryExists(false),
+				Steps: []resource.TestStep{
+					{
+						Config: testAccResourceVSphereContentLibraryConfig("terraform-test-library", false),
+						Check: resource.ComposeTestCheckFunc(
+							testAccResourceVSphereContentLibraryExists(true),
+						),
+					},
+					{
+						Config: testAccResourceVSphereContentLibraryConfig("terraform-test-library-renamed", true),
+						Check: resource.ComposeTestCheckFunc(
+							testAccResourceVSphereContentLibraryExists(true),
+							resource.TestCheckResourceAttr("vsphere_content_library.library", "name", "terraform-test-library-renamed"),
+							resource.TestCheckResourceAttr("vsphere_content_library.library", "published", "true"),
+							resource.TestCheckResourceAttrSet("vsphere_content_library.library", "publish_url"),
+						),
+					},
+				},
+			},
+		},
+		{
+			"subscribed",
+			resource.TestCase{
+				PreCheck: func() {
+					testAccPreCheck(tp)
+					testAccResourceVSphereContentLibraryPreCheck(tp)
+				},
+				Providers:    testAccProviders,
+				CheckDestroy: testAccResourceVSphereContentLibraryExists(false),
+				Steps: []resource.TestStep{
+					{
+						Config: testAccResourceVSphereContentLibraryConfigSubscribed(),
+						Check: resource.ComposeTestCheckFunc(
+							resource.TestCheckResourceAttr("vsphere_content_library.subscribed", "type", contentLibraryTypeSubscribed),
+							resource.TestCheckResourceAttrPair(
+								"vsphere_content_library.subscribed", "subscription.0.url",
+								"vsphere_content_library.library", "publish_url",
+							),
+						),
+					},
+				},
+			},
+		},
+	}
+
+	for _, tc := range testAccResourceVSphereContentLibraryCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tp = t
+			resource.Test(t, tc.testCase)
+		})
+	}
+}
+
+func testAccResourceVSphereContentLibraryPreCheck(t *testing.T) {
+	if os.Getenv("VSPHERE_DATACENTER") == "" {
+		t.Skip("set VSPHERE_DATACENTER to run vsphere_content_library acceptance tests")
+	}
+	if os.Getenv("VSPHERE_DATASTORE") == "" {
+		t.Skip("set VSPHERE_DATASTORE to run vsphere_content_library acceptance tests")
+	}
+}
+
+func testAccResourceVSphereContentLibraryExists(expected bool) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		rs, ok := s.RootModule().Resources["vsphere_content_library.library"]
+		if !ok {
+			if expected {
+				return errors.New("vsphere_content_library.library not found in state")
+			}
+			return nil
+		}
+		clc, err := testAccProvider.Meta().(*VSphereClient).ContentLibraryClient()
+		if err != nil {
+			return err
+		}
+		_, err = getContentLibrary(clc, rs.Primary.ID)
+		if err != nil {
+			if isContentLibraryNotFoundError(err) && !expected {
+				return nil
+			}
+			return err
+		}
+		if !expected {
+			return fmt.Errorf("expected content library %q to be missing", rs.Primary.ID)
+		}
+		return nil
+	}
+}
+
+func testAccResourceVSphereContentLibraryConfig(name string, published bool) string {
+	return fmt.Sprintf(`
+data "vsphere_datacenter" "dc" {
+  name = "%s"
+}
+
+resource "vsphere_content_library" "library" {
+  name          = "%s"
+  description   = "Managed by Terraform"
+  datacenter_id = "${data.vsphere_datacenter.dc.id}"
+  datastore     = "%s"
+  published     = %t
+}
+`,
+		os.Getenv("VSPHERE_DATACENTER"),
+		name,
+		os.Getenv("VSPHERE_DATASTORE"),
+		published,
+	)
+}
+
+func testAccResourceVSphereContentLibraryConfigSubscribed() string {
+	return fmt.Sprintf(`
+%s
+
+resource "vsphere_content_library" "subscribed" {
+  name          = "terraform-test-library-subscribed"
+  datacenter_id = "${data.vsphere_datacenter.dc.id}"
+  datastore     = "%s"
+
+  subscription {
+    url       = "${vsphere_content_library.library.publish_url}"
+    on_demand = true
+  }
+}
+`,
+		testAccResourceVSphereContentLibraryConfig("terraform-test-library", true),
+		os.Getenv("VSPHERE_DATASTORE"),
+	)
+}
@@ -0,0 +1,171 @@
+package vsphere
+
+import (
+	"fmt"
+	"log"
+	"path"
+	"strings"
+
+	"github.com/hashicorp/terraform/helper/schema"
+	"github.com/hashicorp/terraform/helper/validation"
+	"github.com/vmware/govmomi/object"
+)
+
+func resourceVSphereDatastoreDirectory() *schema.Resource {
+	return &schema.Resource{
+		Create: resourceVSphereDatastoreDirectoryCreate,
+		Read:   resourceVSphereDatastoreDirectoryRead,
+		Update: resourceVSphereDatastoreDirectoryUpdate,
+		Delete: resourceVSphereDatastoreDirectoryDelete,
+		Importer: &schema.ResourceImporter{
+			State: resourceVSphereDatastoreDirectoryImport,
+		},
+
+		Schema: map[string]*schema.Schema{
+			"datastore": &schema.Schema{
+				Type:        schema.TypeString,
+				Description: "The name or path of the datastore to create the directory on.",
+				Required:    true,
+				ForceNew:    true,
+			},
+			"datacenter_id": &schema.Schema{
+				Type:        schema.TypeString,
+				Description: "The managed object ID of the datacenter the datastore is in. This is required if the supplied datastore is not an absolute path containing a datacenter and there are multiple datacenters in your infrastructure.",
+				Optional:    true,
+				Computed:    true,
+				ForceNew:    true,
+			},
+			"path": &schema.Schema{
+				Type:         schema.TypeString,
+				Description:  "The path of the directory, relative to the root of the datastore. Missing parent directories are created as well.",
+				Required:     true,
+				StateFunc:    normalizeDatastoreDirectoryPath,
+				ValidateFunc: validation.NoZeroValues,
+			},
+			"force_destroy": &schema.Schema{
+				Type:        schema.TypeBool,
+				Description: "Delete the directory on destroy even if it is not empty. When false, destroying a directory that still has files or sub-directories in it is an error.",
+				Optional:    true,
+				Default:     false,
+			},
+		},
+	}
+}
+
+func resourceVSphereDatastoreDirectoryCreate(d *schema.ResourceData, meta interface{}) error {
+	client := meta.(*VSphereClient).vimClient
+	ds, dc, err := datastoreFromResourceData(d, client)
+	if err != nil {
+		return err
+	}
+	name := normalizeDatastoreDirectoryPath(d.Get("path"))
+	log.Printf("[DEBUG] Creating directory %s", ds.Path(name))
+	if err := makeDatastoreDirectory(client, ds, dc, name); err != nil {
+		return fmt.Errorf("error creating directory %s: %s", ds.Path(name), err)
+	}
+	d.SetId(ds.Path(name))
+	return resourceVSphereDatastoreDirectoryRead(d, meta)
+}
+
+func resourceVSphereDatastoreDirectoryRead(d *schema.ResourceData, meta interface{}) error {
+	client := meta.(*VSphereClient).vimClient
+	ds, dc, err := datastoreFromResourceData(d, client)
+	if err != nil {
+		return err
+	}
+	name := normalizeDatastoreDirectoryPath(d.Get("path"))
+	exists, err := datastoreDirectoryExists(ds, name)
+	if err != nil {
+		return fmt.Errorf("error checking directory %s: %s", ds.Path(name), err)
+	}
+	if !exists {
+		log.Printf("[DEBUG] Directory %s not found, marking resource as gone", ds.Path(name))
+		d.SetId("")
+		return nil
+	}
+	d.Set("datacenter_id", dc.Reference().Value)
+	d.Set("path", name)
+	return nil
+}
+
+func resourceVSphereDatastoreDirectoryUpdate(d *schema.ResourceData, meta interface{}) error {
+	client := meta.(*VSphereClient).vimClient
+	ds, dc, err := datastoreFromResourceData(d, client)
+	if err != nil {
+		return err
+	}
+	if d.HasChange("path") {
+		o, n := d.GetChange("path")
+		oldName := normalizeDatastoreDirectoryPath(o)
+		newName := normalizeDatastoreDirectoryPath(n)
+		log.Printf("[DEBUG] Moving directory %s to %s", ds.Path(oldName), ds.Path(newName))
+		if err := moveDatastoreFile(client, ds, dc, oldName, newName); err != nil {
+			return fmt.Errorf("error moving directory %s to %s: %s", ds.Path(oldName), ds.Path(newName), err)
+		}
+		d.SetId(ds.Path(newName))
+	}
+	return resourceVSphereDatastoreDirectoryRead(d, meta)
+}
+
+func resourceVSphereDatastoreDirectoryDelete(d *schema.ResourceData, meta interface{}) error {
+	client := meta.(*VSphereClient).vimClient
+	ds, dc, err := datastoreFromResourceData(d, client)
+	if err != nil {
+		return err
+	}
+	name := normalizeDatastoreDirectoryPath(d.Get("path"))
+	if !d.Get("force_destroy").(bool) {
+		empty, err := datastoreDirectoryIsEmpty(ds, name)
+		if err != nil {
+			return fmt.Errorf("error checking contents of directory %s: %s", ds.Path(name), err)
+		}
+		if !empty {
+			return fmt.Errorf("directory %s is not empty. Remove its contents or set force_destroy to delete it anyway", ds.Path(name))
+		}
+	}
+	log.Printf("[DEBUG] Deleting directory %s", ds.Path(name))
+	if err := deleteDatastoreFile(client, ds, dc, name); err != nil {
+		return fmt.Errorf("error deleting directory %s: %s", ds.Path(name), err)
+	}
+	return nil
+}
+
+func resourceVSphereDatastoreDirectoryImport(d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
+	// The ID is a datastore path in the "[datastore] path" format. The datastore
+	// is looked up by name across all datacenters, so that datacenter_id can be
+	// populated.
+	client := meta.(*VSphereClient).vimClient
+	var p object.DatastorePath
+	if !p.FromString(d.Id()) || p.Datastore == "" || normalizeDatastoreDirectoryPath(p.Path) == "" {
+		return nil, fmt.Errorf("invalid import ID %q, must be in the format \"[datastore] path\"", d.Id())
+	}
+	ds, err := datastoreFromName(client, p.Datastore)
+	if err != nil {
+		return nil, err
+	}
+	dc, err := datastoreDatacenter(client, ds)
+	if err != nil {
+		return nil, fmt.Errorf("error locating datacenter for datastore %q: %s", p.Datastore, err)
+	}
+	name := normalizeDatastoreDirectoryPath(p.Path)
+	exists, err := datastoreDirectoryExists(ds, name)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, fmt.Errorf("directory %s does not exist", ds.Path(name))
+	}
+	d.SetId(ds.Path(name))
+	d.Set("datastore", ds.Name())
+	d.Set("datacenter_id", dc.Reference().Value)
+	d.Set("path", name)
+	d.Set("force_destroy", false)
+	return []*schema.ResourceData{d}, nil
+}
+
+// normalizeDatastoreDirectoryPath cleans up a directory path so that it is
+// relative to the root of the datastore, without any leading or trailing
+// slashes.
+func normalizeDatastoreDirectoryPath(v interface{}) string {
+	return strings.Trim(path.Clean("/"+v.(string)), "/")
+}
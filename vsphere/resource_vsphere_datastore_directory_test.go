@@ -0,0 +1,221 @@
+package vsphere
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"regexp"
+	"testing"
+
+	"github.com/hashicorp/terraform/helper/resource"
+	"github.com/hashicorp/terraform/terraform"
+)
+
+const testAccResourceVSphereDatastoreDirectorySourceFile = "/tmp/tf_test_datastore_directory.txt"
+
+func TestAccResourceVSphereDatastoreDirectory(t *testing.T) {
+	var tp *testing.T
+	testAccResourceVSphereDatastoreDirectoryCases := []struct {
+		name     string
+		testCase resource.TestCase
+	}{
+		{
+			"basic",
+			resource.TestCase{
+				PreCheck: func() {
+					testAccPreCheck(tp)
+					testAccResourceVSphereDatastoreDirectoryPreCheck(tp)
+				},
+				Providers:    testAccProviders,
+				CheckDestroy: testAccResourceVSphereDatastoreDirectoryExists(false),
+				Steps: []resource.TestStep{
+					{
+						Config: testAccResourceVSphereDatastoreDirectoryConfig("tf-test-dir/nested"),
+						Check: resource.ComposeTestCheckFunc(
+							testAccResourceVSphereDatastoreDirectoryExists(true),
+							resource.TestCheckResourceAttr("vsphere_datastore_directory.dir", "path", "tf-test-dir/nested"),
+						),
+					},
+				},
+			},
+		},
+		{
+			"rename",
+			resource.TestCase{
+				PreCheck: func() {
+					testAccPreCheck(tp)
+					testAccResourceVSphereDatastoreDirectoryPreCheck(tp)
+				},
+				Providers:    testAccProviders,
+				CheckDestroy: testAccResourceVSphereDatastoreDirectoryExists(false),
+				Steps: []resource.TestStep{
+					{
+						Config: testAccResourceVSphereDatastoreDirectoryConfig("tf-test-dir"),
+						Check: resource.ComposeTestCheckFunc(
+							testAccResourceVSphereDatastoreDirectoryExists(true),
+						),
+					},
+					{
+						Config: testAccResourceVSphereDatastoreDirectoryConfig("tf-test-dir-renamed"),
+						Check: resource.ComposeTestCheckFunc(
+							testAccResourceVSphereDatastoreDirectoryExists(true),
+							resource.TestCheckResourceAttr("vsphere_datastore_directory.dir", "path", "tf-test-dir-renamed"),
+						),
+					},
+				},
+			},
+		},
+		{
+			"refuse to delete non-empty directory",
+			resource.TestCase{
+				PreCheck: func() {
+					testAccPreCheck(tp)
+					testAccResourceVSphereDatastoreDirectoryPreCheck(tp)
+				},
+				Providers:    testAccProviders,
+				CheckDestroy: testAccResourceVSphereDatastoreDirectoryExists(false),
+				Steps: []resource.TestStep{
+					{
+						Config: testAccResourceVSphereDatastoreDirectoryConfigWithFile(),
+						Check: resource.ComposeTestCheckFunc(
+							testAccResourceVSphereDatastoreDirectoryExists(true),
+						),
+					},
+					{
+						Config:      testAccResourceVSphereDatastoreDirectoryConfigFileOnly(),
+						ExpectError: regexp.MustCompile("is not empty"),
+					},
+				},
+			},
+		},
+		{
+			"import",
+			resource.TestCase{
+				PreCheck: func() {
+					testAccPreCheck(tp)
+					testAccResourceVSphereDatastoreDirectoryPreCheck(tp)
+				},
+				Providers:    testAccProviders,
+				CheckDestroy: testAccResourceVSphereDatastoreDirectoryExists(false),
+				Steps: []resource.TestStep{
+					{
+						Config: testAccResourceVSphereDatastoreDirectoryConfig("tf-test-dir"),
+						Check: resource.ComposeTestCheckFunc(
+							testAccResourceVSphereDatastoreDirectoryExists(true),
+						),
+					},
+					{
+						ResourceName:      "vsphere_datastore_directory.dir",
+						ImportState:       true,
+						ImportStateVerify: true,
+						ImportStateIdFunc: func(s *terraform.State) (string, error) {
+							return fmt.Sprintf("[%s] tf-test-dir", os.Getenv("VSPHERE_DATASTORE")), nil
+						},
+						Config: testAccResourceVSphereDatastoreDirectoryConfig("tf-test-dir"),
+					},
+				},
+			},
+		},
+	}
+
+	if err := ioutil.WriteFile(testAccResourceVSphereDatastoreDirectorySourceFile, []byte("tf_test\n"), 0644); err != nil {
+		t.Fatalf("error writing test file: %s", err)
+	}
+	defer os.Remove(testAccResourceVSphereDatastoreDirectorySourceFile)
+
+	for _, tc := range testAccResourceVSphereDatastoreDirectoryCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tp = t
+			resource.Test(t, tc.testCase)
+		})
+	}
+}
+
+func testAccResourceVSphereDatastoreDirectoryPreCheck(t *testing.T) {
+	if os.Getenv("VSPHERE_DATACENTER") == "" {
+		t.Skip("set VSPHERE_DATACENTER to run vsphere_datastore_directory acceptance tests")
+	}
+	if os.Getenv("VSPHERE_DATASTORE") == "" {
+		t.Skip("set VSPHERE_DATASTORE to run vsphere_datastore_directory acceptance tests")
+	}
+}
+
+func testAccResourceVSphereDatastoreDirectoryExists(expected bool) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		rs, ok := s.RootModule().Resources["vsphere_datastore_directory.dir"]
+		if !ok {
+			if expected {
+				return errors.New("vsphere_datastore_directory.dir not found in state")
+			}
+			return nil
+		}
+		client := testAccProvider.Meta().(*VSphereClient).vimClient
+		dc, err := datacenterFromID(client, rs.Primary.Attributes["datacenter_id"])
+		if err != nil {
+			return err
+		}
+		ds, err := datastoreFromPath(client, rs.Primary.Attributes["datastore"], dc)
+		if err != nil {
+			return err
+		}
+		actual, err := datastoreDirectoryExists(ds, rs.Primary.Attributes["path"])
+		if err != nil {
+			return err
+		}
+		if expected != actual {
+			return fmt.Errorf("expected directory %q to exist to be %t, got %t", rs.Primary.Attributes["path"], expected, actual)
+		}
+		return nil
+	}
+}
+
+func testAccResourceVSphereDatastoreDirectoryConfig(path string) string {
+	return fmt.Sprintf(`
+data "vsphere_datacenter" "datacenter" {
+  name = "%s"
+}
+
+resource "vsphere_datastore_directory" "dir" {
+  datacenter_id = "${data.vsphere_datacenter.datacenter.id}"
+  datastore     = "%s"
+  path          = "%s"
+}
+`, os.Getenv("VSPHERE_DATACENTER"), os.Getenv("VSPHERE_DATASTORE"), path)
+}
+
+func testAccResourceVSphereDatastoreDirectoryConfigWithFile() string {
+	return fmt.Sprintf(`
+data "vsphere_datacenter" "datacenter" {
+  name = "%s"
+}
+
+resource "vsphere_datastore_directory" "dir" {
+  datacenter_id = "${data.vsphere_datacenter.datacenter.id}"
+  datastore     = "%s"
+  path          = "tf-test-dir"
+}
+
+resource "vsphere_file" "file" {
+  datacenter       = "${data.vsphere_datacenter.datacenter.name}"
+  datastore        = "${vsphere_datastore_directory.dir.datastore}"
+  source_file      = "%s"
+  destination_file = "${vsphere_datastore_directory.dir.path}/tf_test.txt"
+}
+`, os.Getenv("VSPHERE_DATACENTER"), os.Getenv("VSPHERE_DATASTORE"), testAccResourceVSphereDatastoreDirectorySourceFile)
+}
+
+func testAccResourceVSphereDatastoreDirectoryConfigFileOnly() string {
+	return fmt.Sprintf(`
+data "vsphere_datacenter" "datacenter" {
+  name = "%s"
+}
+
+resource "vsphere_file" "file" {
+  datacenter       = "${data.vsphere_datacenter.datacenter.name}"
+  datastore        = "%s"
+  source_file      = "%s"
+  destination_file = "tf-test-dir/tf_test.txt"
+}
+`, os.Getenv("VSPHERE_DATACENTER"), os.Getenv("VSPHERE_DATASTORE"), testAccResourceVSphereDatastoreDirectorySourceFile)
+}
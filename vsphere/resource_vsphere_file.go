@@ -3,13 +3,14 @@ package vsphere
 import (
 	"fmt"
 	"log"
+	"os"
 	"strings"
 
+	"github.com/hashicorp/terraform/config"
 	"github.com/hashicorp/terraform/helper/schema"
 	"github.com/vmware/govmomi"
 	"github.com/vmware/govmomi/find"
 	"github.com/vmware/govmomi/object"
-	"github.com/vmware/govmomi/vim25/soap"
 	"golang.org/x/net/context"
 )
 
@@ -22,6 +23,7 @@ type file struct {
 	destinationFile   string
 	createDirectories bool
 	copyFile          bool
+	sourceFileSHA256  string
 }
 
 func resourceVSphereFile() *schema.Resource {
@@ -31,6 +33,8 @@ func resourceVSphereFile() *schema.Resource {
 		Update: resourceVSphereFileUpdate,
 		Delete: resourceVSphereFileDelete,
 
+		CustomizeDiff: resourceVSphereFileCustomizeDiff,
+
 		Schema: map[string]*schema.Schema{
 			"datacenter": {
 				Type:     schema.TypeString,
@@ -69,6 +73,18 @@ func resourceVSphereFile() *schema.Resource {
 				Type:     schema.TypeBool,
 				Optional: true,
 			},
+
+			"source_file_hash": {
+				Type:        schema.TypeString,
+				Description: "A user-supplied hash of the contents of source_file. When defined, the local file is not hashed during plan, and the file is uploaded again whenever this value changes.",
+				Optional:    true,
+			},
+
+			"source_file_sha256": {
+				Type:        schema.TypeString,
+				Description: "The SHA-256 checksum of source_file as of the last upload. The file is uploaded again when the checksum of the local file changes.",
+				Computed:    true,
+			},
 		},
 	}
 }
@@ -122,6 +138,7 @@ func resourceVSphereFileCreate(d *schema.ResourceData, meta interface{}) error {
 	}
 
 	d.SetId(fmt.Sprintf("[%v] %v/%v", f.datastore, f.datacenter, f.destinationFile))
+	d.Set("source_file_sha256", f.sourceFileSHA256)
 	log.Printf("[INFO] Created file: %s", f.destinationFile)
 
 	return resourceVSphereFileRead(d, meta)
@@ -182,10 +199,9 @@ func createFile(client *govmomi.Client, f *file) error {
 			return fmt.Errorf("error %s", err)
 		}
 
-		p := soap.DefaultUpload
-		err = client.Client.UploadFile(f.sourceFile, dsurl, &p)
+		f.sourceFileSHA256, err = uploadDatastoreFile(client, f.sourceFile, dsurl)
 		if err != nil {
-			return fmt.Errorf("error %s", err)
+			return err
 		}
 	}
 
@@ -250,6 +266,18 @@ func resourceVSphereFileRead(d *schema.ResourceData, meta interface{}) error {
 		if !ok {
 			return err
 		}
+		return nil
+	}
+
+	// Files uploaded with an earlier version of this provider have no checksum
+	// in state. Seed it from the local file, so that the file is only uploaded
+	// again when its contents change.
+	if f.sourceDatacenter == "" && f.sourceDatastore == "" && d.Get("source_file_hash").(string) == "" && d.Get("source_file_sha256").(string) == "" {
+		if sum, err := fileSHA256(f.sourceFile); err == nil {
+			d.Set("source_file_sha256", sum)
+		} else {
+			log.Printf("[DEBUG] could not calculate checksum of %q, leaving it unset: %s", f.sourceFile, err)
+		}
 	}
 
 	return nil
@@ -323,6 +351,76 @@ func resourceVSphereFileUpdate(d *schema.ResourceData, meta interface{}) error {
 		}
 	}
 
+	if resourceVSphereFileNeedsUpload(d) {
+		// Contents of the local file have changed, upload it again to the
+		// (possibly new) destination.
+		f := file{
+			datacenter:      d.Get("datacenter").(string),
+			datastore:       d.Get("datastore").(string),
+			sourceFile:      d.Get("source_file").(string),
+			destinationFile: d.Get("destination_file").(string),
+		}
+		log.Printf("[DEBUG] contents of %q have changed, uploading again", f.sourceFile)
+		client := meta.(*VSphereClient).vimClient
+		if err := createFile(client, &f); err != nil {
+			return err
+		}
+		d.Set("source_file_sha256", f.sourceFileSHA256)
+	}
+
+	return nil
+}
+
+// resourceVSphereFileNeedsUpload returns true if the file is an upload (as
+// opposed to a copy within vSphere) and the contents of the local file have
+// changed since the last upload.
+func resourceVSphereFileNeedsUpload(d *schema.ResourceData) bool {
+	if d.Get("source_datacenter").(string) != "" || d.Get("source_datastore").(string) != "" {
+		return false
+	}
+	if d.HasChange("source_file_hash") && d.Get("source_file_hash").(string) != "" {
+		return true
+	}
+	return d.HasChange("source_file_sha256")
+}
+
+// resourceVSphereFileCustomizeDiff calculates the checksum of the local
+// source file, so that changes to its contents are reflected in the diff. This
+// is skipped for copies within vSphere, or when a hash has been supplied in
+// source_file_hash.
+func resourceVSphereFileCustomizeDiff(d *schema.ResourceDiff, meta interface{}) error {
+	if d.Get("source_datacenter").(string) != "" || d.Get("source_datastore").(string) != "" {
+		return nil
+	}
+	if d.Get("source_file_hash").(string) != "" {
+		return nil
+	}
+	name := d.Get("source_file").(string)
+	if name == "" || name == config.UnknownVariableValue {
+		// The path is not known yet, so neither is the checksum.
+		return d.SetNewComputed("source_file_sha256")
+	}
+	sum, err := fileSHA256(name)
+	if err != nil {
+		if os.IsNotExist(err) {
+			if d.Get("source_file_sha256").(string) != "" {
+				// The file has been uploaded before, and is no longer around
+				// locally, such as on a different machine or after a cleanup of
+				// build artifacts. Keep the uploaded file as it is.
+				log.Printf("[DEBUG] %q does not exist, keeping the uploaded file", name)
+				return nil
+			}
+			// The file may be created during apply, so defer the checksum until
+			// then.
+			log.Printf("[DEBUG] %q does not exist yet, deferring checksum to apply", name)
+			return d.SetNewComputed("source_file_sha256")
+		}
+		return fmt.Errorf("error calculating checksum of %q: %s", name, err)
+	}
+	if sum != d.Get("source_file_sha256").(string) {
+		log.Printf("[DEBUG] checksum of %q has changed to %s", name, sum)
+		return d.SetNew("source_file_sha256", sum)
+	}
 	return nil
 }
 
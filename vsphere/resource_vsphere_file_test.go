@@ -1,6 +1,7 @@
 package vsphere
 
 import (
+	"crypto/sha256"
 	"fmt"
 	"io/ioutil"
 	"os"
@@ -54,6 +55,68 @@ func TestAccVSphereFile_basic(t *testing.T) {
 	os.Remove(testVmdkFile)
 }
 
+// File upload, followed by a change to the contents of the local file, which
+// should trigger another upload
+func TestAccVSphereFile_changeContents(t *testing.T) {
+	testVmdkFileData := []byte("# Disk DescriptorFile\n")
+	testVmdkFileDataChanged := []byte("# Disk DescriptorFile\n# Changed\n")
+	testVmdkFile := "/tmp/tf_test.vmdk"
+	err := ioutil.WriteFile(testVmdkFile, testVmdkFileData, 0644)
+	if err != nil {
+		t.Errorf("error %s", err)
+		return
+	}
+
+	datacenter := os.Getenv("VSPHERE_DATACENTER")
+	datastore := os.Getenv("VSPHERE_DATASTORE")
+	testMethod := "change_contents"
+	resourceName := "vsphere_file." + testMethod
+	destinationFile := "tf_file_test.vmdk"
+	sourceFile := testVmdkFile
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckVSphereFileDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(
+					testAccCheckVSphereFileConfig,
+					testMethod,
+					datacenter,
+					datastore,
+					sourceFile,
+					destinationFile,
+				),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckVSphereFileExists(resourceName, destinationFile, true),
+					resource.TestCheckResourceAttr(resourceName, "source_file_sha256", fmt.Sprintf("%x", sha256.Sum256(testVmdkFileData))),
+				),
+			},
+			{
+				PreConfig: func() {
+					if err := ioutil.WriteFile(testVmdkFile, testVmdkFileDataChanged, 0644); err != nil {
+						t.Fatalf("error %s", err)
+					}
+				},
+				Config: fmt.Sprintf(
+					testAccCheckVSphereFileConfig,
+					testMethod,
+					datacenter,
+					datastore,
+					sourceFile,
+					destinationFile,
+				),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckVSphereFileExists(resourceName, destinationFile, true),
+					resource.TestCheckResourceAttr(resourceName, "source_file_sha256", fmt.Sprintf("%x", sha256.Sum256(testVmdkFileDataChanged))),
+				),
+			},
+		},
+	})
+	os.Remove(testVmdkFile)
+}
+
 // Basic file copy within vSphere
 func TestAccVSphereFile_basicUploadAndCopy(t *testing.T) {
 	testVmdkFileData := []byte("# Disk DescriptorFile\n")
@@ -0,0 +1,276 @@
+package vsphere
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/hashicorp/terraform/helper/schema"
+	"github.com/hashicorp/terraform/helper/validation"
+	"github.com/vmware/govmomi"
+	"github.com/vmware/govmomi/vim25/types"
+	"github.com/vmware/vic/pkg/vsphere/tags"
+)
+
+func resourceVSphereFirstClassDisk() *schema.Resource {
+	return &schema.Resource{
+		Create:        resourceVSphereFirstClassDiskCreate,
+		Read:          resourceVSphereFirstClassDiskRead,
+		Update:        resourceVSphereFirstClassDiskUpdate,
+		Delete:        resourceVSphereFirstClassDiskDelete,
+		CustomizeDiff: resourceVSphereFirstClassDiskCustomizeDiff,
+
+		Schema: map[string]*schema.Schema{
+			"name": &schema.Schema{
+				Type:         schema.TypeString,
+				Description:  "The name of the disk.",
+				Required:     true,
+				ValidateFunc: validation.NoZeroValues,
+			},
+			"datastore": &schema.Schema{
+				Type:        schema.TypeString,
+				Description: "The name or path of the datastore to create the disk on.",
+				Required:    true,
+				ForceNew:    true,
+			},
+			"datacenter_id": &schema.Schema{
+				Type:        schema.TypeString,
+				Description: "The managed object ID of the datacenter the datastore is in. This is required if the supplied datastore is not an absolute path containing a datacenter and there are multiple datacenters in your infrastructure.",
+				Optional:    true,
+				Computed:    true,
+				ForceNew:    true,
+			},
+			"size": &schema.Schema{
+				Type:         schema.TypeInt,
+				Description:  "The size of the disk, in GB. The disk can be grown in place, but not shrunk.",
+				Required:     true,
+				ValidateFunc: validation.IntAtLeast(1),
+			},
+			"provisioning_type": &schema.Schema{
+				Type:        schema.TypeString,
+				Description: "The provisioning type of the disk. Can be one of thin, eagerZeroedThick, or lazyZeroedThick.",
+				Optional:    true,
+				ForceNew:    true,
+				Default:     string(types.BaseConfigInfoDiskFileBackingInfoProvisioningTypeThin),
+				ValidateFunc: validation.StringInSlice([]string{
+					string(types.BaseConfigInfoDiskFileBackingInfoProvisioningTypeThin),
+					string(types.BaseConfigInfoDiskFileBackingInfoProvisioningTypeEagerZeroedThick),
+					string(types.BaseConfigInfoDiskFileBackingInfoProvisioningTypeLazyZeroedThick),
+				}, false),
+			},
+			"file_path": &schema.Schema{
+				Type:        schema.TypeString,
+				Description: "The path to the virtual disk file backing the disk, in [datastore] path format.",
+				Computed:    true,
+			},
+			vSphereTagAttributeKey: tagsSchema(),
+		},
+	}
+}
+
+func resourceVSphereFirstClassDiskCreate(d *schema.ResourceData, meta interface{}) error {
+	client := meta.(*VSphereClient).vimClient
+	if err := validateVirtualCenter(client); err != nil {
+		return err
+	}
+	// Fetch the tags client early so that we fail before creating the disk if
+	// tags are defined but the endpoint does not support them.
+	tagsClient, err := tagsClientIfDefined(d, meta)
+	if err != nil {
+		return err
+	}
+	ds, _, err := datastoreFromResourceData(d, client)
+	if err != nil {
+		return err
+	}
+
+	spec := types.VslmCreateSpec{
+		Name:         d.Get("name").(string),
+		CapacityInMB: int64(d.Get("size").(int)) * 1024,
+		BackingSpec: &types.VslmCreateSpecDiskFileBackingSpec{
+			VslmCreateSpecBackingSpec: types.VslmCreateSpecBackingSpec{
+				Datastore: ds.Reference(),
+			},
+			ProvisioningType: d.Get("provisioning_type").(string),
+		},
+	}
+	log.Printf("[DEBUG] Creating first class disk %q on datastore %q", spec.Name, ds.Name())
+	obj, err := createFirstClassDisk(client, spec)
+	if err != nil {
+		return fmt.Errorf("error creating first class disk: %s", err)
+	}
+	d.SetId(obj.Config.Id.Id)
+
+	if tagsClient != nil {
+		if err := processFirstClassDiskTagDiff(client, tagsClient, d); err != nil {
+			return err
+		}
+	}
+	return resourceVSphereFirstClassDiskRead(d, meta)
+}
+
+func resourceVSphereFirstClassDiskRead(d *schema.ResourceData, meta interface{}) error {
+	client := meta.(*VSphereClient).vimClient
+	ds, dc, err := datastoreFromResourceData(d, client)
+	if err != nil {
+		return err
+	}
+	obj, err := retrieveFirstClassDisk(client, d.Id(), ds)
+	if err != nil {
+		if isAnyNotFoundError(err) {
+			log.Printf("[DEBUG] First class disk %q not found, marking resource as gone", d.Id())
+			d.SetId("")
+			return nil
+		}
+		return fmt.Errorf("error fetching first class disk %q: %s", d.Id(), err)
+	}
+
+	d.Set("datacenter_id", dc.Reference().Value)
+	d.Set("name", obj.Config.Name)
+	d.Set("size", obj.Config.CapacityInMB/1024)
+	if backing, ok := obj.Config.Backing.(*types.BaseConfigInfoDiskFileBackingInfo); ok {
+		d.Set("file_path", backing.FilePath)
+		d.Set("provisioning_type", backing.ProvisioningType)
+	}
+
+	// Read tags if we have the ability to do so
+	if tagsClient, _ := meta.(*VSphereClient).TagsClient(); tagsClient != nil {
+		if err := readTagsForFirstClassDisk(client, tagsClient, d); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func resourceVSphereFirstClassDiskUpdate(d *schema.ResourceData, meta interface{}) error {
+	client := meta.(*VSphereClient).vimClient
+	tagsClient, err := tagsClientIfDefined(d, meta)
+	if err != nil {
+		return err
+	}
+	ds, _, err := datastoreFromResourceData(d, client)
+	if err != nil {
+		return err
+	}
+
+	if d.HasChange("name") {
+		name := d.Get("name").(string)
+		log.Printf("[DEBUG] Renaming first class disk %q to %q", d.Id(), name)
+		if err := renameFirstClassDisk(client, d.Id(), ds, name); err != nil {
+			return fmt.Errorf("error renaming first class disk %q: %s", d.Id(), err)
+		}
+	}
+	if d.HasChange("size") {
+		size := int64(d.Get("size").(int)) * 1024
+		log.Printf("[DEBUG] Extending first class disk %q to %d MB", d.Id(), size)
+		if err := extendFirstClassDisk(client, d.Id(), ds, size); err != nil {
+			return fmt.Errorf("error extending first class disk %q: %s", d.Id(), err)
+		}
+	}
+	if tagsClient != nil {
+		if err := processFirstClassDiskTagDiff(client, tagsClient, d); err != nil {
+			return err
+		}
+	}
+	return resourceVSphereFirstClassDiskRead(d, meta)
+}
+
+func resourceVSphereFirstClassDiskDelete(d *schema.ResourceData, meta interface{}) error {
+	client := meta.(*VSphereClient).vimClient
+	ds, _, err := datastoreFromResourceData(d, client)
+	if err != nil {
+		return err
+	}
+	log.Printf("[DEBUG] Deleting first class disk %q", d.Id())
+	if err := deleteFirstClassDisk(client, d.Id(), ds); err != nil {
+		return fmt.Errorf("error deleting first class disk %q: %s", d.Id(), err)
+	}
+	return nil
+}
+
+// resourceVSphereFirstClassDiskCustomizeDiff checks to make sure that the disk
+// is not being shrunk, as first class disks can only be grown in place.
+func resourceVSphereFirstClassDiskCustomizeDiff(d *schema.ResourceDiff, meta interface{}) error {
+	if d.Id() == "" || !d.HasChange("size") {
+		return nil
+	}
+	o, n := d.GetChange("size")
+	if n.(int) < o.(int) {
+		return fmt.Errorf("first class disks cannot be shrunk (current size: %d GB, new size: %d GB)", o.(int), n.(int))
+	}
+	return nil
+}
+
+// firstClassDiskTagEntry resolves the supplied tag ID to the category and tag
+// names used by the VStorageObjectManager tagging API.
+func firstClassDiskTagEntry(tagsClient *tags.RestClient, id string) (types.VslmTagEntry, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), defaultAPITimeout)
+	defer cancel()
+	tag, err := tagsClient.GetTag(ctx, id)
+	if err != nil {
+		return types.VslmTagEntry{}, fmt.Errorf("could not get tag for ID %q: %s", id, err)
+	}
+	category, err := tagsClient.GetCategory(ctx, tag.CategoryID)
+	if err != nil {
+		return types.VslmTagEntry{}, fmt.Errorf("could not get category for ID %q: %s", tag.CategoryID, err)
+	}
+	return types.VslmTagEntry{
+		TagName:            tag.Name,
+		ParentCategoryName: category.Name,
+	}, nil
+}
+
+// readTagsForFirstClassDisk reads the tags attached to the first class disk
+// and saves their IDs in the supplied ResourceData.
+//
+// The VStorageObjectManager only reports tags by category and tag name, so
+// these are resolved back to IDs through the tags client.
+func readTagsForFirstClassDisk(client *govmomi.Client, tagsClient *tags.RestClient, d *schema.ResourceData) error {
+	entries, err := firstClassDiskTags(client, d.Id())
+	if err != nil {
+		return fmt.Errorf("error reading tags for first class disk %q: %s", d.Id(), err)
+	}
+	var ids []string
+	for _, entry := range entries {
+		categoryID, err := tagCategoryByName(tagsClient, entry.ParentCategoryName)
+		if err != nil {
+			return err
+		}
+		id, err := tagByName(tagsClient, entry.TagName, categoryID)
+		if err != nil {
+			return err
+		}
+		ids = append(ids, id)
+	}
+	if err := d.Set(vSphereTagAttributeKey, ids); err != nil {
+		return fmt.Errorf("error saving tag IDs to resource data: %s", err)
+	}
+	return nil
+}
+
+// processFirstClassDiskTagDiff attaches and detaches tags on the first class
+// disk to match the tag IDs in the supplied ResourceData.
+func processFirstClassDiskTagDiff(client *govmomi.Client, tagsClient *tags.RestClient, d *schema.ResourceData) error {
+	o, n := d.GetChange(vSphereTagAttributeKey)
+	oldSet := o.(*schema.Set)
+	newSet := n.(*schema.Set)
+	for _, id := range sliceInterfacesToStrings(oldSet.Difference(newSet).List()) {
+		entry, err := firstClassDiskTagEntry(tagsClient, id)
+		if err != nil {
+			return err
+		}
+		if err := detachFirstClassDiskTag(client, d.Id(), entry); err != nil {
+			return fmt.Errorf("error detaching tag %q from first class disk %q: %s", id, d.Id(), err)
+		}
+	}
+	for _, id := range sliceInterfacesToStrings(newSet.Difference(oldSet).List()) {
+		entry, err := firstClassDiskTagEntry(tagsClient, id)
+		if err != nil {
+			return err
+		}
+		if err := attachFirstClassDiskTag(client, d.Id(), entry); err != nil {
+			return fmt.Errorf("error attaching tag %q to first class disk %q: %s", id, d.Id(), err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,157 @@
+package vsphere
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"testing"
+
+	"github.com/hashicorp/terraform/helper/resource"
+	"github.com/hashicorp/terraform/terraform"
+)
+
+func TestAccResourceVSphereFirstClassDisk(t *testing.T) {
+	var tp *testing.T
+	testAccResourceVSphereFirstClassDiskCases := []struct {
+		name     string
+		testCase resource.TestCase
+	}{
+		{
+			"basic",
+			resource.TestCase{
+				PreCheck: func() {
+					testAccPreCheck(tp)
+					testAccResourceVSphereFirstClassDiskPreCheck(tp)
+				},
+				Providers:    testAccProviders,
+				CheckDestroy: testAccResourceVSphereFirstClassDiskExists(false),
+				Steps: []resource.TestStep{
+					{
+						Config: testAccResourceVSphereFirstClassDiskConfig("tf-test-fcd", 1),
+						Check: resource.ComposeTestCheckFunc(
+							testAccResourceVSphereFirstClassDiskExists(true),
+							resource.TestCheckResourceAttr("vsphere_first_class_disk.disk", "size", "1"),
+							resource.TestCheckResourceAttr("vsphere_first_class_disk.disk", "provisioning_type", "thin"),
+							resource.TestMatchResourceAttr("vsphere_first_class_disk.disk", "file_path", regexp.MustCompile(`\.vmdk$`)),
+						),
+					},
+				},
+			},
+		},
+		{
+			"extend and rename",
+			resource.TestCase{
+				PreCheck: func() {
+					testAccPreCheck(tp)
+					testAccResourceVSphereFirstClassDiskPreCheck(tp)
+				},
+				Providers:    testAccProviders,
+				CheckDestroy: testAccResourceVSphereFirstClassDiskExists(false),
+				Steps: []resource.TestStep{
+					{
+						Config: testAccResourceVSphereFirstClassDiskConfig("tf-test-fcd", 1),
+						Check: resource.ComposeTestCheckFunc(
+							testAccResourceVSphereFirstClassDiskExists(true),
+						),
+					},
+					{
+						Config: testAccResourceVSphereFirstClassDiskConfig("tf-test-fcd-renamed", 2),
+						Check: resource.ComposeTestCheckFunc(
+							testAccResourceVSphereFirstClassDiskExists(true),
+							resource.TestCheckResourceAttr("vsphere_first_class_disk.disk", "name", "tf-test-fcd-renamed"),
+							resource.TestCheckResourceAttr("vsphere_first_class_disk.disk", "size", "2"),
+						),
+					},
+				},
+			},
+		},
+		{
+			"shrink error",
+			resource.TestCase{
+				PreCheck: func() {
+					testAccPreCheck(tp)
+					testAccResourceVSphereFirstClassDiskPreCheck(tp)
+				},
+				Providers:    testAccProviders,
+				CheckDestroy: testAccResourceVSphereFirstClassDiskExists(false),
+				Steps: []resource.TestStep{
+					{
+						Config: testAccResourceVSphereFirstClassDiskConfig("tf-test-fcd", 2),
+						Check: resource.ComposeTestCheckFunc(
+							testAccResourceVSphereFirstClassDiskExists(true),
+						),
+					},
+					{
+						Config:      testAccResourceVSphereFirstClassDiskConfig("tf-test-fcd", 1),
+						PlanOnly:    true,
+						ExpectError: regexp.MustCompile("first class disks cannot be shrunk"),
+					},
+				},
+			},
+		},
+	}
+
+	for _, tc := range testAccResourceVSphereFirstClassDiskCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tp = t
+			resource.Test(t, tc.testCase)
+		})
+	}
+}
+
+func testAccResourceVSphereFirstClassDiskPreCheck(t *testing.T) {
+	if os.Getenv("VSPHERE_DATACENTER") == "" {
+		t.Skip("set VSPHERE_DATACENTER to run vsphere_first_class_disk acceptance tests")
+	}
+	if os.Getenv("VSPHERE_DATASTORE") == "" {
+		t.Skip("set VSPHERE_DATASTORE to run vsphere_first_class_disk acceptance tests")
+	}
+}
+
+func testAccResourceVSphereFirstClassDiskExists(expected bool) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		rs, ok := s.RootModule().Resources["vsphere_first_class_disk.disk"]
+		if !ok {
+			if expected {
+				return errors.New("vsphere_first_class_disk.disk not found in state")
+			}
+			return nil
+		}
+		client := testAccProvider.Meta().(*VSphereClient).vimClient
+		dc, err := datacenterFromID(client, rs.Primary.Attributes["datacenter_id"])
+		if err != nil {
+			return err
+		}
+		ds, err := datastoreFromPath(client, rs.Primary.Attributes["datastore"], dc)
+		if err != nil {
+			return err
+		}
+		_, err = retrieveFirstClassDisk(client, rs.Primary.ID, ds)
+		if err != nil {
+			if isAnyNotFoundError(err) && !expected {
+				return nil
+			}
+			return err
+		}
+		if !expected {
+			return fmt.Errorf("expected first class disk %q to be missing", rs.Primary.ID)
+		}
+		return nil
+	}
+}
+
+func testAccResourceVSphereFirstClassDiskConfig(name string, size int) string {
+	return fmt.Sprintf(`
+data "vsphere_datacenter" "datacenter" {
+  name = "%s"
+}
+
+resource "vsphere_first_class_disk" "disk" {
+  name          = "%s"
+  datacenter_id = "${data.vsphere_datacenter.datacenter.id}"
+  datastore     = "%s"
+  size          = %d
+}
+`, os.Getenv("VSPHERE_DATACENTER"), name, os.Getenv("VSPHERE_DATASTORE"), size)
+}
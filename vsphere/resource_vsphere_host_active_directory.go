@@ -0,0 +1,232 @@
+package vsphere
+
+import (
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/hashicorp/terraform/helper/schema"
+)
+
+func resourceVSphereHostActiveDirectory() *schema.Resource {
+	return &schema.Resource{
+		Create: resourceVSphereHostActiveDirectoryCreate,
+		Read:   resourceVSphereHostActiveDirectoryRead,
+		Update: resourceVSphereHostActiveDirectoryUpdate,
+		Delete: resourceVSphereHostActiveDirectoryDelete,
+		Importer: &schema.ResourceImporter{
+			State: resourceVSphereHostActiveDirectoryImport,
+		},
+
+		Schema: map[string]*schema.Schema{
+			"host_system_id": &schema.Schema{
+				Type:        schema.TypeString,
+				Description: "The managed object ID of the host to join to the domain.",
+				Required:    true,
+				ForceNew:    true,
+			},
+			"domain_name": &schema.Schema{
+				Type:        schema.TypeString,
+				Description: "The name of the Active Directory domain to join the host to.",
+				Required:    true,
+				ForceNew:    true,
+			},
+			"username": &schema.Schema{
+				Type:        schema.TypeString,
+				Description: "The name of a user with permissions to join the host to the domain. This is only used when joining the domain.",
+				Required:    true,
+			},
+			"password": &schema.Schema{
+				Type:        schema.TypeString,
+				Description: "The password for the user defined in username. This is only used when joining the domain.",
+				Required:    true,
+				Sensitive:   true,
+			},
+			"force_leave": &schema.Schema{
+				Type:        schema.TypeBool,
+				Description: "Leave the domain on destroy even if there are permissions defined for domain users or groups on the host.",
+				Optional:    true,
+			},
+			"nfs_username": &schema.Schema{
+				Type:        schema.TypeString,
+				Description: "The Kerberos principal used by the host to access NFS v4.1 datastores with Kerberos security.",
+				Optional:    true,
+			},
+			"nfs_password": &schema.Schema{
+				Type:        schema.TypeString,
+				Description: "The password for the principal defined in nfs_username.",
+				Optional:    true,
+				Sensitive:   true,
+			},
+			"domain_membership_status": &schema.Schema{
+				Type:        schema.TypeString,
+				Description: "The status of the host's membership in the domain.",
+				Computed:    true,
+			},
+			"trusted_domains": &schema.Schema{
+				Type:        schema.TypeList,
+				Description: "The domains trusted by the domain the host is joined to.",
+				Computed:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+			},
+		},
+	}
+}
+
+func resourceVSphereHostActiveDirectoryCreate(d *schema.ResourceData, meta interface{}) error {
+	client := meta.(*VSphereClient).vimClient
+	if err := validateHostActiveDirectoryNfsUser(d); err != nil {
+		return err
+	}
+	hsID := d.Get("host_system_id").(string)
+	ad, err := hostActiveDirectoryAuthenticationFromHostSystemID(client, hsID)
+	if err != nil {
+		return fmt.Errorf("error loading Active Directory store: %s", err)
+	}
+	info, err := hostActiveDirectoryInfo(ad)
+	if err != nil {
+		return err
+	}
+	domain := d.Get("domain_name").(string)
+	switch {
+	case info.JoinedDomain == "":
+		log.Printf("[DEBUG] Joining host %q to domain %q", hostSystemNameOrID(client, hsID), domain)
+		if err := joinDomain(client, ad, domain, d.Get("username").(string), d.Get("password").(string)); err != nil {
+			return fmt.Errorf("error joining domain %q: %s", domain, err)
+		}
+	case info.JoinedDomain != domain:
+		return fmt.Errorf("host %q is already joined to domain %q", hostSystemNameOrID(client, hsID), info.JoinedDomain)
+	default:
+		log.Printf("[DEBUG] Host %q is already joined to domain %q, skipping join", hostSystemNameOrID(client, hsID), domain)
+	}
+	d.SetId(hsID)
+
+	if err := resourceVSphereHostActiveDirectoryApplyNfsUser(d, meta); err != nil {
+		return err
+	}
+	return resourceVSphereHostActiveDirectoryRead(d, meta)
+}
+
+func resourceVSphereHostActiveDirectoryRead(d *schema.ResourceData, meta interface{}) error {
+	client := meta.(*VSphereClient).vimClient
+	hsID := d.Id()
+	ad, err := hostActiveDirectoryAuthenticationFromHostSystemID(client, hsID)
+	if err != nil {
+		return fmt.Errorf("error loading Active Directory store: %s", err)
+	}
+	info, err := hostActiveDirectoryInfo(ad)
+	if err != nil {
+		return err
+	}
+	if info.JoinedDomain == "" {
+		log.Printf("[DEBUG] Host %q is not joined to a domain, marking resource as gone", hostSystemNameOrID(client, hsID))
+		d.SetId("")
+		return nil
+	}
+	d.Set("host_system_id", hsID)
+	d.Set("domain_name", info.JoinedDomain)
+	d.Set("domain_membership_status", info.DomainMembershipStatus)
+	if err := d.Set("trusted_domains", info.TrustedDomain); err != nil {
+		return fmt.Errorf("error setting trusted domains: %s", err)
+	}
+
+	ss, err := hostStorageSystemFromHostSystemID(client, hsID)
+	if err != nil {
+		return fmt.Errorf("error loading storage system: %s", err)
+	}
+	user, err := queryNFSUser(ss)
+	if err != nil {
+		return fmt.Errorf("error querying NFS user: %s", err)
+	}
+	d.Set("nfs_username", user)
+
+	return nil
+}
+
+func resourceVSphereHostActiveDirectoryUpdate(d *schema.ResourceData, meta interface{}) error {
+	if err := validateHostActiveDirectoryNfsUser(d); err != nil {
+		return err
+	}
+	if d.HasChange("nfs_username") || d.HasChange("nfs_password") {
+		if err := resourceVSphereHostActiveDirectoryApplyNfsUser(d, meta); err != nil {
+			return err
+		}
+	}
+	return resourceVSphereHostActiveDirectoryRead(d, meta)
+}
+
+func resourceVSphereHostActiveDirectoryDelete(d *schema.ResourceData, meta interface{}) error {
+	client := meta.(*VSphereClient).vimClient
+	hsID := d.Id()
+	ss, err := hostStorageSystemFromHostSystemID(client, hsID)
+	if err != nil {
+		return fmt.Errorf("error loading storage system: %s", err)
+	}
+	if d.Get("nfs_username").(string) != "" {
+		if err := clearNFSUser(ss); err != nil {
+			return fmt.Errorf("error clearing NFS user: %s", err)
+		}
+	}
+
+	ad, err := hostActiveDirectoryAuthenticationFromHostSystemID(client, hsID)
+	if err != nil {
+		return fmt.Errorf("error loading Active Directory store: %s", err)
+	}
+	if err := leaveCurrentDomain(client, ad, d.Get("force_leave").(bool)); err != nil {
+		return fmt.Errorf("error leaving domain: %s", err)
+	}
+	return nil
+}
+
+func resourceVSphereHostActiveDirectoryImport(d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
+	// The ID is the host system ID. We only need to validate that the host is
+	// joined to a domain here - the rest is handled by read on refresh.
+	client := meta.(*VSphereClient).vimClient
+	ad, err := hostActiveDirectoryAuthenticationFromHostSystemID(client, d.Id())
+	if err != nil {
+		return nil, fmt.Errorf("error loading Active Directory store: %s", err)
+	}
+	info, err := hostActiveDirectoryInfo(ad)
+	if err != nil {
+		return nil, err
+	}
+	if info.JoinedDomain == "" {
+		return nil, fmt.Errorf("host %q is not joined to a domain", hostSystemNameOrID(client, d.Id()))
+	}
+	return []*schema.ResourceData{d}, nil
+}
+
+// resourceVSphereHostActiveDirectoryApplyNfsUser sets or clears the NFS
+// Kerberos credentials on the host, depending on whether or not nfs_username
+// is defined.
+func resourceVSphereHostActiveDirectoryApplyNfsUser(d *schema.ResourceData, meta interface{}) error {
+	client := meta.(*VSphereClient).vimClient
+	ss, err := hostStorageSystemFromHostSystemID(client, d.Get("host_system_id").(string))
+	if err != nil {
+		return fmt.Errorf("error loading storage system: %s", err)
+	}
+	user := d.Get("nfs_username").(string)
+	if user == "" {
+		if o, _ := d.GetChange("nfs_username"); o.(string) != "" {
+			if err := clearNFSUser(ss); err != nil {
+				return fmt.Errorf("error clearing NFS user: %s", err)
+			}
+		}
+		return nil
+	}
+	if err := setNFSUser(ss, user, d.Get("nfs_password").(string)); err != nil {
+		return fmt.Errorf("error setting NFS user: %s", err)
+	}
+	return nil
+}
+
+// validateHostActiveDirectoryNfsUser ensures that nfs_username and
+// nfs_password are either both defined, or both omitted.
+func validateHostActiveDirectoryNfsUser(d *schema.ResourceData) error {
+	user := d.Get("nfs_username").(string)
+	password := d.Get("nfs_password").(string)
+	if (user == "") != (password == "") {
+		return errors.New("nfs_username and nfs_password must be defined together")
+	}
+	return nil
+}
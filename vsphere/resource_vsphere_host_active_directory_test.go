@@ -0,0 +1,252 @@
+package vsphere
+
+import (
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/hashicorp/terraform/helper/resource"
+	"github.com/hashicorp/terraform/terraform"
+)
+
+func TestAccResourceVSphereHostActiveDirectory(t *testing.T) {
+	var tp *testing.T
+	testAccResourceVSphereHostActiveDirectoryCases := []struct {
+		name     string
+		testCase resource.TestCase
+	}{
+		{
+			"basic",
+			resource.TestCase{
+				PreCheck: func() {
+					testAccPreCheck(tp)
+					testAccResourceVSphereHostActiveDirectoryPreCheck(tp)
+				},
+				Providers:    testAccProviders,
+				CheckDestroy: testAccResourceVSphereHostActiveDirectoryJoined(false),
+				Steps: []resource.TestStep{
+					{
+						Config: testAccResourceVSphereHostActiveDirectoryConfig(),
+						Check: resource.ComposeTestCheckFunc(
+							testAccResourceVSphereHostActiveDirectoryJoined(true),
+							testAccResourceVSphereHostActiveDirectoryHasNfsUser(""),
+						),
+					},
+				},
+			},
+		},
+		{
+			"with NFS user",
+			resource.TestCase{
+				PreCheck: func() {
+					testAccPreCheck(tp)
+					testAccResourceVSphereHostActiveDirectoryPreCheck(tp)
+				},
+				Providers:    testAccProviders,
+				CheckDestroy: testAccResourceVSphereHostActiveDirectoryJoined(false),
+				Steps: []resource.TestStep{
+					{
+						Config: testAccResourceVSphereHostActiveDirectoryConfigNfsUser(),
+						Check: resource.ComposeTestCheckFunc(
+							testAccResourceVSphereHostActiveDirectoryJoined(true),
+							testAccResourceVSphereHostActiveDirectoryHasNfsUser(os.Getenv("VSPHERE_AD_NFS_USER")),
+						),
+					},
+				},
+			},
+		},
+		{
+			"basic, then add NFS user",
+			resource.TestCase{
+				PreCheck: func() {
+					testAccPreCheck(tp)
+					testAccResourceVSphereHostActiveDirectoryPreCheck(tp)
+				},
+				Providers:    testAccProviders,
+				CheckDestroy: testAccResourceVSphereHostActiveDirectoryJoined(false),
+				Steps: []resource.TestStep{
+					{
+						Config: testAccResourceVSphereHostActiveDirectoryConfig(),
+						Check: resource.ComposeTestCheckFunc(
+							testAccResourceVSphereHostActiveDirectoryJoined(true),
+						),
+					},
+					{
+						Config: testAccResourceVSphereHostActiveDirectoryConfigNfsUser(),
+						Check: resource.ComposeTestCheckFunc(
+							testAccResourceVSphereHostActiveDirectoryJoined(true),
+							testAccResourceVSphereHostActiveDirectoryHasNfsUser(os.Getenv("VSPHERE_AD_NFS_USER")),
+						),
+					},
+				},
+			},
+		},
+	}
+
+	for _, tc := range testAccResourceVSphereHostActiveDirectoryCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tp = t
+			resource.Test(t, tc.testCase)
+		})
+	}
+}
+
+func testAccResourceVSphereHostActiveDirectoryPreCheck(t *testing.T) {
+	if os.Getenv("VSPHERE_ESXI_HOST") == "" {
+		t.Skip("set VSPHERE_ESXI_HOST to run vsphere_host_active_directory acceptance tests")
+	}
+	if os.Getenv("VSPHERE_AD_DOMAIN") == "" {
+		t.Skip("set VSPHERE_AD_DOMAIN to run vsphere_host_active_directory acceptance tests")
+	}
+	if os.Getenv("VSPHERE_AD_USER") == "" {
+		t.Skip("set VSPHERE_AD_USER to run vsphere_host_active_directory acceptance tests")
+	}
+	if os.Getenv("VSPHERE_AD_PASSWORD") == "" {
+		t.Skip("set VSPHERE_AD_PASSWORD to run vsphere_host_active_directory acceptance tests")
+	}
+	if os.Getenv("VSPHERE_AD_NFS_USER") == "" {
+		t.Skip("set VSPHERE_AD_NFS_USER to run vsphere_host_active_directory acceptance tests")
+	}
+	if os.Getenv("VSPHERE_AD_NFS_PASSWORD") == "" {
+		t.Skip("set VSPHERE_AD_NFS_PASSWORD to run vsphere_host_active_directory acceptance tests")
+	}
+}
+
+func testAccResourceVSphereHostActiveDirectoryJoined(expected bool) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		vars, err := testClientVariablesForResource(s, "vsphere_host_active_directory.ad")
+		if err != nil {
+			return err
+		}
+		ad, err := hostActiveDirectoryAuthenticationFromHostSystemID(vars.client, vars.resourceID)
+		if err != nil {
+			return err
+		}
+		info, err := hostActiveDirectoryInfo(ad)
+		if err != nil {
+			return err
+		}
+		actual := info.JoinedDomain != ""
+		if expected != actual {
+			return fmt.Errorf("expected host to be joined to a domain to be %t, got %t (domain: %q)", expected, actual, info.JoinedDomain)
+		}
+		return nil
+	}
+}
+
+func testAccResourceVSphereHostActiveDirectoryHasNfsUser(expected string) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		vars, err := testClientVariablesForResource(s, "vsphere_host_active_directory.ad")
+		if err != nil {
+			return err
+		}
+		ss, err := hostStorageSystemFromHostSystemID(vars.client, vars.resourceID)
+		if err != nil {
+			return err
+		}
+		actual, err := queryNFSUser(ss)
+		if err != nil {
+			return err
+		}
+		if expected != actual {
+			return fmt.Errorf("expected NFS user to be %q, got %q", expected, actual)
+		}
+		return nil
+	}
+}
+
+func testAccResourceVSphereHostActiveDirectoryConfig() string {
+	return fmt.Sprintf(`
+variable "domain" {
+  type    = "string"
+  default = "%s"
+}
+
+variable "username" {
+  type    = "string"
+  default = "%s"
+}
+
+variable "password" {
+  type    = "string"
+  default = "%s"
+}
+
+data "vsphere_datacenter" "datacenter" {
+  name = "%s"
+}
+
+data "vsphere_host" "esxi_host" {
+  name          = "%s"
+  datacenter_id = "${data.vsphere_datacenter.datacenter.id}"
+}
+
+resource "vsphere_host_active_directory" "ad" {
+  host_system_id = "${data.vsphere_host.esxi_host.id}"
+  domain_name    = "${var.domain}"
+  username       = "${var.username}"
+  password       = "${var.password}"
+}
+`,
+		os.Getenv("VSPHERE_AD_DOMAIN"),
+		os.Getenv("VSPHERE_AD_USER"),
+		os.Getenv("VSPHERE_AD_PASSWORD"),
+		os.Getenv("VSPHERE_DATACENTER"),
+		os.Getenv("VSPHERE_ESXI_HOST"),
+	)
+}
+
+func testAccResourceVSphereHostActiveDirectoryConfigNfsUser() string {
+	return fmt.Sprintf(`
+variable "domain" {
+  type    = "string"
+  default = "%s"
+}
+
+variable "username" {
+  type    = "string"
+  default = "%s"
+}
+
+variable "password" {
+  type    = "string"
+  default = "%s"
+}
+
+variable "nfs_username" {
+  type    = "string"
+  default = "%s"
+}
+
+variable "nfs_password" {
+  type    = "string"
+  default = "%s"
+}
+
+data "vsphere_datacenter" "datacenter" {
+  name = "%s"
+}
+
+data "vsphere_host" "esxi_host" {
+  name          = "%s"
+  datacenter_id = "${data.vsphere_datacenter.datacenter.id}"
+}
+
+resource "vsphere_host_active_directory" "ad" {
+  host_system_id = "${data.vsphere_host.esxi_host.id}"
+  domain_name    = "${var.domain}"
+  username       = "${var.username}"
+  password       = "${var.password}"
+  nfs_username   = "${var.nfs_username}"
+  nfs_password   = "${var.nfs_password}"
+}
+`,
+		os.Getenv("VSPHERE_AD_DOMAIN"),
+		os.Getenv("VSPHERE_AD_USER"),
+		os.Getenv("VSPHERE_AD_PASSWORD"),
+		os.Getenv("VSPHERE_AD_NFS_USER"),
+		os.Getenv("VSPHERE_AD_NFS_PASSWORD"),
+		os.Getenv("VSPHERE_DATACENTER"),
+		os.Getenv("VSPHERE_ESXI_HOST"),
+	)
+}
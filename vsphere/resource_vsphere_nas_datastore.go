@@ -4,7 +4,9 @@ import (
 	"errors"
 	"fmt"
 
+	"github.com/hashicorp/terraform/config"
 	"github.com/hashicorp/terraform/helper/schema"
+	"github.com/hashicorp/terraform/helper/validation"
 	"github.com/vmware/govmomi/vim25/types"
 )
 
@@ -32,7 +34,20 @@ func resourceVSphereNasDatastore() *schema.Resource {
 			Optional:    true,
 			StateFunc:   normalizeFolderPath,
 		},
+		"maintenance_mode_enabled": &schema.Schema{
+			Type:        schema.TypeBool,
+			Description: "Put the datastore into maintenance mode. Entering maintenance mode waits for all virtual machines to be evacuated from the datastore.",
+			Optional:    true,
+		},
+		"maintenance_mode_timeout": &schema.Schema{
+			Type:         schema.TypeInt,
+			Description:  "The time, in minutes, to wait for the datastore to enter or exit maintenance mode.",
+			Optional:     true,
+			Default:      defaultDatastoreMaintenanceModeTimeout,
+			ValidateFunc: validation.IntAtLeast(1),
+		},
 	}
+	mergeSchema(s, schemaStorageIORMConfigSpec())
 	mergeSchema(s, schemaHostNasVolumeSpec())
 	mergeSchema(s, schemaDatastoreSummary())
 
@@ -40,10 +55,11 @@ func resourceVSphereNasDatastore() *schema.Resource {
 	s[vSphereTagAttributeKey] = tagsSchema()
 
 	return &schema.Resource{
-		Create: resourceVSphereNasDatastoreCreate,
-		Read:   resourceVSphereNasDatastoreRead,
-		Update: resourceVSphereNasDatastoreUpdate,
-		Delete: resourceVSphereNasDatastoreDelete,
+		Create:        resourceVSphereNasDatastoreCreate,
+		Read:          resourceVSphereNasDatastoreRead,
+		Update:        resourceVSphereNasDatastoreUpdate,
+		Delete:        resourceVSphereNasDatastoreDelete,
+		CustomizeDiff: resourceVSphereNasDatastoreCustomizeDiff,
 		Importer: &schema.ResourceImporter{
 			State: resourceVSphereNasDatastoreImport,
 		},
@@ -91,6 +107,15 @@ func resourceVSphereNasDatastoreCreate(d *schema.ResourceData, meta interface{})
 		}
 	}
 
+	// Apply Storage I/O Control settings and maintenance mode last, as entering
+	// maintenance mode makes the datastore unavailable for any further use.
+	if err := processDatastoreIORMDiff(client, d, ds); err != nil {
+		return err
+	}
+	if err := processDatastoreMaintenanceModeDiff(client, d, ds); err != nil {
+		return err
+	}
+
 	// Done
 	return resourceVSphereNasDatastoreRead(d, meta)
 }
@@ -109,6 +134,12 @@ func resourceVSphereNasDatastoreRead(d *schema.ResourceData, meta interface{}) e
 	if err := flattenDatastoreSummary(d, &props.Summary); err != nil {
 		return err
 	}
+	d.Set("maintenance_mode_enabled", datastoreInMaintenanceMode(&props.Summary))
+	if props.IormConfiguration != nil {
+		if err := flattenStorageIORMInfo(d, props.IormConfiguration); err != nil {
+			return err
+		}
+	}
 
 	// Set the folder
 	folder, err := rootPathParticleDatastore.SplitRelativeFolder(ds.InventoryPath)
@@ -198,6 +229,14 @@ func resourceVSphereNasDatastoreUpdate(d *schema.ResourceData, meta interface{})
 		return fmt.Errorf("error mounting hosts: %s", err)
 	}
 
+	// Process Storage I/O Control and maintenance mode changes.
+	if err := processDatastoreIORMDiff(client, d, ds); err != nil {
+		return err
+	}
+	if err := processDatastoreMaintenanceModeDiff(client, d, ds); err != nil {
+		return err
+	}
+
 	// Should be done with the update here.
 	return resourceVSphereNasDatastoreRead(d, meta)
 }
@@ -227,6 +266,38 @@ func resourceVSphereNasDatastoreDelete(d *schema.ResourceData, meta interface{})
 	return nil
 }
 
+func resourceVSphereNasDatastoreCustomizeDiff(d *schema.ResourceDiff, meta interface{}) error {
+	// Kerberos security types require NFS v4.1, and hosts that have been joined
+	// to an Active Directory domain with NFS Kerberos credentials set. We only
+	// check hosts that are being added, as existing mounts have already been
+	// validated.
+	switch d.Get("security_type").(string) {
+	case hostNasVolumeSecurityTypeSecKrb5, hostNasVolumeSecurityTypeSecKrb5i:
+	default:
+		return nil
+	}
+	if d.Get("type").(string) != string(types.HostFileSystemVolumeFileSystemTypeNFS41) {
+		return fmt.Errorf("security_type %q requires type to be %q", d.Get("security_type").(string), types.HostFileSystemVolumeFileSystemTypeNFS41)
+	}
+
+	client := meta.(*VSphereClient).vimClient
+	o, n := d.GetChange("host_system_ids")
+	p := &nasDatastoreMountProcessor{
+		oldHSIDs: sliceInterfacesToStrings(o.(*schema.Set).List()),
+		newHSIDs: sliceInterfacesToStrings(n.(*schema.Set).List()),
+	}
+	for _, hsID := range p.diffNewOld() {
+		if hsID == config.UnknownVariableValue {
+			// Host IDs that are not known yet can't be checked until apply.
+			continue
+		}
+		if err := validateHostNfsKerberos(client, hsID); err != nil {
+			return fmt.Errorf("host_system_ids: %s", err)
+		}
+	}
+	return nil
+}
+
 func resourceVSphereNasDatastoreImport(d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
 	// We support importing a MoRef - so we need to load the datastore and check
 	// to make sure 1) it exists, and 2) it's a VMFS datastore. If it is, we are
@@ -261,5 +332,6 @@ func resourceVSphereNasDatastoreImport(d *schema.ResourceData, meta interface{})
 	}
 	d.Set("access_mode", accessMode)
 	d.Set("type", t)
+	d.Set("maintenance_mode_timeout", defaultDatastoreMaintenanceModeTimeout)
 	return []*schema.ResourceData{d}, nil
 }
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"path"
+	"regexp"
 	"testing"
 
 	"github.com/hashicorp/terraform/helper/resource"
@@ -79,6 +80,23 @@ func TestAccResourceVSphereNasDatastore(t *testing.T) {
 				},
 			},
 		},
+		{
+			"kerberos without NFS v4.1",
+			resource.TestCase{
+				PreCheck: func() {
+					testAccPreCheck(tp)
+					testAccResourceVSphereNasDatastorePreCheck(tp)
+				},
+				Providers: testAccProviders,
+				Steps: []resource.TestStep{
+					{
+						Config:      testAccResourceVSphereNasDatastoreConfigKerberosNFS3(),
+						ExpectError: regexp.MustCompile("requires type to be \"NFS41\""),
+						PlanOnly:    true,
+					},
+				},
+			},
+		},
 		{
 			"multi-host, then basic",
 			resource.TestCase{
@@ -230,6 +248,60 @@ func TestAccResourceVSphereNasDatastore(t *testing.T) {
 				},
 			},
 		},
+		{
+			"storage I/O control",
+			resource.TestCase{
+				PreCheck: func() {
+					testAccPreCheck(tp)
+					testAccResourceVSphereNasDatastorePreCheck(tp)
+					testAccSkipIfEsxi(tp)
+				},
+				Providers:    testAccProviders,
+				CheckDestroy: testAccResourceVSphereNasDatastoreExists(false),
+				Steps: []resource.TestStep{
+					{
+						Config: testAccResourceVSphereNasDatastoreConfigBasic(),
+						Check: resource.ComposeTestCheckFunc(
+							testAccResourceVSphereNasDatastoreExists(true),
+						),
+					},
+					{
+						Config: testAccResourceVSphereNasDatastoreConfigBasicSIOC(),
+						Check: resource.ComposeTestCheckFunc(
+							testAccResourceVSphereNasDatastoreExists(true),
+							testAccResourceVSphereDatastoreCheckSIOC("vsphere_nas_datastore.datastore", true, 40),
+						),
+					},
+				},
+			},
+		},
+		{
+			"maintenance mode",
+			resource.TestCase{
+				PreCheck: func() {
+					testAccPreCheck(tp)
+					testAccResourceVSphereNasDatastorePreCheck(tp)
+				},
+				Providers:    testAccProviders,
+				CheckDestroy: testAccResourceVSphereNasDatastoreExists(false),
+				Steps: []resource.TestStep{
+					{
+						Config: testAccResourceVSphereNasDatastoreConfigBasicMaintenanceMode(),
+						Check: resource.ComposeTestCheckFunc(
+							testAccResourceVSphereNasDatastoreExists(true),
+							testAccResourceVSphereDatastoreCheckMaintenanceMode("vsphere_nas_datastore.datastore", true),
+						),
+					},
+					{
+						Config: testAccResourceVSphereNasDatastoreConfigBasic(),
+						Check: resource.ComposeTestCheckFunc(
+							testAccResourceVSphereNasDatastoreExists(true),
+							testAccResourceVSphereDatastoreCheckMaintenanceMode("vsphere_nas_datastore.datastore", false),
+						),
+					},
+				},
+			},
+		},
 		{
 			"import",
 			resource.TestCase{
@@ -593,3 +665,106 @@ resource "vsphere_nas_datastore" "datastore" {
 }
 `, os.Getenv("VSPHERE_NAS_HOST"), os.Getenv("VSPHERE_NFS_PATH"), os.Getenv("VSPHERE_DATACENTER"), os.Getenv("VSPHERE_ESXI_HOST"))
 }
+
+func testAccResourceVSphereNasDatastoreConfigKerberosNFS3() string {
+	return fmt.Sprintf(`
+variable "nfs_host" {
+  type    = "string"
+  default = "%s"
+}
+
+variable "nfs_path" {
+  type    = "string"
+  default = "%s"
+}
+
+data "vsphere_datacenter" "datacenter" {
+  name = "%s"
+}
+
+data "vsphere_host" "esxi_host" {
+  name          = "%s"
+  datacenter_id = "${data.vsphere_datacenter.datacenter.id}"
+}
+
+resource "vsphere_nas_datastore" "datastore" {
+  name            = "terraform-test-nas"
+  host_system_ids = ["${data.vsphere_host.esxi_host.id}"]
+
+  type          = "NFS"
+  security_type = "SEC_KRB5"
+  remote_hosts  = ["${var.nfs_host}"]
+  remote_path   = "${var.nfs_path}"
+}
+`, os.Getenv("VSPHERE_NAS_HOST"), os.Getenv("VSPHERE_NFS_PATH"), os.Getenv("VSPHERE_DATACENTER"), os.Getenv("VSPHERE_ESXI_HOST"))
+}
+
+func testAccResourceVSphereNasDatastoreConfigBasicSIOC() string {
+	return fmt.Sprintf(`
+variable "nfs_host" {
+  type    = "string"
+  default = "%s"
+}
+
+variable "nfs_path" {
+  type    = "string"
+  default = "%s"
+}
+
+data "vsphere_datacenter" "datacenter" {
+  name = "%s"
+}
+
+data "vsphere_host" "esxi_host" {
+  name          = "%s"
+  datacenter_id = "${data.vsphere_datacenter.datacenter.id}"
+}
+
+resource "vsphere_nas_datastore" "datastore" {
+  name            = "terraform-test-nas"
+  host_system_ids = ["${data.vsphere_host.esxi_host.id}"]
+
+  type         = "NFS"
+  remote_hosts = ["${var.nfs_host}"]
+  remote_path  = "${var.nfs_path}"
+
+  sioc_enabled                   = true
+  sioc_congestion_threshold_mode = "manual"
+  sioc_congestion_threshold      = 40
+}
+`, os.Getenv("VSPHERE_NAS_HOST"), os.Getenv("VSPHERE_NFS_PATH"), os.Getenv("VSPHERE_DATACENTER"), os.Getenv("VSPHERE_ESXI_HOST"))
+}
+
+func testAccResourceVSphereNasDatastoreConfigBasicMaintenanceMode() string {
+	return fmt.Sprintf(`
+variable "nfs_host" {
+  type    = "string"
+  default = "%s"
+}
+
+variable "nfs_path" {
+  type    = "string"
+  default = "%s"
+}
+
+data "vsphere_datacenter" "datacenter" {
+  name = "%s"
+}
+
+data "vsphere_host" "esxi_host" {
+  name          = "%s"
+  datacenter_id = "${data.vsphere_datacenter.datacenter.id}"
+}
+
+resource "vsphere_nas_datastore" "datastore" {
+  name            = "terraform-test-nas"
+  host_system_ids = ["${data.vsphere_host.esxi_host.id}"]
+
+  type         = "NFS"
+  remote_hosts = ["${var.nfs_host}"]
+  remote_path  = "${var.nfs_path}"
+
+  maintenance_mode_enabled = true
+}
+`, os.Getenv("VSPHERE_NAS_HOST"), os.Getenv("VSPHERE_NFS_PATH"), os.Getenv("VSPHERE_DATACENTER"), os.Getenv("VSPHERE_ESXI_HOST"))
+}
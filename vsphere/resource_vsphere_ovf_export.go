@@ -0,0 +1,200 @@
+package vsphere
+
+import (
+	"bufio"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+
+	"github.com/hashicorp/terraform/helper/schema"
+	"github.com/hashicorp/terraform/helper/validation"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// ovfExportManifestLine matches a line in an OVF manifest, capturing the file
+// name.
+var ovfExportManifestLine = regexp.MustCompile(`^SHA256\((.+)\)= [0-9a-f]+$`)
+
+func resourceVSphereOvfExport() *schema.Resource {
+	return &schema.Resource{
+		Create:        resourceVSphereOvfExportCreate,
+		Read:          resourceVSphereOvfExportRead,
+		Delete:        resourceVSphereOvfExportDelete,
+		CustomizeDiff: resourceVSphereOvfExportCustomizeDiff,
+
+		Schema: map[string]*schema.Schema{
+			"virtual_machine_uuid": &schema.Schema{
+				Type:         schema.TypeString,
+				Description:  "The UUID of the virtual machine or template to export.",
+				Required:     true,
+				ForceNew:     true,
+				ValidateFunc: validation.NoZeroValues,
+			},
+			"directory": &schema.Schema{
+				Type:         schema.TypeString,
+				Description:  "The local directory to export the virtual machine to. The directory is created if it does not exist.",
+				Required:     true,
+				ForceNew:     true,
+				ValidateFunc: validation.NoZeroValues,
+			},
+			"name": &schema.Schema{
+				Type:        schema.TypeString,
+				Description: "The name of the OVF package, used for the names of the descriptor and manifest files. Defaults to the name of the virtual machine.",
+				Optional:    true,
+				Computed:    true,
+				ForceNew:    true,
+			},
+			"change_version": &schema.Schema{
+				Type:        schema.TypeString,
+				Description: "The change version of the virtual machine at the time of the export. The virtual machine is exported again when its change version differs.",
+				Computed:    true,
+			},
+			"ovf_file": &schema.Schema{
+				Type:        schema.TypeString,
+				Description: "The path to the exported OVF descriptor.",
+				Computed:    true,
+			},
+			"manifest_file": &schema.Schema{
+				Type:        schema.TypeString,
+				Description: "The path to the manifest containing the SHA-256 checksums of the exported files.",
+				Computed:    true,
+			},
+		},
+	}
+}
+
+func resourceVSphereOvfExportCreate(d *schema.ResourceData, meta interface{}) error {
+	client := meta.(*VSphereClient).vimClient
+	vm, err := virtualMachineFromUUID(client, d.Get("virtual_machine_uuid").(string))
+	if err != nil {
+		return fmt.Errorf("error fetching virtual machine: %s", err)
+	}
+	props, err := virtualMachineProperties(vm)
+	if err != nil {
+		return fmt.Errorf("error fetching virtual machine properties: %s", err)
+	}
+	// vSphere only exports virtual machines that are powered off.
+	if props.Runtime.PowerState != types.VirtualMachinePowerStatePoweredOff {
+		return fmt.Errorf("virtual machine %q must be powered off to be exported, current power state: %s", props.Name, props.Runtime.PowerState)
+	}
+	name := d.Get("name").(string)
+	if name == "" {
+		name = props.Name
+	}
+	dir := d.Get("directory").(string)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("error creating directory %q: %s", dir, err)
+	}
+
+	log.Printf("[DEBUG] Exporting virtual machine %q to %q", props.Name, dir)
+	if _, err := exportOVF(client, vm, dir, name); err != nil {
+		return fmt.Errorf("error exporting virtual machine %q: %s", props.Name, err)
+	}
+
+	d.SetId(filepath.Join(dir, name+".ovf"))
+	d.Set("name", name)
+	d.Set("change_version", props.Config.ChangeVersion)
+	d.Set("ovf_file", filepath.Join(dir, name+".ovf"))
+	d.Set("manifest_file", filepath.Join(dir, name+".mf"))
+	return resourceVSphereOvfExportRead(d, meta)
+}
+
+func resourceVSphereOvfExportRead(d *schema.ResourceData, meta interface{}) error {
+	// The export only needs to be redone if any of the exported files have gone
+	// missing. Changes to the virtual machine are detected in CustomizeDiff.
+	files, err := resourceVSphereOvfExportFiles(d)
+	if err != nil {
+		if os.IsNotExist(err) {
+			log.Printf("[DEBUG] Manifest %q not found, marking resource as gone", d.Get("manifest_file").(string))
+			d.SetId("")
+			return nil
+		}
+		return err
+	}
+	for _, f := range files {
+		if _, err := os.Stat(f); err != nil {
+			if os.IsNotExist(err) {
+				log.Printf("[DEBUG] Exported file %q not found, marking resource as gone", f)
+				d.SetId("")
+				return nil
+			}
+			return fmt.Errorf("error checking exported file %q: %s", f, err)
+		}
+	}
+	return nil
+}
+
+func resourceVSphereOvfExportDelete(d *schema.ResourceData, meta interface{}) error {
+	files, err := resourceVSphereOvfExportFiles(d)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	files = append(files, d.Get("manifest_file").(string))
+	for _, f := range files {
+		log.Printf("[DEBUG] Removing exported file %q", f)
+		if err := os.Remove(f); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("error removing exported file %q: %s", f, err)
+		}
+	}
+	return nil
+}
+
+// resourceVSphereOvfExportCustomizeDiff forces a new export when the change
+// version of the virtual machine differs from the one that was exported. A
+// virtual machine that can no longer be found does not trigger a new export.
+func resourceVSphereOvfExportCustomizeDiff(d *schema.ResourceDiff, meta interface{}) error {
+	if d.Id() == "" || d.HasChange("virtual_machine_uuid") {
+		return nil
+	}
+	client := meta.(*VSphereClient).vimClient
+	vm, err := virtualMachineFromUUID(client, d.Get("virtual_machine_uuid").(string))
+	if err != nil {
+		if isVirtualMachineNotFoundError(err) {
+			log.Printf("[WARN] Virtual machine %q not found, not checking for changes", d.Get("virtual_machine_uuid").(string))
+			return nil
+		}
+		return fmt.Errorf("error fetching virtual machine: %s", err)
+	}
+	props, err := virtualMachineProperties(vm)
+	if err != nil {
+		return fmt.Errorf("error fetching virtual machine properties: %s", err)
+	}
+	if props.Config.ChangeVersion == d.Get("change_version").(string) {
+		return nil
+	}
+	log.Printf("[DEBUG] Change version of virtual machine %q is now %q, exporting again", props.Name, props.Config.ChangeVersion)
+	if err := d.SetNew("change_version", props.Config.ChangeVersion); err != nil {
+		return err
+	}
+	return d.ForceNew("change_version")
+}
+
+// resourceVSphereOvfExportFiles reads the manifest of the export and returns
+// the paths of the files listed in it.
+func resourceVSphereOvfExportFiles(d *schema.ResourceData) ([]string, error) {
+	mf := d.Get("manifest_file").(string)
+	f, err := os.Open(mf)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var files []string
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		m := ovfExportManifestLine.FindStringSubmatch(s.Text())
+		if m == nil {
+			return nil, fmt.Errorf("invalid line in manifest %q: %q", mf, s.Text())
+		}
+		files = append(files, filepath.Join(filepath.Dir(mf), m[1]))
+	}
+	if err := s.Err(); err != nil {
+		return nil, fmt.Errorf("error reading manifest %q: %s", mf, err)
+	}
+	return files, nil
+}
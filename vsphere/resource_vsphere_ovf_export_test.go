@@ -0,0 +1,125 @@
+package vsphere
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hashicorp/terraform/helper/resource"
+	"github.com/hashicorp/terraform/terraform"
+)
+
+func TestAccResourceVSphereOvfExport(t *testing.T) {
+	var tp *testing.T
+	testAccResourceVSphereOvfExportCases := []struct {
+		name     string
+		testCase resource.TestCase
+	}{
+		{
+			"basic",
+			resource.TestCase{
+				PreCheck: func() {
+					testAccPreCheck(tp)
+					testAccResourceVSphereOvfExportPreCheck(tp)
+				},
+				Providers:    testAccProviders,
+				CheckDestroy: testAccResourceVSphereOvfExportExists(false),
+				Steps: []resource.TestStep{
+					{
+						Config: testAccResourceVSphereOvfExportConfig(),
+						Check: resource.ComposeTestCheckFunc(
+							testAccResourceVSphereOvfExportExists(true),
+							resource.TestCheckResourceAttr("vsphere_ovf_export.export", "name", "terraform-test-export"),
+							resource.TestCheckResourceAttrSet("vsphere_ovf_export.export", "change_version"),
+						),
+					},
+				},
+			},
+		},
+		{
+			"removed files",
+			resource.TestCase{
+				PreCheck: func() {
+					testAccPreCheck(tp)
+					testAccResourceVSphereOvfExportPreCheck(tp)
+				},
+				Providers:    testAccProviders,
+				CheckDestroy: testAccResourceVSphereOvfExportExists(false),
+				Steps: []resource.TestStep{
+					{
+						Config: testAccResourceVSphereOvfExportConfig(),
+						Check: resource.ComposeTestCheckFunc(
+							testAccResourceVSphereOvfExportExists(true),
+						),
+					},
+					{
+						PreConfig: func() {
+							if err := os.Remove(testAccResourceVSphereOvfExportDir() + "/terraform-test-export.mf"); err != nil {
+								panic(err)
+							}
+						},
+						Config: testAccResourceVSphereOvfExportConfig(),
+						Check: resource.ComposeTestCheckFunc(
+							testAccResourceVSphereOvfExportExists(true),
+						),
+					},
+				},
+			},
+		},
+	}
+
+	for _, tc := range testAccResourceVSphereOvfExportCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tp = t
+			resource.Test(t, tc.testCase)
+		})
+	}
+}
+
+func testAccResourceVSphereOvfExportPreCheck(t *testing.T) {
+	if os.Getenv("VSPHERE_TEMPLATE_UUID") == "" {
+		t.Skip("set VSPHERE_TEMPLATE_UUID to run vsphere_ovf_export acceptance tests")
+	}
+}
+
+func testAccResourceVSphereOvfExportDir() string {
+	return filepath.Join(os.TempDir(), "terraform-test-ovf-export")
+}
+
+func testAccResourceVSphereOvfExportExists(expected bool) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		mf := filepath.Join(testAccResourceVSphereOvfExportDir(), "terraform-test-export.mf")
+		rs, ok := s.RootModule().Resources["vsphere_ovf_export.export"]
+		if !ok && expected {
+			return errors.New("vsphere_ovf_export.export not found in state")
+		}
+		if ok {
+			mf = rs.Primary.Attributes["manifest_file"]
+		}
+		_, err := os.Stat(mf)
+		switch {
+		case err != nil && os.IsNotExist(err) && !expected:
+			return nil
+		case err != nil:
+			return err
+		case !expected:
+			return fmt.Errorf("expected manifest %q to be missing", mf)
+		}
+		return nil
+	}
+}
+
+func testAccResourceVSphereOvfExportConfig() string {
+	return fmt.Sprintf(`
+resource "vsphere_ovf_export" "export" {
+  virtual_machine_uuid = "%s"
+  directory            = "%s"
+  name                 = "terraform-test-export"
+}
+`,
+		os.Getenv("VSPHERE_TEMPLATE_UUID"),
+		testAccResourceVSphereOvfExportDir(),
+	)
+}
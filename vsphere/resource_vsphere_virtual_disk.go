@@ -16,12 +16,14 @@ import (
 )
 
 type virtualDisk struct {
-	size        int
-	vmdkPath    string
-	initType    string
-	adapterType string
-	datacenter  string
-	datastore   string
+	size            int
+	vmdkPath        string
+	initType        string
+	adapterType     string
+	datacenter      string
+	datastore       string
+	sourceVmdkPath  string
+	sourceDatastore string
 }
 
 // Define VirtualDisk args
@@ -29,20 +31,24 @@ func resourceVSphereVirtualDisk() *schema.Resource {
 	return &schema.Resource{
 		Create: resourceVSphereVirtualDiskCreate,
 		Read:   resourceVSphereVirtualDiskRead,
+		Update: resourceVSphereVirtualDiskUpdate,
 		Delete: resourceVSphereVirtualDiskDelete,
+		Importer: &schema.ResourceImporter{
+			State: resourceVSphereVirtualDiskImport,
+		},
+
+		CustomizeDiff: resourceVSphereVirtualDiskCustomizeDiff,
 
 		Schema: map[string]*schema.Schema{
 			// Size in GB
 			"size": &schema.Schema{
 				Type:     schema.TypeInt,
 				Required: true,
-				ForceNew: true, //TODO Can this be optional (resize)?
 			},
 
 			"vmdk_path": &schema.Schema{
 				Type:     schema.TypeString,
 				Required: true,
-				ForceNew: true, //TODO Can this be optional (move)?
 			},
 
 			"type": &schema.Schema{
@@ -50,6 +56,11 @@ func resourceVSphereVirtualDisk() *schema.Resource {
 				Optional: true,
 				ForceNew: true,
 				Default:  "eagerZeroedThick",
+				// Imported disks of a type that the resource does not support have
+				// no type in state.
+				DiffSuppressFunc: func(k, old, new string, d *schema.ResourceData) bool {
+					return d.Id() != "" && old == ""
+				},
 				ValidateFunc: func(v interface{}, k string) (ws []string, errors []error) {
 					value := v.(string)
 					if value != "thin" && value != "eagerZeroedThick" && value != "lazy" {
@@ -84,7 +95,20 @@ func resourceVSphereVirtualDisk() *schema.Resource {
 			"datastore": &schema.Schema{
 				Type:     schema.TypeString,
 				Optional: true,
-				ForceNew: true,
+			},
+
+			"source_vmdk_path": &schema.Schema{
+				Type:        schema.TypeString,
+				Description: "The path of an existing virtual disk to copy when creating this disk, instead of creating an empty disk.",
+				Optional:    true,
+				ForceNew:    true,
+			},
+
+			"source_datastore": &schema.Schema{
+				Type:        schema.TypeString,
+				Description: "The name of the datastore that source_vmdk_path is on. Defaults to datastore.",
+				Optional:    true,
+				ForceNew:    true,
 			},
 		},
 	}
@@ -118,6 +142,16 @@ func resourceVSphereVirtualDiskCreate(d *schema.ResourceData, meta interface{})
 		vDisk.datastore = v.(string)
 	}
 
+	if v, ok := d.GetOk("source_vmdk_path"); ok {
+		vDisk.sourceVmdkPath = v.(string)
+	}
+
+	if v, ok := d.GetOk("source_datastore"); ok {
+		vDisk.sourceDatastore = v.(string)
+	} else {
+		vDisk.sourceDatastore = vDisk.datastore
+	}
+
 	finder := find.NewFinder(client.Client, true)
 
 	dc, err := getDatacenter(client, d.Get("datacenter").(string))
@@ -131,7 +165,15 @@ func resourceVSphereVirtualDiskCreate(d *schema.ResourceData, meta interface{})
 		return fmt.Errorf("Error finding Datastore: %s: %s", vDisk.datastore, err)
 	}
 
-	err = createHardDisk(client, vDisk.size, ds.Path(vDisk.vmdkPath), vDisk.initType, vDisk.adapterType, vDisk.datacenter)
+	if vDisk.sourceVmdkPath != "" {
+		sourceDs, err := getDatastore(finder, vDisk.sourceDatastore)
+		if err != nil {
+			return fmt.Errorf("Error finding Datastore: %s: %s", vDisk.sourceDatastore, err)
+		}
+		err = copyHardDisk(client, dc, sourceDs, ds, &vDisk)
+	} else {
+		err = createHardDisk(client, vDisk.size, ds.Path(vDisk.vmdkPath), vDisk.initType, vDisk.adapterType, vDisk.datacenter)
+	}
 	if err != nil {
 		return err
 	}
@@ -256,6 +298,50 @@ func resourceVSphereVirtualDiskRead(d *schema.ResourceData, meta interface{}) er
 
 }
 
+func resourceVSphereVirtualDiskUpdate(d *schema.ResourceData, meta interface{}) error {
+	client := meta.(*VSphereClient).vimClient
+
+	dc, err := getDatacenter(client, d.Get("datacenter").(string))
+	if err != nil {
+		return fmt.Errorf("Error finding Datacenter: %s: %s", d.Get("datacenter").(string), err)
+	}
+	finder := find.NewFinder(client.Client, true)
+	finder = finder.SetDatacenter(dc)
+
+	ods, nds := d.GetChange("datastore")
+	dsNew, err := getDatastore(finder, nds.(string))
+	if err != nil {
+		return fmt.Errorf("Error finding Datastore: %s: %s", nds.(string), err)
+	}
+
+	if d.HasChange("vmdk_path") || d.HasChange("datastore") {
+		dsOld, err := getDatastore(finder, ods.(string))
+		if err != nil {
+			return fmt.Errorf("Error finding Datastore: %s: %s", ods.(string), err)
+		}
+		op, np := d.GetChange("vmdk_path")
+		src := dsOld.Path(op.(string))
+		dst := dsNew.Path(np.(string))
+		log.Printf("[DEBUG] Moving virtual disk %s to %s", src, dst)
+		if err := moveVirtualDisk(client, src, dst, dc); err != nil {
+			return fmt.Errorf("error moving virtual disk %s to %s: %s", src, dst, err)
+		}
+		d.SetId(np.(string))
+	}
+
+	if d.HasChange("size") {
+		diskPath := dsNew.Path(d.Get("vmdk_path").(string))
+		size := d.Get("size").(int)
+		log.Printf("[DEBUG] Extending virtual disk %s to %d GB", diskPath, size)
+		eagerZero := d.Get("type").(string) == "eagerZeroedThick"
+		if err := extendVirtualDisk(client, diskPath, dc, int64(1024*1024*size), eagerZero); err != nil {
+			return fmt.Errorf("error extending virtual disk %s: %s", diskPath, err)
+		}
+	}
+
+	return resourceVSphereVirtualDiskRead(d, meta)
+}
+
 func resourceVSphereVirtualDiskDelete(d *schema.ResourceData, meta interface{}) error {
 	client := meta.(*VSphereClient).vimClient
 
@@ -303,21 +389,11 @@ func resourceVSphereVirtualDiskDelete(d *schema.ResourceData, meta interface{})
 
 // createHardDisk creates a new Hard Disk.
 func createHardDisk(client *govmomi.Client, size int, diskPath string, diskType string, adapterType string, dc string) error {
-	var vDiskType string
-	switch diskType {
-	case "thin":
-		vDiskType = "thin"
-	case "eagerZeroedThick":
-		vDiskType = "eagerZeroedThick"
-	case "lazy":
-		vDiskType = "preallocated"
-	}
-
 	virtualDiskManager := object.NewVirtualDiskManager(client.Client)
 	spec := &types.FileBackedVirtualDiskSpec{
 		VirtualDiskSpec: types.VirtualDiskSpec{
 			AdapterType: adapterType,
-			DiskType:    vDiskType,
+			DiskType:    virtualDiskType(diskType),
 		},
 		CapacityKb: int64(1024 * 1024 * size),
 	}
@@ -341,3 +417,92 @@ func createHardDisk(client *govmomi.Client, size int, diskPath string, diskType
 
 	return nil
 }
+
+// copyHardDisk creates a new Hard Disk by copying an existing one, converting
+// it to the disk and adapter types defined in vDisk. The copy is then extended
+// if a larger size than the source disk was requested.
+func copyHardDisk(client *govmomi.Client, dc *object.Datacenter, sourceDs, ds *object.Datastore, vDisk *virtualDisk) error {
+	src := sourceDs.Path(vDisk.sourceVmdkPath)
+	dst := ds.Path(vDisk.vmdkPath)
+	fi, err := virtualDiskFileInfo(sourceDs, vDisk.sourceVmdkPath)
+	if err != nil {
+		return fmt.Errorf("error reading source disk %s: %s", src, err)
+	}
+	capacityKb := int64(1024 * 1024 * vDisk.size)
+	if capacityKb < fi.CapacityKb {
+		return fmt.Errorf("size (%d GB) cannot be smaller than the size of source disk %s (%d KB)", vDisk.size, src, fi.CapacityKb)
+	}
+
+	spec := &types.VirtualDiskSpec{
+		AdapterType: vDisk.adapterType,
+		DiskType:    virtualDiskType(vDisk.initType),
+	}
+	log.Printf("[DEBUG] Copying virtual disk %s to %s with spec: %v", src, dst, spec)
+	if err := copyVirtualDisk(client, src, dst, dc, spec); err != nil {
+		return fmt.Errorf("error copying virtual disk %s to %s: %s", src, dst, err)
+	}
+
+	if capacityKb > fi.CapacityKb {
+		log.Printf("[DEBUG] Extending virtual disk %s to %d GB", dst, vDisk.size)
+		if err := extendVirtualDisk(client, dst, dc, capacityKb, vDisk.initType == "eagerZeroedThick"); err != nil {
+			return fmt.Errorf("error extending virtual disk %s: %s", dst, err)
+		}
+	}
+	log.Printf("[INFO] Copied disk.")
+	return nil
+}
+
+func resourceVSphereVirtualDiskImport(d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
+	// The ID is a datastore path in the "[datastore] path" format. The datastore
+	// is looked up by name across all datacenters, so that datacenter can be
+	// populated.
+	client := meta.(*VSphereClient).vimClient
+	var p object.DatastorePath
+	if !p.FromString(d.Id()) || p.Datastore == "" || p.Path == "" {
+		return nil, fmt.Errorf("invalid import ID %q, must be in the format \"[datastore] path/to/disk.vmdk\"", d.Id())
+	}
+	ds, err := datastoreFromName(client, p.Datastore)
+	if err != nil {
+		return nil, err
+	}
+	dc, err := datastoreDatacenter(client, ds)
+	if err != nil {
+		return nil, fmt.Errorf("error locating datacenter for datastore %q: %s", p.Datastore, err)
+	}
+	fi, err := virtualDiskFileInfo(ds, p.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	d.SetId(p.Path)
+	d.Set("vmdk_path", p.Path)
+	d.Set("datastore", ds.Name())
+	d.Set("datacenter", dc.Name())
+	// The type is left unset when the virtual disk manager reports a type that
+	// the resource does not support, so that any type in configuration is
+	// accepted.
+	diskType, err := queryVirtualDiskType(client, ds.Path(p.Path), dc)
+	if err != nil {
+		return nil, fmt.Errorf("error querying type of virtual disk %q: %s", d.Id(), err)
+	}
+	d.Set("type", diskType)
+	if t, ok := virtualDiskAdapterTypes[fi.ControllerType]; ok {
+		d.Set("adapter_type", t)
+	} else {
+		d.Set("adapter_type", "lsiLogic")
+	}
+	return []*schema.ResourceData{d}, nil
+}
+
+// resourceVSphereVirtualDiskCustomizeDiff checks to make sure that the disk is
+// not being shrunk, as virtual disks can only be grown in place.
+func resourceVSphereVirtualDiskCustomizeDiff(d *schema.ResourceDiff, meta interface{}) error {
+	if d.Id() == "" || !d.HasChange("size") {
+		return nil
+	}
+	o, n := d.GetChange("size")
+	if n.(int) < o.(int) {
+		return fmt.Errorf("virtual disks cannot be shrunk (current size: %d GB, new size: %d GB)", o.(int), n.(int))
+	}
+	return nil
+}
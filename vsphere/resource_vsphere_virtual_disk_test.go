@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"regexp"
 	"testing"
 
 	"github.com/hashicorp/terraform/helper/acctest"
@@ -51,6 +52,132 @@ func TestAccVSphereVirtualDisk_basic(t *testing.T) {
 	})
 }
 
+func TestAccVSphereVirtualDisk_grow(t *testing.T) {
+	rString := acctest.RandString(5)
+	vmdkPath := fmt.Sprintf("tfTestDisk-%s.vmdk", rString)
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckVSphereVirtualDiskDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccCheckVSphereVirtualDiskConfigSizePath(1, vmdkPath),
+				Check: resource.ComposeTestCheckFunc(
+					testAccVSphereVirtualDiskExists("vsphere_virtual_disk.foo"),
+					resource.TestCheckResourceAttr("vsphere_virtual_disk.foo", "size", "1"),
+				),
+			},
+			{
+				Config: testAccCheckVSphereVirtualDiskConfigSizePath(2, vmdkPath),
+				Check: resource.ComposeTestCheckFunc(
+					testAccVSphereVirtualDiskExists("vsphere_virtual_disk.foo"),
+					resource.TestCheckResourceAttr("vsphere_virtual_disk.foo", "size", "2"),
+				),
+			},
+		},
+	})
+}
+
+func TestAccVSphereVirtualDisk_shrinkError(t *testing.T) {
+	rString := acctest.RandString(5)
+	vmdkPath := fmt.Sprintf("tfTestDisk-%s.vmdk", rString)
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckVSphereVirtualDiskDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccCheckVSphereVirtualDiskConfigSizePath(2, vmdkPath),
+				Check: resource.ComposeTestCheckFunc(
+					testAccVSphereVirtualDiskExists("vsphere_virtual_disk.foo"),
+				),
+			},
+			{
+				Config:      testAccCheckVSphereVirtualDiskConfigSizePath(1, vmdkPath),
+				ExpectError: regexp.MustCompile("virtual disks cannot be shrunk"),
+				PlanOnly:    true,
+			},
+		},
+	})
+}
+
+func TestAccVSphereVirtualDisk_move(t *testing.T) {
+	rString := acctest.RandString(5)
+	vmdkPath := fmt.Sprintf("tfTestDisk-%s.vmdk", rString)
+	vmdkPathMoved := fmt.Sprintf("tfTestDisk-%s-moved.vmdk", rString)
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckVSphereVirtualDiskDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccCheckVSphereVirtualDiskConfigSizePath(1, vmdkPath),
+				Check: resource.ComposeTestCheckFunc(
+					testAccVSphereVirtualDiskExists("vsphere_virtual_disk.foo"),
+				),
+			},
+			{
+				Config: testAccCheckVSphereVirtualDiskConfigSizePath(1, vmdkPathMoved),
+				Check: resource.ComposeTestCheckFunc(
+					testAccVSphereVirtualDiskExists("vsphere_virtual_disk.foo"),
+					resource.TestCheckResourceAttr("vsphere_virtual_disk.foo", "vmdk_path", vmdkPathMoved),
+				),
+			},
+		},
+	})
+}
+
+func TestAccVSphereVirtualDisk_import(t *testing.T) {
+	rString := acctest.RandString(5)
+	vmdkPath := fmt.Sprintf("tfTestDisk-%s.vmdk", rString)
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckVSphereVirtualDiskDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccCheckVSphereVirtualDiskConfigSizePath(1, vmdkPath),
+				Check: resource.ComposeTestCheckFunc(
+					testAccVSphereVirtualDiskExists("vsphere_virtual_disk.foo"),
+				),
+			},
+			{
+				ResourceName:      "vsphere_virtual_disk.foo",
+				ImportState:       true,
+				ImportStateVerify: true,
+				ImportStateIdFunc: func(s *terraform.State) (string, error) {
+					return fmt.Sprintf("[%s] %s", os.Getenv("VSPHERE_DATASTORE"), vmdkPath), nil
+				},
+				Config: testAccCheckVSphereVirtualDiskConfigSizePath(1, vmdkPath),
+			},
+		},
+	})
+}
+
+func TestAccVSphereVirtualDisk_copy(t *testing.T) {
+	rString := acctest.RandString(5)
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckVSphereVirtualDiskDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccCheckVSphereVirtualDiskConfigCopy(rString),
+				Check: resource.ComposeTestCheckFunc(
+					testAccVSphereVirtualDiskExists("vsphere_virtual_disk.foo"),
+					testAccVSphereVirtualDiskExists("vsphere_virtual_disk.copy"),
+					resource.TestCheckResourceAttr("vsphere_virtual_disk.copy", "size", "2"),
+				),
+			},
+		},
+	})
+}
+
 func testAccVSphereVirtualDiskExists(name string) resource.TestCheckFunc {
 	return func(s *terraform.State) error {
 		rs, ok := s.RootModule().Resources[name]
@@ -127,3 +254,39 @@ resource "vsphere_virtual_disk" "foo" {
 }
 `, rName, initTypeOpt, adapterTypeOpt, datacenterOpt, datastoreOpt)
 }
+
+func testAccCheckVSphereVirtualDiskConfigSizePath(size int, vmdkPath string) string {
+	return fmt.Sprintf(`
+resource "vsphere_virtual_disk" "foo" {
+    size = %d
+    vmdk_path = "%s"
+    type = "thin"
+    adapter_type = "lsiLogic"
+    datacenter = "%s"
+    datastore = "%s"
+}
+`, size, vmdkPath, os.Getenv("VSPHERE_DATACENTER"), os.Getenv("VSPHERE_DATASTORE"))
+}
+
+func testAccCheckVSphereVirtualDiskConfigCopy(rName string) string {
+	return fmt.Sprintf(`
+resource "vsphere_virtual_disk" "foo" {
+    size = 1
+    vmdk_path = "tfTestDisk-%s.vmdk"
+    type = "thin"
+    adapter_type = "lsiLogic"
+    datacenter = "%s"
+    datastore = "%s"
+}
+
+resource "vsphere_virtual_disk" "copy" {
+    size = 2
+    vmdk_path = "tfTestDisk-%s-copy.vmdk"
+    type = "eagerZeroedThick"
+    adapter_type = "lsiLogic"
+    datacenter = "${vsphere_virtual_disk.foo.datacenter}"
+    datastore = "${vsphere_virtual_disk.foo.datastore}"
+    source_vmdk_path = "${vsphere_virtual_disk.foo.vmdk_path}"
+}
+`, rName, os.Getenv("VSPHERE_DATACENTER"), os.Getenv("VSPHERE_DATASTORE"), rName)
+}
@@ -1,18 +1,23 @@
 package vsphere
 
 import (
+	"bytes"
 	"fmt"
 	"log"
 	"net"
+	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 
+	"github.com/hashicorp/terraform/helper/hashcode"
 	"github.com/hashicorp/terraform/helper/schema"
 	"github.com/hashicorp/terraform/helper/validation"
 	"github.com/vmware/govmomi"
 	"github.com/vmware/govmomi/find"
 	"github.com/vmware/govmomi/object"
 	"github.com/vmware/govmomi/property"
+	"github.com/vmware/govmomi/vim25/methods"
 	"github.com/vmware/govmomi/vim25/mo"
 	"github.com/vmware/govmomi/vim25/types"
 	"golang.org/x/net/context"
@@ -27,6 +32,34 @@ var DefaultDNSServers = []string{
 	"8.8.4.4",
 }
 
+// virtualMachinePowerStateAllowedValues are the power states that can be set
+// in the power_state attribute.
+var virtualMachinePowerStateAllowedValues = []string{
+	string(types.VirtualMachinePowerStatePoweredOn),
+	string(types.VirtualMachinePowerStatePoweredOff),
+	string(types.VirtualMachinePowerStateSuspended),
+}
+
+// virtualMachineFirmwareAllowedValues are the allowed values for the firmware
+// attribute.
+var virtualMachineFirmwareAllowedValues = []string{
+	string(types.GuestOsDescriptorFirmwareTypeBios),
+	string(types.GuestOsDescriptorFirmwareTypeEfi),
+}
+
+// virtualMachineBootDeviceAllowedValues are the allowed values for entries in
+// the boot_order attribute.
+var virtualMachineBootDeviceAllowedValues = []string{
+	object.DeviceTypeDisk,
+	object.DeviceTypeCdrom,
+	object.DeviceTypeEthernet,
+	object.DeviceTypeFloppy,
+}
+
+// virtualMachineUUIDRegexp matches the UUID of a virtual machine, as used in
+// the import ID of the vsphere_virtual_machine resource.
+var virtualMachineUUIDRegexp = regexp.MustCompile("^[0-9a-fA-F]{8}-([0-9a-fA-F]{4}-){3}[0-9a-fA-F]{12}$")
+
 var DiskControllerTypes = []string{
 	"scsi",
 	"scsi-lsi-parallel",
@@ -34,11 +67,62 @@ var DiskControllerTypes = []string{
 	"scsi-paravirtual",
 	"scsi-lsi-sas",
 	"ide",
+	"sata",
+	"nvme",
+}
+
+// virtualMachineSCSIControllerTypeAllowedValues are the types of SCSI
+// controllers that can be defined in the scsi_controller block.
+var virtualMachineSCSIControllerTypeAllowedValues = []string{
+	"lsilogic",
+	"lsilogic-sas",
+	"pvscsi",
+	"buslogic",
+}
+
+var virtualMachineDiskModeAllowedValues = []string{
+	string(types.VirtualDiskModePersistent),
+	string(types.VirtualDiskModeIndependent_persistent),
+	string(types.VirtualDiskModeIndependent_nonpersistent),
+}
+
+var virtualMachineDiskSharingAllowedValues = []string{
+	string(types.VirtualDiskSharingSharingNone),
+	string(types.VirtualDiskSharingSharingMultiWriter),
+}
+
+var virtualMachineRDMCompatibilityModeAllowedValues = []string{
+	string(types.VirtualDiskCompatibilityModePhysicalMode),
+	string(types.VirtualDiskCompatibilityModeVirtualMode),
+}
+
+var virtualMachineSCSIBusSharingAllowedValues = []string{
+	string(types.VirtualSCSISharingNoSharing),
+	string(types.VirtualSCSISharingPhysicalSharing),
+	string(types.VirtualSCSISharingVirtualSharing),
 }
 
 var virtualMachineNetworkAdapterTypeAllowedValues = []string{
 	"vmxnet3",
 	"e1000",
+	"e1000e",
+	"vmxnet2",
+	"pcnet32",
+	"sriov",
+}
+
+// virtualMachineNetworkInterfaceForceNewKeys are the keys in a
+// network_interface block that can only be applied during guest
+// customization, and as such force a new virtual machine when changed.
+var virtualMachineNetworkInterfaceForceNewKeys = []string{
+	"ip_address",
+	"subnet_mask",
+	"ipv4_address",
+	"ipv4_prefix_length",
+	"ipv4_gateway",
+	"ipv6_address",
+	"ipv6_prefix_length",
+	"ipv6_gateway",
 }
 
 type networkInterface struct {
@@ -51,6 +135,7 @@ type networkInterface struct {
 	ipv6PrefixLength int
 	ipv6Gateway      string
 	adapterType      string
+	physicalFunction string
 	macAddress       string
 }
 
@@ -60,10 +145,32 @@ type hardDisk struct {
 	iops       int64
 	initType   string
 	vmdkPath   string
+	datastore  string
 	controller string
+	busNumber  int32
+	unitNumber int32
+	backing    diskBackingOptions
+	fcdID      string
+	rdmLUN     string
+	rdmMode    string
 	bootable   bool
 }
 
+// diskBackingOptions are the mode and sharing settings of the backing of a
+// disk.
+type diskBackingOptions struct {
+	diskMode     string
+	writeThrough bool
+	sharing      string
+}
+
+// scsiController describes a SCSI controller from the scsi_controller block.
+// The bus number of the controller is its index in the block.
+type scsiController struct {
+	controllerType string
+	busSharing     string
+}
+
 //Additional options Vsphere can use clones of windows machines
 type windowsOptConfig struct {
 	productKey         string
@@ -78,10 +185,6 @@ type cdrom struct {
 	path      string
 }
 
-type memoryAllocation struct {
-	reservation int64
-}
-
 type virtualMachine struct {
 	name                     string
 	hostname                 string
@@ -89,14 +192,29 @@ type virtualMachine struct {
 	datacenter               string
 	cluster                  string
 	resourcePool             string
+	host                     string
 	datastore                string
 	vcpu                     int32
 	memoryMb                 int64
-	memoryAllocation         memoryAllocation
+	cpuAllocation            *types.ResourceAllocationInfo
+	memoryAllocation         *types.ResourceAllocationInfo
+	memoryReservationLocked  bool
+	firmware                 string
+	nestedHVEnabled          *bool
+	bootOptions              *types.VirtualMachineBootOptions
+	bootOrder                []string
 	annotation               string
 	template                 string
+	contentLibraryItemID     string
+	ovfFile                  string
+	ovfDatastore             string
+	ovfProperties            map[string]string
+	ovfDeploymentOption      string
 	networkInterfaces        []networkInterface
 	hardDisks                []hardDisk
+	scsiControllers          []scsiController
+	sataControllerCount      int
+	nvmeControllerCount      int
 	cdroms                   []cdrom
 	domain                   string
 	timeZone                 string
@@ -105,7 +223,11 @@ type virtualMachine struct {
 	hasBootableVmdk          bool
 	linkedClone              bool
 	skipCustomization        bool
+	powerState               types.VirtualMachinePowerState
 	enableDiskUUID           bool
+	cpuHotAddEnabled         bool
+	cpuHotRemoveEnabled      bool
+	memoryHotAddEnabled      bool
 	moid                     string
 	windowsOptionalConfig    windowsOptConfig
 	customConfigurations     map[string](types.AnyType)
@@ -116,6 +238,13 @@ func (v virtualMachine) Path() string {
 	return vmPath(v.folder, v.name)
 }
 
+// hasTemplateDisk returns true if the first disk of the virtual machine comes
+// from a template, a content library item, or an OVF package, rather than
+// being created or attached.
+func (v virtualMachine) hasTemplateDisk() bool {
+	return v.template != "" || v.contentLibraryItemID != "" || v.ovfFile != ""
+}
+
 func vmPath(folder string, name string) string {
 	var path string
 	if len(folder) > 0 {
@@ -126,12 +255,16 @@ func vmPath(folder string, name string) string {
 
 func resourceVSphereVirtualMachine() *schema.Resource {
 	return &schema.Resource{
-		Create: resourceVSphereVirtualMachineCreate,
-		Read:   resourceVSphereVirtualMachineRead,
-		Update: resourceVSphereVirtualMachineUpdate,
-		Delete: resourceVSphereVirtualMachineDelete,
+		Create:        resourceVSphereVirtualMachineCreate,
+		Read:          resourceVSphereVirtualMachineRead,
+		Update:        resourceVSphereVirtualMachineUpdate,
+		Delete:        resourceVSphereVirtualMachineDelete,
+		CustomizeDiff: resourceVSphereVirtualMachineCustomizeDiff,
+		Importer: &schema.ResourceImporter{
+			State: resourceVSphereVirtualMachineImport,
+		},
 
-		SchemaVersion: 1,
+		SchemaVersion: 4,
 		MigrateState:  resourceVSphereVirtualMachineMigrateState,
 
 		Schema: map[string]*schema.Schema{
@@ -163,11 +296,149 @@ func resourceVSphereVirtualMachine() *schema.Resource {
 				Required: true,
 			},
 
+			"cpu_hot_add_enabled": &schema.Schema{
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  false,
+			},
+
+			"cpu_hot_remove_enabled": &schema.Schema{
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  false,
+			},
+
+			"memory_hot_add_enabled": &schema.Schema{
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  false,
+			},
+
+			"reboot_required": &schema.Schema{
+				Type:     schema.TypeBool,
+				Computed: true,
+			},
+
+			"firmware": &schema.Schema{
+				Type:         schema.TypeString,
+				Optional:     true,
+				Computed:     true,
+				ValidateFunc: validation.StringInSlice(virtualMachineFirmwareAllowedValues, false),
+			},
+
+			"efi_secure_boot_enabled": &schema.Schema{
+				Type:     schema.TypeBool,
+				Optional: true,
+				Computed: true,
+			},
+
+			"nested_hv_enabled": &schema.Schema{
+				Type:     schema.TypeBool,
+				Optional: true,
+				Computed: true,
+			},
+
+			"boot_delay": &schema.Schema{
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Computed:     true,
+				ValidateFunc: validation.IntAtLeast(0),
+			},
+
+			"boot_retry_enabled": &schema.Schema{
+				Type:     schema.TypeBool,
+				Optional: true,
+				Computed: true,
+			},
+
+			"boot_retry_delay": &schema.Schema{
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Computed:     true,
+				ValidateFunc: validation.IntAtLeast(0),
+			},
+
+			"enter_bios_setup": &schema.Schema{
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  false,
+			},
+
+			"boot_order": &schema.Schema{
+				Type:     schema.TypeList,
+				Optional: true,
+				Computed: true,
+				Elem: &schema.Schema{
+					Type:         schema.TypeString,
+					ValidateFunc: validation.StringInSlice(virtualMachineBootDeviceAllowedValues, false),
+				},
+			},
+
+			"cpu_reservation": &schema.Schema{
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Default:      0,
+				ValidateFunc: validation.IntAtLeast(0),
+			},
+
+			"cpu_limit": &schema.Schema{
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Default:      -1,
+				ValidateFunc: validation.IntAtLeast(-1),
+			},
+
+			"cpu_share_level": &schema.Schema{
+				Type:         schema.TypeString,
+				Optional:     true,
+				Default:      string(types.SharesLevelNormal),
+				ValidateFunc: validation.StringInSlice(sharesLevelAllowedValues, false),
+			},
+
+			"cpu_share_count": &schema.Schema{
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Computed:     true,
+				ValidateFunc: validation.IntAtLeast(0),
+			},
+
 			"memory_reservation": &schema.Schema{
-				Type:     schema.TypeInt,
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Default:      0,
+				ValidateFunc: validation.IntAtLeast(0),
+				DiffSuppressFunc: func(k, old, new string, d *schema.ResourceData) bool {
+					// The reservation always matches the memory size of the virtual
+					// machine when all guest memory is reserved.
+					return d.Get("memory_reservation_locked_to_max").(bool)
+				},
+			},
+
+			"memory_reservation_locked_to_max": &schema.Schema{
+				Type:     schema.TypeBool,
 				Optional: true,
-				Default:  0,
-				ForceNew: true,
+				Default:  false,
+			},
+
+			"memory_limit": &schema.Schema{
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Default:      -1,
+				ValidateFunc: validation.IntAtLeast(-1),
+			},
+
+			"memory_share_level": &schema.Schema{
+				Type:         schema.TypeString,
+				Optional:     true,
+				Default:      string(types.SharesLevelNormal),
+				ValidateFunc: validation.StringInSlice(sharesLevelAllowedValues, false),
+			},
+
+			"memory_share_count": &schema.Schema{
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Computed:     true,
+				ValidateFunc: validation.IntAtLeast(0),
 			},
 
 			"annotation": &schema.Schema{
@@ -184,13 +455,16 @@ func resourceVSphereVirtualMachine() *schema.Resource {
 			"cluster": &schema.Schema{
 				Type:     schema.TypeString,
 				Optional: true,
-				ForceNew: true,
 			},
 
 			"resource_pool": &schema.Schema{
 				Type:     schema.TypeString,
 				Optional: true,
-				ForceNew: true,
+			},
+
+			"host": &schema.Schema{
+				Type:     schema.TypeString,
+				Optional: true,
 			},
 
 			"linked_clone": &schema.Schema{
@@ -241,6 +515,17 @@ func resourceVSphereVirtualMachine() *schema.Resource {
 				Default:  false,
 			},
 
+			"ovf_properties": &schema.Schema{
+				Type:     schema.TypeMap,
+				Optional: true,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+			},
+
+			"ovf_deployment_option": &schema.Schema{
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+
 			"wait_for_customization_timeout": &schema.Schema{
 				Type:     schema.TypeInt,
 				Optional: true,
@@ -260,6 +545,19 @@ func resourceVSphereVirtualMachine() *schema.Resource {
 				Default:  false,
 			},
 
+			"shutdown_wait_timeout": &schema.Schema{
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Default:      3,
+				ValidateFunc: validation.IntAtLeast(1),
+			},
+
+			"force_power_off": &schema.Schema{
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  true,
+			},
+
 			"uuid": &schema.Schema{
 				Type:     schema.TypeString,
 				Computed: true,
@@ -274,7 +572,7 @@ func resourceVSphereVirtualMachine() *schema.Resource {
 				Type:         schema.TypeString,
 				Optional:     true,
 				Default:      string(types.VirtualMachinePowerStatePoweredOn),
-				ValidateFunc: validation.StringInSlice([]string{string(types.VirtualMachinePowerStatePoweredOn)}, false),
+				ValidateFunc: validation.StringInSlice(virtualMachinePowerStateAllowedValues, false),
 			},
 
 			"custom_configuration_parameters": &schema.Schema{
@@ -325,7 +623,6 @@ func resourceVSphereVirtualMachine() *schema.Resource {
 			"network_interface": &schema.Schema{
 				Type:     schema.TypeList,
 				Required: true,
-				ForceNew: true,
 				Elem: &schema.Resource{
 					Schema: map[string]*schema.Schema{
 						"key": &schema.Schema{
@@ -336,7 +633,6 @@ func resourceVSphereVirtualMachine() *schema.Resource {
 						"label": &schema.Schema{
 							Type:     schema.TypeString,
 							Required: true,
-							ForceNew: true,
 						},
 
 						"ip_address": &schema.Schema{
@@ -393,16 +689,26 @@ func resourceVSphereVirtualMachine() *schema.Resource {
 						"adapter_type": &schema.Schema{
 							Type:         schema.TypeString,
 							Optional:     true,
-							ForceNew:     true,
 							Default:      "vmxnet3",
 							ValidateFunc: validation.StringInSlice(virtualMachineNetworkAdapterTypeAllowedValues, false),
 						},
 
+						"physical_function": &schema.Schema{
+							Type:     schema.TypeString,
+							Optional: true,
+						},
+
 						"mac_address": &schema.Schema{
 							Type:     schema.TypeString,
 							Optional: true,
 							Computed: true,
 						},
+
+						"preserve_mac_address": &schema.Schema{
+							Type:     schema.TypeBool,
+							Optional: true,
+							Default:  false,
+						},
 					},
 				},
 			},
@@ -410,6 +716,7 @@ func resourceVSphereVirtualMachine() *schema.Resource {
 			"disk": &schema.Schema{
 				Type:     schema.TypeSet,
 				Required: true,
+				Set:      resourceVSphereVirtualMachineDiskHash,
 				Elem: &schema.Resource{
 					Schema: map[string]*schema.Schema{
 						"uuid": &schema.Schema{
@@ -427,10 +734,25 @@ func resourceVSphereVirtualMachine() *schema.Resource {
 							Optional: true,
 						},
 
+						"content_library_item_id": &schema.Schema{
+							Type:     schema.TypeString,
+							Optional: true,
+						},
+
+						"ovf_file": &schema.Schema{
+							Type:     schema.TypeString,
+							Optional: true,
+						},
+
+						"ovf_datastore": &schema.Schema{
+							Type:     schema.TypeString,
+							Optional: true,
+						},
+
 						"type": &schema.Schema{
 							Type:     schema.TypeString,
 							Optional: true,
-							Default:  "eager_zeroed",
+							Computed: true,
 							ValidateFunc: func(v interface{}, k string) (ws []string, errors []error) {
 								value := v.(string)
 								if value != "thin" && value != "eager_zeroed" && value != "lazy" {
@@ -442,13 +764,15 @@ func resourceVSphereVirtualMachine() *schema.Resource {
 						},
 
 						"datastore": &schema.Schema{
-							Type:     schema.TypeString,
-							Optional: true,
+							Type:             schema.TypeString,
+							Optional:         true,
+							DiffSuppressFunc: suppressVirtualMachineDiskDatastore,
 						},
 
 						"size": &schema.Schema{
-							Type:     schema.TypeInt,
-							Optional: true,
+							Type:             schema.TypeInt,
+							Optional:         true,
+							DiffSuppressFunc: suppressVirtualMachineDiskUnset("0"),
 						},
 
 						"name": &schema.Schema{
@@ -467,6 +791,11 @@ func resourceVSphereVirtualMachine() *schema.Resource {
 							Optional: true,
 						},
 
+						"first_class_disk_id": &schema.Schema{
+							Type:     schema.TypeString,
+							Optional: true,
+						},
+
 						"bootable": &schema.Schema{
 							Type:     schema.TypeBool,
 							Optional: true,
@@ -477,10 +806,52 @@ func resourceVSphereVirtualMachine() *schema.Resource {
 							Optional: true,
 						},
 
+						"attach": &schema.Schema{
+							Type:     schema.TypeBool,
+							Optional: true,
+							Default:  false,
+						},
+
+						"disk_mode": &schema.Schema{
+							Type:         schema.TypeString,
+							Optional:     true,
+							Default:      string(types.VirtualDiskModePersistent),
+							ValidateFunc: validation.StringInSlice(virtualMachineDiskModeAllowedValues, false),
+						},
+
+						"write_through": &schema.Schema{
+							Type:     schema.TypeBool,
+							Optional: true,
+							Default:  false,
+						},
+
+						"sharing": &schema.Schema{
+							Type:         schema.TypeString,
+							Optional:     true,
+							Default:      string(types.VirtualDiskSharingSharingNone),
+							ValidateFunc: validation.StringInSlice(virtualMachineDiskSharingAllowedValues, false),
+						},
+
+						"rdm_lun": &schema.Schema{
+							Type:     schema.TypeString,
+							Optional: true,
+						},
+
+						"rdm_compatibility_mode": &schema.Schema{
+							Type:         schema.TypeString,
+							Optional:     true,
+							ValidateFunc: validation.StringInSlice(virtualMachineRDMCompatibilityModeAllowedValues, false),
+						},
+
 						"controller_type": &schema.Schema{
 							Type:     schema.TypeString,
 							Optional: true,
 							Default:  "scsi",
+							// The specific type of SCSI controller of an existing disk is
+							// not read back, and does not move the disk.
+							DiffSuppressFunc: func(k, old, new string, d *schema.ResourceData) bool {
+								return old != "" && strings.HasPrefix(old, "scsi") && strings.HasPrefix(new, "scsi")
+							},
 							ValidateFunc: func(v interface{}, k string) (ws []string, errors []error) {
 								value := v.(string)
 								found := false
@@ -496,10 +867,69 @@ func resourceVSphereVirtualMachine() *schema.Resource {
 								return
 							},
 						},
+
+						"controller_bus_number": &schema.Schema{
+							Type:             schema.TypeInt,
+							Optional:         true,
+							Default:          -1,
+							ValidateFunc:     validation.IntBetween(-1, 3),
+							DiffSuppressFunc: suppressVirtualMachineDiskUnset("-1"),
+						},
+
+						"unit_number": &schema.Schema{
+							Type:             schema.TypeInt,
+							Optional:         true,
+							Default:          -1,
+							ValidateFunc:     validation.IntBetween(-1, 29),
+							DiffSuppressFunc: suppressVirtualMachineDiskUnset("-1"),
+						},
+					},
+				},
+			},
+
+			"scsi_controller": &schema.Schema{
+				Type:     schema.TypeList,
+				Optional: true,
+				Computed: true,
+				MaxItems: 4,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"key": &schema.Schema{
+							Type:     schema.TypeInt,
+							Computed: true,
+						},
+
+						"type": &schema.Schema{
+							Type:         schema.TypeString,
+							Optional:     true,
+							Default:      "lsilogic",
+							ValidateFunc: validation.StringInSlice(virtualMachineSCSIControllerTypeAllowedValues, false),
+						},
+
+						"bus_sharing": &schema.Schema{
+							Type:         schema.TypeString,
+							Optional:     true,
+							Default:      string(types.VirtualSCSISharingNoSharing),
+							ValidateFunc: validation.StringInSlice(virtualMachineSCSIBusSharingAllowedValues, false),
+						},
 					},
 				},
 			},
 
+			"sata_controller_count": &schema.Schema{
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Computed:     true,
+				ValidateFunc: validation.IntBetween(0, 4),
+			},
+
+			"nvme_controller_count": &schema.Schema{
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Computed:     true,
+				ValidateFunc: validation.IntBetween(0, 4),
+			},
+
 			"detach_unknown_disks_on_delete": &schema.Schema{
 				Type:     schema.TypeBool,
 				Optional: true,
@@ -545,13 +975,52 @@ func resourceVSphereVirtualMachineUpdate(d *schema.ResourceData, meta interface{
 	if d.HasChange("vcpu") {
 		configSpec.NumCPUs = int32(d.Get("vcpu").(int))
 		hasChanges = true
-		rebootRequired = true
 	}
 
 	if d.HasChange("memory") {
 		configSpec.MemoryMB = int64(d.Get("memory").(int))
 		hasChanges = true
-		rebootRequired = true
+	}
+
+	if d.HasChange("cpu_hot_add_enabled") {
+		configSpec.CpuHotAddEnabled = boolPtr(d.Get("cpu_hot_add_enabled").(bool))
+		hasChanges = true
+	}
+
+	if d.HasChange("cpu_hot_remove_enabled") {
+		configSpec.CpuHotRemoveEnabled = boolPtr(d.Get("cpu_hot_remove_enabled").(bool))
+		hasChanges = true
+	}
+
+	if d.HasChange("memory_hot_add_enabled") {
+		configSpec.MemoryHotAddEnabled = boolPtr(d.Get("memory_hot_add_enabled").(bool))
+		hasChanges = true
+	}
+
+	// Resource allocation changes never require a reboot.
+	if d.HasChange("cpu_reservation") || d.HasChange("cpu_limit") || d.HasChange("cpu_share_level") || d.HasChange("cpu_share_count") {
+		configSpec.CpuAllocation = expandVirtualMachineResourceAllocation(d, "cpu")
+		hasChanges = true
+	}
+
+	if d.HasChange("memory_reservation") || d.HasChange("memory_limit") || d.HasChange("memory_share_level") || d.HasChange("memory_share_count") {
+		configSpec.MemoryAllocation = expandVirtualMachineResourceAllocation(d, "memory")
+		hasChanges = true
+	}
+
+	if d.HasChange("memory_reservation_locked_to_max") {
+		configSpec.MemoryReservationLockedToMax = boolPtr(d.Get("memory_reservation_locked_to_max").(bool))
+		hasChanges = true
+	}
+
+	if d.HasChange("firmware") {
+		configSpec.Firmware = d.Get("firmware").(string)
+		hasChanges = true
+	}
+
+	if d.HasChange("nested_hv_enabled") {
+		configSpec.NestedHVEnabled = boolPtr(d.Get("nested_hv_enabled").(bool))
+		hasChanges = true
 	}
 
 	if d.HasChange("annotation") {
@@ -580,13 +1049,95 @@ func resourceVSphereVirtualMachineUpdate(d *schema.ResourceData, meta interface{
 		return err
 	}
 
-	// Apply any pending tags now, before proceeding with any expensive VM updates
-	if tagsClient != nil {
+	// Boot options are set as a whole, and the boot order needs the current
+	// devices of the VM.
+	if d.HasChange("efi_secure_boot_enabled") || d.HasChange("boot_delay") || d.HasChange("boot_retry_enabled") || d.HasChange("boot_retry_delay") || d.HasChange("enter_bios_setup") || d.HasChange("boot_order") {
+		devices, err := vm.Device(context.TODO())
+		if err != nil {
+			return fmt.Errorf("error fetching devices for boot order: %s", err)
+		}
+		configSpec.BootOptions = expandVirtualMachineBootOptions(d, devices)
+		hasChanges = true
+	}
+
+	// Apply any pending tags now, before proceeding with any expensive VM updates
+	if tagsClient != nil {
 		if err := processTagDiff(tagsClient, d, vm); err != nil {
 			return err
 		}
 	}
 
+	// We process power state changes here in addition to VM updates, before
+	// any devices are changed, so that changes that need the VM to be powered
+	// off are made after it has been shut down. Any tasks already running on
+	// the VM, such as a power operation started outside of
+	// Terraform, are waited on first so that we do not fight them, and the
+	// power state is then reconciled against the actual state of the VM.
+	if err := waitForVirtualMachineTasks(vm); err != nil {
+		return err
+	}
+	powerState, err := vm.PowerState(context.TODO())
+	if err != nil {
+		return err
+	}
+
+	// CPU and memory changes are applied live where the hot-add settings of the
+	// virtual machine and its guest operating system allow it, otherwise the
+	// virtual machine is power cycled.
+	var hotPlug *virtualMachineHotPlugSupport
+	if virtualMachineHotPlugChange(d) {
+		if hotPlug, err = virtualMachineGuestHotPlugSupport(vm); err != nil {
+			return err
+		}
+	}
+	rebootRequired = virtualMachineRebootRequired(d, hotPlug)
+	desiredPowerState := types.VirtualMachinePowerState(d.Get("power_state").(string))
+	shutdownTimeout := d.Get("shutdown_wait_timeout").(int)
+	forcePowerOff := d.Get("force_power_off").(bool)
+
+	if (rebootRequired || desiredPowerState == types.VirtualMachinePowerStatePoweredOff) && powerState != types.VirtualMachinePowerStatePoweredOff {
+		log.Printf("[INFO] Shutting down virtual machine: %s", d.Id())
+		if err := setVirtualMachinePowerState(vm, powerState, types.VirtualMachinePowerStatePoweredOff, shutdownTimeout, forcePowerOff); err != nil {
+			return err
+		}
+		powerState = types.VirtualMachinePowerStatePoweredOff
+	}
+
+	// Placement changes are applied through vMotion and Storage vMotion, in a
+	// single migration, before any devices are changed.
+	if d.HasChange("cluster") || d.HasChange("resource_pool") || d.HasChange("host") || d.HasChange("disk") {
+		loc, ok, err := expandVirtualMachineRelocation(d, finder)
+		if err != nil {
+			return err
+		}
+		if ok {
+			spec, err := buildVMRelocateSpec(loc, vm, false, "")
+			if err != nil {
+				return err
+			}
+			log.Printf("[INFO] Migrating virtual machine: %s", d.Id())
+			if err := relocateVirtualMachine(vm, spec); err != nil {
+				return fmt.Errorf("error migrating virtual machine: %s", err)
+			}
+		}
+	}
+
+	// Disk controllers are configured before disks are changed, so that new
+	// disks can be attached to new controllers.
+	if d.HasChange("scsi_controller") || d.HasChange("sata_controller_count") || d.HasChange("nvme_controller_count") {
+		var controllers []scsiController
+		for _, v := range d.Get("scsi_controller").([]interface{}) {
+			c := v.(map[string]interface{})
+			controllers = append(controllers, scsiController{
+				controllerType: c["type"].(string),
+				busSharing:     c["bus_sharing"].(string),
+			})
+		}
+		if err := configureVirtualMachineDiskControllers(vm, controllers, d.Get("sata_controller_count").(int), d.Get("nvme_controller_count").(int)); err != nil {
+			return err
+		}
+	}
+
 	if d.HasChange("disk") {
 		hasChanges = true
 		oldDisks, newDisks := d.GetChange("disk")
@@ -605,9 +1156,20 @@ func resourceVSphereVirtualMachineUpdate(d *schema.ResourceData, meta interface{
 				}
 				virtualDisk := devices.FindByKey(int32(disk["key"].(int)))
 
-				keep := false
-				if v, ok := disk["keep_on_remove"].(bool); ok {
-					keep = v
+				if id, ok := disk["first_class_disk_id"].(string); ok && id != "" {
+					// First class disks are detached, never deleted, as their lifecycle
+					// is managed outside of this resource.
+					if err := detachFirstClassDisk(vm, id); err != nil {
+						return fmt.Errorf("[ERROR] Update Remove Disk - Error detaching first class disk %s: %v", id, err)
+					}
+					continue
+				}
+
+				// Attached disks are shared with other virtual machines, and are
+				// never deleted.
+				keep := disk["attach"].(bool)
+				if v, ok := disk["keep_on_remove"].(bool); ok && v {
+					keep = true
 				}
 
 				err = vm.RemoveDevice(context.TODO(), keep, virtualDisk)
@@ -634,6 +1196,23 @@ func resourceVSphereVirtualMachineUpdate(d *schema.ResourceData, meta interface{
 					}
 				}
 
+				if id, ok := disk["first_class_disk_id"].(string); ok && id != "" {
+					log.Printf("[INFO] Attaching first class disk: %v", id)
+					if err := addFirstClassDisk(vm, id, datastore, disk["controller_type"].(string), int32(disk["controller_bus_number"].(int)), int32(disk["unit_number"].(int))); err != nil {
+						log.Printf("[ERROR] Add First Class Disk Failed: %v", err)
+						return err
+					}
+					continue
+				}
+
+				if lun := disk["rdm_lun"].(string); lun != "" {
+					log.Printf("[INFO] Attaching raw device mapping: %v", lun)
+					if err := addRDMDisk(client, vm, lun, disk["rdm_compatibility_mode"].(string), datastore, disk["controller_type"].(string), int32(disk["controller_bus_number"].(int)), int32(disk["unit_number"].(int)), expandDiskBackingOptions(disk)); err != nil {
+						return err
+					}
+					continue
+				}
+
 				var size int64
 				if disk["size"] == 0 {
 					size = 0
@@ -662,15 +1241,13 @@ func resourceVSphereVirtualMachineUpdate(d *schema.ResourceData, meta interface{
 					return fmt.Errorf("[ERROR] resourceVSphereVirtualMachineUpdate - Neither vmdk path nor vmdk name was given")
 				}
 
-				var initType string
+				initType := "eager_zeroed"
 				if disk["type"] != "" {
 					initType = disk["type"].(string)
-				} else {
-					initType = "thin"
 				}
 
 				log.Printf("[INFO] Attaching disk: %v", diskPath)
-				err = addHardDisk(vm, size, iops, initType, datastore, diskPath, controller_type)
+				err = addHardDisk(vm, size, iops, initType, datastore, diskPath, controller_type, int32(disk["controller_bus_number"].(int)), int32(disk["unit_number"].(int)), expandDiskBackingOptions(disk))
 				if err != nil {
 					log.Printf("[ERROR] Add Hard Disk Failed: %v", err)
 					return err
@@ -680,31 +1257,42 @@ func resourceVSphereVirtualMachineUpdate(d *schema.ResourceData, meta interface{
 				return err
 			}
 		}
-	}
-
-	log.Printf("[DEBUG] virtual machine config spec: %v", configSpec)
-
-	// We process power state changes here in addition to VM updates. The only
-	// "at rest" power state for a VM managed by Terraform is on, but depending
-	// on if the VM was powered off or not outside of Terraform, we need to
-	// handle rebootRequired in different ways.
-	o, _ := d.GetChange("power_state")
-	powerState := types.VirtualMachinePowerState(o.(string))
-
-	if rebootRequired && powerState != types.VirtualMachinePowerStatePoweredOff {
-		log.Printf("[INFO] Shutting down virtual machine: %s", d.Id())
-
-		task, err := vm.PowerOff(context.TODO())
-		if err != nil {
-			return err
+		// Changed disks. Only the source and controller address of a disk are
+		// part of its hash, so disks with other changes are in both sets. Size,
+		// IOPS, and backing changes are applied to the existing disk, and the
+		// remaining attributes only live in state.
+		oldDisksByHash := virtualMachineDisksByHash(oldDiskSet)
+		for _, diskRaw := range newDiskSet.Intersection(oldDiskSet).List() {
+			disk := diskRaw.(map[string]interface{})
+			oldDisk := oldDisksByHash[resourceVSphereVirtualMachineDiskHash(disk)]
+			key := int32(oldDisk["key"].(int))
+			if disk["size"].(int) > oldDisk["size"].(int) {
+				log.Printf("[INFO] Growing disk %q to %d GB", virtualMachineDiskSource(disk), disk["size"])
+				if err := growHardDisk(vm, key, int64(disk["size"].(int))); err != nil {
+					return err
+				}
+			}
+			changed := disk["iops"] != oldDisk["iops"]
+			for _, k := range virtualMachineDiskBackingKeys {
+				changed = changed || disk[k] != oldDisk[k]
+			}
+			if changed {
+				log.Printf("[INFO] Updating disk %q", virtualMachineDiskSource(disk))
+				if err := updateHardDisk(vm, key, int64(disk["iops"].(int)), expandDiskBackingOptions(disk)); err != nil {
+					return err
+				}
+			}
 		}
+	}
 
-		err = task.Wait(context.TODO())
-		if err != nil {
+	if d.HasChange("network_interface") {
+		if err := updateVirtualMachineNetworkInterfaces(d, finder, vm); err != nil {
 			return err
 		}
 	}
 
+	log.Printf("[DEBUG] virtual machine config spec: %v", configSpec)
+
 	// Perform reconfiguration tasks if we we have them
 	if hasChanges {
 		log.Printf("[INFO] Reconfiguring virtual machine: %s", d.Id())
@@ -722,21 +1310,14 @@ func resourceVSphereVirtualMachineUpdate(d *schema.ResourceData, meta interface{
 		}
 	}
 
-	if rebootRequired || powerState != types.VirtualMachinePowerStatePoweredOn {
-		task, err := vm.PowerOn(context.TODO())
-		if err != nil {
-			return err
-		}
-
-		err = task.Wait(context.TODO())
-		if err != nil {
-			log.Printf("[ERROR] %s", err)
+	if powerState != desiredPowerState {
+		if err := setVirtualMachinePowerState(vm, powerState, desiredPowerState, shutdownTimeout, forcePowerOff); err != nil {
 			return err
 		}
 
 		// Wait for VM guest networking before returning, so that Read can get
 		// accurate networking info for the state.
-		if d.Get("wait_for_guest_net").(bool) {
+		if desiredPowerState == types.VirtualMachinePowerStatePoweredOn && d.Get("wait_for_guest_net").(bool) {
 			log.Printf("[DEBUG] Waiting for routeable guest network access")
 			if err := waitForGuestVMNet(client, vm); err != nil {
 				return err
@@ -748,6 +1329,468 @@ func resourceVSphereVirtualMachineUpdate(d *schema.ResourceData, meta interface{
 	return resourceVSphereVirtualMachineRead(d, meta)
 }
 
+// resourceVSphereVirtualMachineCustomizeDiff validates the firmware and boot
+// settings, and flags reboot_required in the diff when the pending changes
+// cannot be applied while the virtual machine is powered on, so that the plan
+// shows if the virtual machine will be power cycled.
+func resourceVSphereVirtualMachineCustomizeDiff(d *schema.ResourceDiff, meta interface{}) error {
+	if d.Get("efi_secure_boot_enabled").(bool) && d.Get("firmware").(string) != string(types.GuestOsDescriptorFirmwareTypeEfi) {
+		return fmt.Errorf("efi_secure_boot_enabled requires firmware to be set to %q", types.GuestOsDescriptorFirmwareTypeEfi)
+	}
+	seen := make(map[string]bool)
+	for _, v := range d.Get("boot_order").([]interface{}) {
+		if seen[v.(string)] {
+			return fmt.Errorf("boot_order contains %q more than once", v.(string))
+		}
+		seen[v.(string)] = true
+	}
+	if err := validateVirtualMachineDisks(d.Get("disk").(*schema.Set)); err != nil {
+		return err
+	}
+	for i, v := range d.Get("network_interface").([]interface{}) {
+		nic := v.(map[string]interface{})
+		if nic["adapter_type"] != "sriov" {
+			if nic["physical_function"] != "" {
+				return fmt.Errorf("network_interface.%d: physical_function can only be set on sriov adapters", i)
+			}
+			continue
+		}
+		if nic["physical_function"] == "" {
+			return fmt.Errorf("network_interface.%d: sriov adapters require physical_function to be set", i)
+		}
+		if !d.Get("memory_reservation_locked_to_max").(bool) {
+			return fmt.Errorf("network_interface.%d: sriov adapters require memory_reservation_locked_to_max to be enabled", i)
+		}
+	}
+	if d.Id() == "" {
+		return nil
+	}
+	if err := virtualMachineNetworkInterfaceCustomizeDiff(d); err != nil {
+		return err
+	}
+	if err := virtualMachineSCSIControllerCustomizeDiff(d); err != nil {
+		return err
+	}
+	if err := virtualMachineDiskSizeCustomizeDiff(d); err != nil {
+		return err
+	}
+	if err := virtualMachineDiskDatastoreCustomizeDiff(d); err != nil {
+		return err
+	}
+	if err := virtualMachineDiskChangeCustomizeDiff(d); err != nil {
+		return err
+	}
+	var hotPlug *virtualMachineHotPlugSupport
+	if virtualMachineHotPlugChange(d) {
+		client := meta.(*VSphereClient).vimClient
+		o, _ := d.GetChange("datacenter")
+		dc, err := getDatacenter(client, o.(string))
+		if err != nil {
+			return err
+		}
+		vm, err := find.NewFinder(client.Client, true).SetDatacenter(dc).VirtualMachine(context.TODO(), d.Id())
+		if err != nil {
+			return fmt.Errorf("error locating virtual machine %q: %s", d.Id(), err)
+		}
+		if hotPlug, err = virtualMachineGuestHotPlugSupport(vm); err != nil {
+			return err
+		}
+	}
+	if virtualMachineRebootRequired(d, hotPlug) {
+		return d.SetNew("reboot_required", true)
+	}
+	return nil
+}
+
+// validateVirtualMachineDisks checks the attach, raw device mapping, and
+// sharing settings of the disks of a virtual machine. Attached disks must be
+// existing vmdk files, raw device mappings only take a LUN, and new disks
+// shared with multi-writer must be eager zeroed, as vSphere does not allow
+// multi-writer sharing on thin or lazy zeroed disks.
+func validateVirtualMachineDisks(disks *schema.Set) error {
+	for _, v := range disks.List() {
+		disk := v.(map[string]interface{})
+		if disk["attach"].(bool) {
+			if disk["vmdk"] == "" {
+				return fmt.Errorf("disks with attach set must have vmdk set to the path of an existing disk")
+			}
+			for _, k := range []string{"template", "content_library_item_id", "ovf_file", "name", "first_class_disk_id"} {
+				if disk[k] != "" {
+					return fmt.Errorf("disk %q: %s cannot be set on an attached disk", disk["vmdk"], k)
+				}
+			}
+			if disk["size"].(int) != 0 {
+				return fmt.Errorf("disk %q: size cannot be set on an attached disk", disk["vmdk"])
+			}
+		}
+		if disk["rdm_lun"] != "" {
+			for _, k := range []string{"template", "content_library_item_id", "ovf_file", "name", "vmdk", "first_class_disk_id"} {
+				if disk[k] != "" {
+					return fmt.Errorf("disk %q: %s cannot be set on a raw device mapping", disk["rdm_lun"], k)
+				}
+			}
+			if disk["size"].(int) != 0 || disk["attach"].(bool) {
+				return fmt.Errorf("disk %q: size and attach cannot be set on a raw device mapping", disk["rdm_lun"])
+			}
+		} else if disk["rdm_compatibility_mode"] != "" {
+			return fmt.Errorf("rdm_compatibility_mode can only be set on raw device mappings")
+		}
+		if disk["sharing"] == string(types.VirtualDiskSharingSharingMultiWriter) && disk["size"].(int) != 0 && disk["type"] != "" && disk["type"] != "eager_zeroed" {
+			return fmt.Errorf("disk %q: disks shared with %s must be of type eager_zeroed", disk["name"], types.VirtualDiskSharingSharingMultiWriter)
+		}
+	}
+	return nil
+}
+
+// virtualMachineDiskSizeCustomizeDiff rejects disks that shrink. Disks are
+// only grown in place, as vSphere cannot shrink a virtual disk.
+func virtualMachineDiskSizeCustomizeDiff(d *schema.ResourceDiff) error {
+	if !d.HasChange("disk") {
+		return nil
+	}
+	o, n := d.GetChange("disk")
+	oldDisks := virtualMachineDisksByHash(o.(*schema.Set))
+	for _, v := range n.(*schema.Set).List() {
+		newDisk := v.(map[string]interface{})
+		oldDisk, ok := oldDisks[resourceVSphereVirtualMachineDiskHash(newDisk)]
+		if !ok {
+			continue
+		}
+		if newDisk["size"].(int) < oldDisk["size"].(int) {
+			return fmt.Errorf("disk %q: size cannot be reduced from %d to %d GB, disks can only be grown", virtualMachineDiskSource(newDisk), oldDisk["size"], newDisk["size"])
+		}
+	}
+	return nil
+}
+
+// virtualMachineDiskDatastoreCustomizeDiff rejects datastore changes on disks
+// that cannot be migrated with the virtual machine. Attached disks are shared
+// with other virtual machines, and first class disks are managed on their
+// own.
+func virtualMachineDiskDatastoreCustomizeDiff(d *schema.ResourceDiff) error {
+	if !d.HasChange("disk") {
+		return nil
+	}
+	o, n := d.GetChange("disk")
+	oldDisks := virtualMachineDisksByHash(o.(*schema.Set))
+	for _, v := range n.(*schema.Set).List() {
+		newDisk := v.(map[string]interface{})
+		oldDisk, ok := oldDisks[resourceVSphereVirtualMachineDiskHash(newDisk)]
+		if !ok || newDisk["datastore"] == oldDisk["datastore"] {
+			continue
+		}
+		if newDisk["attach"].(bool) || newDisk["first_class_disk_id"] != "" {
+			return fmt.Errorf("disk %q: the datastore of attached and first class disks cannot be changed", newDisk["vmdk"].(string)+newDisk["first_class_disk_id"].(string))
+		}
+	}
+	return nil
+}
+
+// virtualMachineDiskChangeCustomizeDiff rejects changes that cannot be made
+// to an existing disk: changes to its type, attach, or compatibility mode,
+// and moving it to another controller address. The controller address of an
+// existing disk is read from the virtual machine, so it can be set to the
+// address the disk is at, and a controller_bus_number or unit_number of -1
+// leaves it where it is.
+func virtualMachineDiskChangeCustomizeDiff(d *schema.ResourceDiff) error {
+	if !d.HasChange("disk") {
+		return nil
+	}
+	o, n := d.GetChange("disk")
+	oldDiskSet := o.(*schema.Set)
+	newDiskSet := n.(*schema.Set)
+	oldDisks := virtualMachineDisksByHash(oldDiskSet)
+	for _, v := range newDiskSet.Intersection(oldDiskSet).List() {
+		newDisk := v.(map[string]interface{})
+		oldDisk := oldDisks[resourceVSphereVirtualMachineDiskHash(newDisk)]
+		for _, k := range virtualMachineDiskImmutableKeys {
+			if newDisk[k] != oldDisk[k] {
+				return fmt.Errorf("disk %q: %s cannot be changed on an existing disk", virtualMachineDiskSource(newDisk), k)
+			}
+		}
+	}
+	return nil
+}
+
+// virtualMachineNetworkInterfaceCustomizeDiff forces a new virtual machine
+// when network interfaces are added or removed, or when their IP settings
+// change, as these are only applied through guest customization. Changes to
+// the network, adapter type, and MAC address of an interface are applied in
+// place. Replacing an adapter with preserve_mac_address set fails if its MAC
+// address cannot be set manually.
+func virtualMachineNetworkInterfaceCustomizeDiff(d *schema.ResourceDiff) error {
+	if !d.HasChange("network_interface") {
+		return nil
+	}
+	o, n := d.GetChange("network_interface")
+	oldNICs := o.([]interface{})
+	newNICs := n.([]interface{})
+	if len(oldNICs) != len(newNICs) {
+		return d.ForceNew("network_interface")
+	}
+	for i := range newNICs {
+		oldNIC := oldNICs[i].(map[string]interface{})
+		newNIC := newNICs[i].(map[string]interface{})
+		for _, k := range virtualMachineNetworkInterfaceForceNewKeys {
+			if oldNIC[k] != newNIC[k] {
+				return d.ForceNew("network_interface")
+			}
+		}
+		replaced := oldNIC["adapter_type"] != newNIC["adapter_type"] || oldNIC["physical_function"] != newNIC["physical_function"]
+		if replaced && newNIC["preserve_mac_address"].(bool) && newNIC["mac_address"] == oldNIC["mac_address"] && !manualMACAddressAllowed(oldNIC["mac_address"].(string)) {
+			return fmt.Errorf("network_interface.%d: MAC address %s was generated by vCenter and cannot be preserved, as vSphere only accepts manual MAC addresses in the VMware range from 00:50:56:00:00:00 to 00:50:56:3f:ff:ff. Unset preserve_mac_address, or set mac_address to an address in that range", i, oldNIC["mac_address"])
+		}
+	}
+	return nil
+}
+
+// manualMACAddressAllowed returns false if the supplied MAC address is in the
+// part of the VMware range that vCenter generates addresses from, from
+// 00:50:56:80:00:00 to 00:50:56:bf:ff:ff, which vSphere rejects as a manual MAC
+// address. Addresses outside of the VMware range are not checked.
+func manualMACAddressAllowed(mac string) bool {
+	mac = strings.ToLower(mac)
+	if !strings.HasPrefix(mac, "00:50:56:") || len(mac) < 11 {
+		return true
+	}
+	octet, err := strconv.ParseUint(mac[9:11], 16, 8)
+	if err != nil {
+		return true
+	}
+	return octet <= 0x3f
+}
+
+// virtualMachineSCSIControllerCustomizeDiff forces a new virtual machine when
+// the type of an existing SCSI controller changes, as a controller cannot be
+// replaced while disks are attached to it.
+func virtualMachineSCSIControllerCustomizeDiff(d *schema.ResourceDiff) error {
+	if !d.HasChange("scsi_controller") {
+		return nil
+	}
+	o, n := d.GetChange("scsi_controller")
+	oldControllers := o.([]interface{})
+	newControllers := n.([]interface{})
+	for i := 0; i < len(oldControllers) && i < len(newControllers); i++ {
+		if oldControllers[i].(map[string]interface{})["type"] != newControllers[i].(map[string]interface{})["type"] {
+			return d.ForceNew("scsi_controller")
+		}
+	}
+	return nil
+}
+
+// virtualMachineChangeGetter is implemented by both schema.ResourceData and
+// schema.ResourceDiff, so that the same checks can be used during diff and
+// apply.
+type virtualMachineChangeGetter interface {
+	GetChange(string) (interface{}, interface{})
+}
+
+// virtualMachineHotPlugSupport describes the CPU and memory hot-plug support of
+// the guest operating system of a virtual machine, as reported by the guest
+// OS descriptor of its environment browser. memoryLimit is the maximum amount
+// of memory, in MB, that can be hot-added to the virtual machine, or 0 if it
+// is not known.
+type virtualMachineHotPlugSupport struct {
+	cpuAdd      bool
+	cpuRemove   bool
+	memoryAdd   bool
+	memoryLimit int64
+}
+
+// virtualMachineHotPlugChange returns true if the pending changes include CPU
+// or memory changes on a running virtual machine, which is when the hot-plug
+// support of the guest operating system needs to be looked up.
+func virtualMachineHotPlugChange(d virtualMachineChangeGetter) bool {
+	o, n := d.GetChange("power_state")
+	if types.VirtualMachinePowerState(o.(string)) != types.VirtualMachinePowerStatePoweredOn || types.VirtualMachinePowerState(n.(string)) != types.VirtualMachinePowerStatePoweredOn {
+		return false
+	}
+	oldCPU, newCPU := d.GetChange("vcpu")
+	oldMemory, newMemory := d.GetChange("memory")
+	return oldCPU.(int) != newCPU.(int) || oldMemory.(int) != newMemory.(int)
+}
+
+// virtualMachineGuestHotPlugSupport looks up the hot-plug support of the guest
+// operating system that the virtual machine is currently configured with. If
+// the environment browser has no descriptor for the guest ID, hot-plug is
+// assumed to be supported by the guest, and only the settings of the virtual
+// machine itself are used.
+func virtualMachineGuestHotPlugSupport(vm *object.VirtualMachine) (*virtualMachineHotPlugSupport, error) {
+	props, err := virtualMachineProperties(vm)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching virtual machine properties: %s", err)
+	}
+	if props.Config == nil {
+		return nil, fmt.Errorf("virtual machine %q has no configuration", vm.InventoryPath)
+	}
+	support := &virtualMachineHotPlugSupport{
+		cpuAdd:      true,
+		cpuRemove:   true,
+		memoryAdd:   true,
+		memoryLimit: props.Config.HotPlugMemoryLimit,
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), defaultAPITimeout)
+	defer cancel()
+	req := types.QueryConfigOption{
+		This: props.EnvironmentBrowser,
+		Key:  props.Config.Version,
+		Host: props.Runtime.Host,
+	}
+	res, err := methods.QueryConfigOption(ctx, vm.Client(), &req)
+	if err != nil {
+		return nil, fmt.Errorf("error querying config options for virtual machine %q: %s", vm.InventoryPath, err)
+	}
+	if res.Returnval == nil {
+		return support, nil
+	}
+	for _, desc := range res.Returnval.GuestOSDescriptor {
+		if desc.Id != props.Config.GuestId {
+			continue
+		}
+		support.cpuAdd = desc.SupportsCpuHotAdd != nil && *desc.SupportsCpuHotAdd
+		support.cpuRemove = desc.SupportsCpuHotRemove != nil && *desc.SupportsCpuHotRemove
+		support.memoryAdd = desc.SupportsMemoryHotAdd != nil && *desc.SupportsMemoryHotAdd
+		return support, nil
+	}
+	log.Printf("[DEBUG] No guest OS descriptor for guest ID %q on virtual machine %q, assuming hot-plug is supported", props.Config.GuestId, vm.InventoryPath)
+	return support, nil
+}
+
+// virtualMachineRebootRequired returns true if the pending changes to CPU,
+// memory, and hot-add settings require the virtual machine to be powered off
+// and back on again.
+//
+// The hot-add settings themselves, firmware, secure boot, nested hardware
+// virtualization, the disk controller changes listed in
+// virtualMachineDiskControllersRebootRequired, the disk changes listed in
+// virtualMachineDisksRebootRequired, and the network interface changes listed
+// in virtualMachineNetworkInterfacesRebootRequired can only be made while the
+// virtual machine is powered off. CPUs can be added or removed live if CPU
+// hot-add or hot-remove is enabled, respectively, and memory can be added live
+// if memory hot-add is enabled, as long as the guest operating system
+// described by hotPlug supports it and the new amount of memory does not
+// exceed its hot-add limit. A nil hotPlug assumes guest support. Memory can
+// never be removed live, and no changes can be made to a suspended virtual
+// machine. A virtual machine that is, or is going to be, powered off never
+// needs to be power cycled.
+func virtualMachineRebootRequired(d virtualMachineChangeGetter, hotPlug *virtualMachineHotPlugSupport) bool {
+	oldPowerState, newPowerState := d.GetChange("power_state")
+	if types.VirtualMachinePowerState(oldPowerState.(string)) == types.VirtualMachinePowerStatePoweredOff || types.VirtualMachinePowerState(newPowerState.(string)) == types.VirtualMachinePowerStatePoweredOff {
+		return false
+	}
+	suspended := types.VirtualMachinePowerState(oldPowerState.(string)) == types.VirtualMachinePowerStateSuspended
+	for _, k := range []string{"cpu_hot_add_enabled", "cpu_hot_remove_enabled", "memory_hot_add_enabled", "firmware", "efi_secure_boot_enabled", "nested_hv_enabled"} {
+		if o, n := d.GetChange(k); o != n {
+			return true
+		}
+	}
+	if virtualMachineDiskControllersRebootRequired(d) || virtualMachineDisksRebootRequired(d) || virtualMachineNetworkInterfacesRebootRequired(d) {
+		return true
+	}
+	oldCPU, newCPU := d.GetChange("vcpu")
+	oldMemory, newMemory := d.GetChange("memory")
+	if suspended {
+		return oldCPU.(int) != newCPU.(int) || oldMemory.(int) != newMemory.(int)
+	}
+	cpuHotAdd, _ := d.GetChange("cpu_hot_add_enabled")
+	cpuHotRemove, _ := d.GetChange("cpu_hot_remove_enabled")
+	memoryHotAdd, _ := d.GetChange("memory_hot_add_enabled")
+	if hotPlug == nil {
+		hotPlug = &virtualMachineHotPlugSupport{cpuAdd: true, cpuRemove: true, memoryAdd: true}
+	}
+	switch {
+	case newCPU.(int) > oldCPU.(int) && (!cpuHotAdd.(bool) || !hotPlug.cpuAdd):
+		return true
+	case newCPU.(int) < oldCPU.(int) && (!cpuHotRemove.(bool) || !hotPlug.cpuRemove):
+		return true
+	}
+	switch {
+	case newMemory.(int) > oldMemory.(int) && (!memoryHotAdd.(bool) || !hotPlug.memoryAdd):
+		return true
+	case newMemory.(int) > oldMemory.(int) && hotPlug.memoryLimit > 0 && int64(newMemory.(int)) > hotPlug.memoryLimit:
+		return true
+	case newMemory.(int) < oldMemory.(int):
+		return true
+	}
+	return false
+}
+
+// virtualMachineDisksRebootRequired returns true if the mode, write-through,
+// or sharing setting of an existing disk changes, as the backing of a disk can
+// only be edited while the virtual machine is powered off, or if a disk on an
+// IDE controller grows, as IDE disks cannot be extended live.
+func virtualMachineDisksRebootRequired(d virtualMachineChangeGetter) bool {
+	o, n := d.GetChange("disk")
+	oldDiskSet := o.(*schema.Set)
+	newDiskSet := n.(*schema.Set)
+	oldDisks := virtualMachineDisksByHash(oldDiskSet)
+	for _, v := range newDiskSet.Intersection(oldDiskSet).List() {
+		newDisk := v.(map[string]interface{})
+		oldDisk := oldDisks[resourceVSphereVirtualMachineDiskHash(newDisk)]
+		for _, k := range virtualMachineDiskBackingKeys {
+			if newDisk[k] != oldDisk[k] {
+				return true
+			}
+		}
+		if newDisk["controller_type"] == "ide" && newDisk["size"].(int) > oldDisk["size"].(int) {
+			return true
+		}
+	}
+	return false
+}
+
+// virtualMachineNetworkInterfacesRebootRequired returns true if an SR-IOV
+// network interface is added, removed, or moved to another physical function,
+// or if a network interface is replaced with one of another adapter type to or
+// from sriov, as SR-IOV adapters can only be added and removed while the
+// virtual machine is powered off.
+func virtualMachineNetworkInterfacesRebootRequired(d virtualMachineChangeGetter) bool {
+	o, n := d.GetChange("network_interface")
+	oldNICs := o.([]interface{})
+	newNICs := n.([]interface{})
+	for i := 0; i < len(oldNICs) || i < len(newNICs); i++ {
+		var oldNIC, newNIC map[string]interface{}
+		if i < len(oldNICs) {
+			oldNIC = oldNICs[i].(map[string]interface{})
+		}
+		if i < len(newNICs) {
+			newNIC = newNICs[i].(map[string]interface{})
+		}
+		if oldNIC["adapter_type"] != "sriov" && newNIC["adapter_type"] != "sriov" {
+			continue
+		}
+		if oldNIC == nil || newNIC == nil || oldNIC["adapter_type"] != newNIC["adapter_type"] || oldNIC["physical_function"] != newNIC["physical_function"] {
+			return true
+		}
+	}
+	return false
+}
+
+// virtualMachineDiskControllersRebootRequired returns true if a SCSI
+// controller is removed or has its bus sharing changed, if a SATA controller
+// is removed, or if the number of NVMe controllers changes. SCSI and SATA
+// controllers can be added live.
+func virtualMachineDiskControllersRebootRequired(d virtualMachineChangeGetter) bool {
+	o, n := d.GetChange("scsi_controller")
+	oldControllers := o.([]interface{})
+	newControllers := n.([]interface{})
+	if len(newControllers) < len(oldControllers) {
+		return true
+	}
+	for i := range oldControllers {
+		if oldControllers[i].(map[string]interface{})["bus_sharing"] != newControllers[i].(map[string]interface{})["bus_sharing"] {
+			return true
+		}
+	}
+	if o, n := d.GetChange("sata_controller_count"); n.(int) < o.(int) {
+		return true
+	}
+	if o, n := d.GetChange("nvme_controller_count"); o.(int) != n.(int) {
+		return true
+	}
+	return false
+}
+
 func resourceVSphereVirtualMachineCreate(d *schema.ResourceData, meta interface{}) error {
 	client := meta.(*VSphereClient).vimClient
 	// Load up the tags client, which will validate a proper vCenter before
@@ -758,12 +1801,12 @@ func resourceVSphereVirtualMachineCreate(d *schema.ResourceData, meta interface{
 	}
 
 	vm := virtualMachine{
-		name:     d.Get("name").(string),
-		vcpu:     int32(d.Get("vcpu").(int)),
-		memoryMb: int64(d.Get("memory").(int)),
-		memoryAllocation: memoryAllocation{
-			reservation: int64(d.Get("memory_reservation").(int)),
-		},
+		name:                     d.Get("name").(string),
+		vcpu:                     int32(d.Get("vcpu").(int)),
+		memoryMb:                 int64(d.Get("memory").(int)),
+		cpuAllocation:            expandVirtualMachineResourceAllocation(d, "cpu"),
+		memoryAllocation:         expandVirtualMachineResourceAllocation(d, "memory"),
+		memoryReservationLocked:  d.Get("memory_reservation_locked_to_max").(bool),
 		customizationWaitTimeout: d.Get("wait_for_customization_timeout").(int),
 	}
 
@@ -787,6 +1830,10 @@ func resourceVSphereVirtualMachineCreate(d *schema.ResourceData, meta interface{
 		vm.resourcePool = v.(string)
 	}
 
+	if v, ok := d.GetOk("host"); ok {
+		vm.host = v.(string)
+	}
+
 	if v, ok := d.GetOk("domain"); ok {
 		vm.domain = v.(string)
 	}
@@ -809,10 +1856,36 @@ func resourceVSphereVirtualMachineCreate(d *schema.ResourceData, meta interface{
 		vm.skipCustomization = v.(bool)
 	}
 
+	if v, ok := d.GetOk("ovf_properties"); ok {
+		vm.ovfProperties = make(map[string]string)
+		for k, p := range v.(map[string]interface{}) {
+			vm.ovfProperties[k] = p.(string)
+		}
+	}
+
+	if v, ok := d.GetOk("ovf_deployment_option"); ok {
+		vm.ovfDeploymentOption = v.(string)
+	}
+
+	vm.powerState = types.VirtualMachinePowerState(d.Get("power_state").(string))
+
 	if v, ok := d.GetOk("enable_disk_uuid"); ok {
 		vm.enableDiskUUID = v.(bool)
 	}
 
+	if v, ok := d.GetOk("firmware"); ok {
+		vm.firmware = v.(string)
+	}
+	vm.nestedHVEnabled = getBoolPtr(d, "nested_hv_enabled")
+	vm.bootOptions = expandVirtualMachineBootOptions(d, nil)
+	for _, v := range d.Get("boot_order").([]interface{}) {
+		vm.bootOrder = append(vm.bootOrder, v.(string))
+	}
+
+	vm.cpuHotAddEnabled = d.Get("cpu_hot_add_enabled").(bool)
+	vm.cpuHotRemoveEnabled = d.Get("cpu_hot_remove_enabled").(bool)
+	vm.memoryHotAddEnabled = d.Get("memory_hot_add_enabled").(bool)
+
 	if raw, ok := d.GetOk("dns_suffixes"); ok {
 		for _, v := range raw.([]interface{}) {
 			vm.dnsSuffixes = append(vm.dnsSuffixes, v.(string))
@@ -889,6 +1962,9 @@ func resourceVSphereVirtualMachineCreate(d *schema.ResourceData, meta interface{
 			if v, ok := network["adapter_type"].(string); ok && v != "" {
 				networks[i].adapterType = v
 			}
+			if v, ok := network["physical_function"].(string); ok && v != "" {
+				networks[i].physicalFunction = v
+			}
 		}
 		vm.networkInterfaces = networks
 		log.Printf("[DEBUG] network_interface init: %v", networks)
@@ -935,26 +2011,85 @@ func resourceVSphereVirtualMachineCreate(d *schema.ResourceData, meta interface{
 					vm.hasBootableVmdk = true
 				}
 
-				if v, ok := disk["type"].(string); ok && v != "" {
-					newDisk.initType = v
-				}
-
-				if v, ok := disk["datastore"].(string); ok && v != "" {
-					vm.datastore = v
-				}
-
-				if v, ok := disk["size"].(int); ok && v != 0 {
+				if vItem, ok := disk["content_library_item_id"].(string); ok && vItem != "" {
 					if v, ok := disk["template"].(string); ok && v != "" {
-						return fmt.Errorf("Cannot specify size of a template")
+						return fmt.Errorf("Cannot specify both a template and a content library item")
 					}
-
 					if v, ok := disk["name"].(string); ok && v != "" {
-						newDisk.name = v
-					} else {
-						return fmt.Errorf("[ERROR] Disk name must be provided when creating a new disk")
+						return fmt.Errorf("Cannot specify name of a content library item")
 					}
-
-					newDisk.size = int64(v)
+					if v, ok := disk["size"].(int); ok && v != 0 {
+						return fmt.Errorf("Cannot specify size of a content library item")
+					}
+					if v, ok := disk["vmdk"].(string); ok && v != "" {
+						return fmt.Errorf("Cannot specify a vmdk for a content library item")
+					}
+					if v, ok := disk["first_class_disk_id"].(string); ok && v != "" {
+						return fmt.Errorf("Cannot specify a first class disk for a content library item")
+					}
+					if vm.linkedClone {
+						return fmt.Errorf("Cannot create a linked clone from a content library item")
+					}
+					vm.contentLibraryItemID = vItem
+					if vm.hasBootableVmdk {
+						return fmt.Errorf("[ERROR] Only one bootable disk, template, or content library item may be given")
+					}
+					vm.hasBootableVmdk = true
+				}
+
+				if vOvf, ok := disk["ovf_file"].(string); ok && vOvf != "" {
+					if v, ok := disk["template"].(string); ok && v != "" {
+						return fmt.Errorf("Cannot specify both a template and an OVF file")
+					}
+					if v, ok := disk["content_library_item_id"].(string); ok && v != "" {
+						return fmt.Errorf("Cannot specify both a content library item and an OVF file")
+					}
+					if v, ok := disk["name"].(string); ok && v != "" {
+						return fmt.Errorf("Cannot specify name of an OVF file")
+					}
+					if v, ok := disk["size"].(int); ok && v != 0 {
+						return fmt.Errorf("Cannot specify size of an OVF file")
+					}
+					if v, ok := disk["vmdk"].(string); ok && v != "" {
+						return fmt.Errorf("Cannot specify a vmdk for an OVF file")
+					}
+					if v, ok := disk["first_class_disk_id"].(string); ok && v != "" {
+						return fmt.Errorf("Cannot specify a first class disk for an OVF file")
+					}
+					if vm.linkedClone {
+						return fmt.Errorf("Cannot create a linked clone from an OVF file")
+					}
+					vm.ovfFile = vOvf
+					vm.ovfDatastore = disk["ovf_datastore"].(string)
+					if vm.hasBootableVmdk {
+						return fmt.Errorf("[ERROR] Only one bootable disk, template, content library item, or OVF file may be given")
+					}
+					vm.hasBootableVmdk = true
+				} else if v, ok := disk["ovf_datastore"].(string); ok && v != "" {
+					return fmt.Errorf("ovf_datastore can only be set with ovf_file")
+				}
+
+				newDisk.initType = "eager_zeroed"
+				if v, ok := disk["type"].(string); ok && v != "" {
+					newDisk.initType = v
+				}
+
+				if v, ok := disk["datastore"].(string); ok && v != "" {
+					newDisk.datastore = v
+				}
+
+				if v, ok := disk["size"].(int); ok && v != 0 {
+					if v, ok := disk["template"].(string); ok && v != "" {
+						return fmt.Errorf("Cannot specify size of a template")
+					}
+
+					if v, ok := disk["name"].(string); ok && v != "" {
+						newDisk.name = v
+					} else {
+						return fmt.Errorf("[ERROR] Disk name must be provided when creating a new disk")
+					}
+
+					newDisk.size = int64(v)
 				}
 
 				if v, ok := disk["iops"].(int); ok && v != 0 {
@@ -964,6 +2099,11 @@ func resourceVSphereVirtualMachineCreate(d *schema.ResourceData, meta interface{
 				if v, ok := disk["controller_type"].(string); ok && v != "" {
 					newDisk.controller = v
 				}
+				newDisk.busNumber = int32(disk["controller_bus_number"].(int))
+				newDisk.unitNumber = int32(disk["unit_number"].(int))
+				newDisk.backing = expandDiskBackingOptions(disk)
+				newDisk.rdmLUN = disk["rdm_lun"].(string)
+				newDisk.rdmMode = disk["rdm_compatibility_mode"].(string)
 
 				if vVmdk, ok := disk["vmdk"].(string); ok && vVmdk != "" {
 					if v, ok := disk["template"].(string); ok && v != "" {
@@ -984,8 +2124,29 @@ func resourceVSphereVirtualMachineCreate(d *schema.ResourceData, meta interface{
 					}
 					newDisk.vmdkPath = vVmdk
 				}
+
+				if vFCD, ok := disk["first_class_disk_id"].(string); ok && vFCD != "" {
+					if v, ok := disk["template"].(string); ok && v != "" {
+						return fmt.Errorf("Cannot specify a first class disk for a template")
+					}
+					if v, ok := disk["size"].(int); ok && v != 0 {
+						return fmt.Errorf("Cannot specify size of a first class disk")
+					}
+					if v, ok := disk["name"].(string); ok && v != "" {
+						return fmt.Errorf("Cannot specify name of a first class disk")
+					}
+					if v, ok := disk["vmdk"].(string); ok && v != "" {
+						return fmt.Errorf("Cannot specify both a vmdk and a first class disk")
+					}
+					newDisk.fcdID = vFCD
+				}
+				// First class disks and raw device mappings stay on their own
+				// datastore, so only the other disks place the virtual machine.
+				if newDisk.datastore != "" && newDisk.fcdID == "" && newDisk.rdmLUN == "" {
+					vm.datastore = newDisk.datastore
+				}
 				// Preserves order so bootable disk is first
-				if newDisk.bootable == true || disk["template"] != "" {
+				if newDisk.bootable == true || disk["template"] != "" || disk["content_library_item_id"] != "" || disk["ovf_file"] != "" {
 					disks = append([]hardDisk{newDisk}, disks...)
 				} else {
 					disks = append(disks, newDisk)
@@ -1015,7 +2176,36 @@ func resourceVSphereVirtualMachineCreate(d *schema.ResourceData, meta interface{
 		log.Printf("[DEBUG] cdrom init: %v", cdroms)
 	}
 
-	if err := vm.setupVirtualMachine(client); err != nil {
+	// Disk controllers that are not in the configuration are left as they are
+	// created by vSphere or inherited from the template.
+	if vL, ok := d.GetOk("scsi_controller"); ok {
+		for _, v := range vL.([]interface{}) {
+			c := v.(map[string]interface{})
+			vm.scsiControllers = append(vm.scsiControllers, scsiController{
+				controllerType: c["type"].(string),
+				busSharing:     c["bus_sharing"].(string),
+			})
+		}
+	}
+	vm.sataControllerCount = -1
+	if v, ok := d.GetOkExists("sata_controller_count"); ok {
+		vm.sataControllerCount = v.(int)
+	}
+	vm.nvmeControllerCount = -1
+	if v, ok := d.GetOkExists("nvme_controller_count"); ok {
+		vm.nvmeControllerCount = v.(int)
+	}
+
+	// Deployments from content library items go through the CIS REST API.
+	var clc *contentLibraryClient
+	if vm.contentLibraryItemID != "" {
+		clc, err = meta.(*VSphereClient).ContentLibraryClient()
+		if err != nil {
+			return err
+		}
+	}
+
+	if err := vm.setupVirtualMachine(client, clc); err != nil {
 		return err
 	}
 
@@ -1038,7 +2228,14 @@ func resourceVSphereVirtualMachineCreate(d *schema.ResourceData, meta interface{
 		}
 	}
 
-	if newProps.Runtime.PowerState == types.VirtualMachinePowerStatePoweredOn && d.Get("wait_for_guest_net").(bool) {
+	desiredPowerState := types.VirtualMachinePowerState(d.Get("power_state").(string))
+	if newProps.Runtime.PowerState != desiredPowerState {
+		if err := setVirtualMachinePowerState(newVM, newProps.Runtime.PowerState, desiredPowerState, d.Get("shutdown_wait_timeout").(int), d.Get("force_power_off").(bool)); err != nil {
+			return err
+		}
+	}
+
+	if desiredPowerState == types.VirtualMachinePowerStatePoweredOn && d.Get("wait_for_guest_net").(bool) {
 		// We also need to wait for the guest networking to ensure an accurate set
 		// of information can be read into state and reported to the provisioners.
 		log.Printf("[DEBUG] Waiting for routeable guest network access")
@@ -1091,20 +2288,42 @@ func resourceVSphereVirtualMachineRead(d *schema.ResourceData, meta interface{})
 		log.Printf("[DEBUG] Set the moid: %#v", mvm.Reference().Value)
 	}
 
+	// Disks are matched to the disks in state by their source. The size,
+	// datastore, controller address, and device key of a disk are always read
+	// from the virtual machine.
 	disks := make([]map[string]interface{}, 0)
-	templateDisk := make(map[string]interface{}, 1)
-	for _, device := range mvm.Config.Hardware.Device {
-		if vd, ok := device.(*types.VirtualDisk); ok {
-
-			virtualDevice := vd.GetVirtualDevice()
-
-			backingInfo := virtualDevice.Backing
+	datastoreNames := make(map[string]string)
+	var templateDisk map[string]interface{}
+	var rdmNames map[string]string
+	devices := object.VirtualDeviceList(mvm.Config.Hardware.Device)
+	prevDisks := d.Get("disk").(*schema.Set).List()
+	for _, device := range devices.SelectByType((*types.VirtualDisk)(nil)) {
+		vd := device.(*types.VirtualDisk)
+		var disk map[string]interface{}
+		var diskUuid string
+		switch backing := vd.Backing.(type) {
+		case *types.VirtualDiskRawDiskMappingVer1BackingInfo:
+			// Raw device mappings are matched by the canonical name of their LUN.
+			if rdmNames == nil {
+				rdmNames, err = virtualMachineRDMCanonicalNames(client, mvm.Runtime.Host)
+				if err != nil {
+					return err
+				}
+			}
+			diskUuid = backing.Uuid
+			for _, prev := range prevDisks {
+				prevDisk := prev.(map[string]interface{})
+				if prevDisk["rdm_lun"] != "" && prevDisk["rdm_lun"] == rdmNames[backing.LunUuid] {
+					disk = prevDisk
+					break
+				}
+			}
+		default:
 			var diskFullPath string
-			var diskUuid string
-			if v, ok := backingInfo.(*types.VirtualDiskFlatVer2BackingInfo); ok {
+			if v, ok := backing.(*types.VirtualDiskFlatVer2BackingInfo); ok {
 				diskFullPath = v.FileName
 				diskUuid = v.Uuid
-			} else if v, ok := backingInfo.(*types.VirtualDiskSparseVer2BackingInfo); ok {
+			} else if v, ok := backing.(*types.VirtualDiskSparseVer2BackingInfo); ok {
 				diskFullPath = v.FileName
 				diskUuid = v.Uuid
 			}
@@ -1122,37 +2341,46 @@ func resourceVSphereVirtualMachineRead(d *schema.ResourceData, meta interface{})
 			// Remove possible extension
 			diskName = strings.Split(diskName, ".")[0]
 
-			if prevDisks, ok := d.GetOk("disk"); ok {
-				if prevDiskSet, ok := prevDisks.(*schema.Set); ok {
-					for _, v := range prevDiskSet.List() {
-						prevDisk := v.(map[string]interface{})
-
-						// We're guaranteed only one template disk.  Passing value directly through since templates should be immutable
-						if prevDisk["template"] != "" {
-							if len(templateDisk) == 0 {
-								templateDisk = prevDisk
-								disks = append(disks, templateDisk)
-								break
-							}
-						}
-
-						// It is enforced that prevDisk["name"] should only be set in the case
-						// of creating a new disk for the user.
-						// size case:  name was set by user, compare parsed filename from mo.filename (without path or .vmdk extension) with name
-						// vmdk case:  compare prevDisk["vmdk"] and mo.Filename
-						if diskName == prevDisk["name"] || diskPath == prevDisk["vmdk"] {
-
-							prevDisk["key"] = virtualDevice.Key
-							prevDisk["uuid"] = diskUuid
-
-							disks = append(disks, prevDisk)
-							break
-						}
+			// It is enforced that prevDisk["name"] should only be set in the case
+			// of creating a new disk for the user.
+			// size case:  name was set by user, compare parsed filename from mo.filename (without path or .vmdk extension) with name
+			// vmdk case:  compare prevDisk["vmdk"] and mo.Filename
+			// first class disk case: compare prevDisk["first_class_disk_id"] and vDiskId
+			for _, prev := range prevDisks {
+				prevDisk := prev.(map[string]interface{})
+				if diskName == prevDisk["name"] || diskPath == prevDisk["vmdk"] || (vd.VDiskId != nil && vd.VDiskId.Id == prevDisk["first_class_disk_id"]) {
+					disk = prevDisk
+					break
+				}
+			}
+			// We're guaranteed only one template disk, which is the first disk
+			// that does not match any other disk.
+			if disk == nil && templateDisk == nil {
+				for _, prev := range prevDisks {
+					prevDisk := prev.(map[string]interface{})
+					if prevDisk["template"] != "" || prevDisk["content_library_item_id"] != "" || prevDisk["ovf_file"] != "" {
+						templateDisk = prevDisk
+						disk = prevDisk
+						break
 					}
 				}
 			}
-			log.Printf("[DEBUG] disks: %#v", disks)
 		}
+		if disk == nil {
+			continue
+		}
+		disk["key"] = vd.Key
+		disk["uuid"] = diskUuid
+		disk["size"] = virtualMachineDiskSizeGB(vd)
+		if diskType := virtualMachineDiskType(vd); diskType != "" {
+			disk["type"] = diskType
+		}
+		if disk["datastore"], err = virtualMachineDiskDatastoreName(collector, vd, datastoreNames); err != nil {
+			return err
+		}
+		disk["controller_bus_number"], disk["unit_number"] = virtualMachineDiskAddress(devices, vd)
+		disks = append(disks, disk)
+		log.Printf("[DEBUG] disks: %#v", disks)
 	}
 	err = d.Set("disk", disks)
 	if err != nil {
@@ -1161,15 +2389,39 @@ func resourceVSphereVirtualMachineRead(d *schema.ResourceData, meta interface{})
 
 	networkInterfaces := make([]map[string]interface{}, 0)
 
+	// Network interfaces that are already in state keep their position, so
+	// that interfaces replaced with a new adapter type do not move to the end
+	// of the list.
+	prevNICs := make(map[int32]map[string]interface{})
+	prevNICIndexes := make(map[int32]int)
+	for i, v := range d.Get("network_interface").([]interface{}) {
+		nic := v.(map[string]interface{})
+		prevNICs[int32(nic["key"].(int))] = nic
+		prevNICIndexes[int32(nic["key"].(int))] = i
+	}
 	deviceList := object.VirtualDeviceList(mvm.Config.Hardware.Device)
 	deviceList = deviceList.SelectByType((*types.VirtualEthernetCard)(nil))
+	sort.SliceStable(deviceList, func(i, j int) bool {
+		ii, iok := prevNICIndexes[deviceList[i].GetVirtualDevice().Key]
+		ji, jok := prevNICIndexes[deviceList[j].GetVirtualDevice().Key]
+		switch {
+		case iok && jok:
+			return ii < ji
+		default:
+			return iok && !jok
+		}
+	})
 	log.Printf("[DEBUG] Device list %+v", deviceList)
 	for _, device := range deviceList {
 		networkInterface := make(map[string]interface{})
-		if _, ok := device.(*types.VirtualE1000); ok {
-			networkInterface["adapter_type"] = "e1000"
-		} else {
-			networkInterface["adapter_type"] = "vmxnet3"
+		networkInterface["adapter_type"] = virtualEthernetCardType(device)
+		networkInterface["physical_function"] = ""
+		if card, ok := device.(*types.VirtualSriovEthernetCard); ok && card.SriovBacking != nil && card.SriovBacking.PhysicalFunctionBacking != nil {
+			networkInterface["physical_function"] = card.SriovBacking.PhysicalFunctionBacking.Id
+		}
+		networkInterface["preserve_mac_address"] = false
+		if prev, ok := prevNICs[device.GetVirtualDevice().Key]; ok {
+			networkInterface["preserve_mac_address"] = prev["preserve_mac_address"]
 		}
 		virtualDevice := device.GetVirtualDevice()
 		nic := device.(types.BaseVirtualEthernetCard)
@@ -1303,12 +2555,28 @@ func resourceVSphereVirtualMachineRead(d *schema.ResourceData, meta interface{})
 
 	d.Set("datacenter", dc)
 	d.Set("memory", mvm.Summary.Config.MemorySizeMB)
-	d.Set("memory_reservation", mvm.Summary.Config.MemoryReservation)
-	d.Set("cpu", mvm.Summary.Config.NumCpu)
+	if err := flattenVirtualMachineResourceAllocation(d, "cpu", mvm.Config.CpuAllocation); err != nil {
+		return err
+	}
+	if err := flattenVirtualMachineResourceAllocation(d, "memory", mvm.Config.MemoryAllocation); err != nil {
+		return err
+	}
+	if err := flattenVirtualMachineFirmwareAndBootOptions(d, mvm.Config); err != nil {
+		return err
+	}
+	if err := flattenVirtualMachineDiskControllers(d, object.VirtualDeviceList(mvm.Config.Hardware.Device)); err != nil {
+		return err
+	}
+	d.Set("memory_reservation_locked_to_max", mvm.Config.MemoryReservationLockedToMax != nil && *mvm.Config.MemoryReservationLockedToMax)
+	d.Set("vcpu", mvm.Summary.Config.NumCpu)
 	d.Set("datastore", rootDatastore)
 	d.Set("uuid", mvm.Summary.Config.Uuid)
 	d.Set("annotation", mvm.Summary.Config.Annotation)
 	d.Set("power_state", mvm.Runtime.PowerState)
+	d.Set("cpu_hot_add_enabled", mvm.Config.CpuHotAddEnabled != nil && *mvm.Config.CpuHotAddEnabled)
+	d.Set("cpu_hot_remove_enabled", mvm.Config.CpuHotRemoveEnabled != nil && *mvm.Config.CpuHotRemoveEnabled)
+	d.Set("memory_hot_add_enabled", mvm.Config.MemoryHotAddEnabled != nil && *mvm.Config.MemoryHotAddEnabled)
+	d.Set("reboot_required", false)
 
 	// Read tags if we have the ability to do so
 	if tagsClient, _ := meta.(*VSphereClient).TagsClient(); tagsClient != nil {
@@ -1320,6 +2588,256 @@ func resourceVSphereVirtualMachineRead(d *schema.ResourceData, meta interface{})
 	return nil
 }
 
+// resourceVSphereVirtualMachineImport imports a virtual machine by its UUID or
+// its full inventory path, such as "/dc1/vm/folder/vm1".
+//
+// Read only refreshes the disks of a virtual machine that it can match to
+// existing state, and does not read placement or CDROM devices at all, so all
+// of these are populated here. Disks are imported as attached vmdk disks, with
+// the first disk marked as bootable. Arguments that are only used during
+// creation are set to their defaults.
+func resourceVSphereVirtualMachineImport(d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
+	client := meta.(*VSphereClient).vimClient
+	id := d.Id()
+	var vm *object.VirtualMachine
+	var err error
+	switch {
+	case virtualMachineUUIDRegexp.MatchString(id):
+		vm, err = virtualMachineFromUUID(client, id)
+	case strings.HasPrefix(id, "/"):
+		vm, err = virtualMachineFromPath(client, id, nil)
+	default:
+		return nil, fmt.Errorf("invalid import ID %q: must be a virtual machine UUID or a full inventory path starting with a slash", id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error locating virtual machine %q: %s", id, err)
+	}
+	props, err := virtualMachineProperties(vm)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching virtual machine properties: %s", err)
+	}
+	if props.Config == nil {
+		return nil, fmt.Errorf("virtual machine %q has no configuration", vm.InventoryPath)
+	}
+	if props.Config.Template {
+		return nil, fmt.Errorf("%q is a template and cannot be imported", vm.InventoryPath)
+	}
+
+	dcPath, err := rootPathParticleVM.SplitDatacenter(vm.InventoryPath)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing datacenter from inventory path: %s", err)
+	}
+	folder, err := rootPathParticleVM.SplitRelativeFolder(vm.InventoryPath)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing folder from inventory path: %s", err)
+	}
+	if folder == "." {
+		folder = ""
+	}
+	d.Set("name", props.Name)
+	d.Set("datacenter", strings.TrimPrefix(dcPath, "/"))
+	d.Set("folder", folder)
+	if err := importVirtualMachinePlacement(client, d, props); err != nil {
+		return nil, err
+	}
+
+	devices := object.VirtualDeviceList(props.Config.Hardware.Device)
+	var rdmNames map[string]string
+	for _, device := range devices.SelectByType((*types.VirtualDisk)(nil)) {
+		if _, ok := device.GetVirtualDevice().Backing.(*types.VirtualDiskRawDiskMappingVer1BackingInfo); ok {
+			if rdmNames, err = virtualMachineRDMCanonicalNames(client, props.Runtime.Host); err != nil {
+				return nil, err
+			}
+			break
+		}
+	}
+	disks, err := importVirtualMachineDisks(devices, rdmNames)
+	if err != nil {
+		return nil, err
+	}
+	if err := d.Set("disk", disks); err != nil {
+		return nil, fmt.Errorf("error setting disks: %s", err)
+	}
+	if err := d.Set("cdrom", importVirtualMachineCdroms(devices)); err != nil {
+		return nil, fmt.Errorf("error setting cdroms: %s", err)
+	}
+
+	enableDiskUUID := false
+	if props.Config.Flags.DiskUuidEnabled != nil {
+		enableDiskUUID = *props.Config.Flags.DiskUuidEnabled
+	}
+	d.Set("enable_disk_uuid", enableDiskUUID)
+	d.Set("linked_clone", false)
+	d.Set("skip_customization", false)
+	d.Set("domain", "vsphere.local")
+	d.Set("time_zone", "Etc/UTC")
+	d.Set("wait_for_customization_timeout", 10)
+	d.Set("wait_for_guest_net", true)
+	d.Set("detach_unknown_disks_on_delete", false)
+	d.Set("shutdown_wait_timeout", 3)
+	d.Set("enter_bios_setup", false)
+	d.Set("force_power_off", true)
+
+	d.SetId(vmPath(folder, props.Name))
+	return []*schema.ResourceData{d}, nil
+}
+
+// importVirtualMachinePlacement sets the cluster and resource pool of an
+// imported virtual machine. The resource pool is only set if the virtual
+// machine is not in the root resource pool of its host or cluster.
+func importVirtualMachinePlacement(client *govmomi.Client, d *schema.ResourceData, props *mo.VirtualMachine) error {
+	if props.ResourcePool == nil {
+		// Templates and some inaccessible virtual machines do not have a resource
+		// pool.
+		return nil
+	}
+	finder := find.NewFinder(client.Client, false)
+	ctx, cancel := context.WithTimeout(context.Background(), defaultAPITimeout)
+	defer cancel()
+	ref, err := finder.ObjectReference(ctx, *props.ResourcePool)
+	if err != nil {
+		return fmt.Errorf("error locating resource pool: %s", err)
+	}
+	pool := ref.(*object.ResourcePool)
+	var poolProps mo.ResourcePool
+	if err := pool.Properties(ctx, pool.Reference(), []string{"parent", "owner"}, &poolProps); err != nil {
+		return fmt.Errorf("error fetching resource pool properties: %s", err)
+	}
+	if poolProps.Owner.Type == "ClusterComputeResource" {
+		var cluster mo.ClusterComputeResource
+		if err := pool.Properties(ctx, poolProps.Owner, []string{"name"}, &cluster); err != nil {
+			return fmt.Errorf("error fetching cluster properties: %s", err)
+		}
+		d.Set("cluster", cluster.Name)
+	}
+	if poolProps.Parent != nil && poolProps.Parent.Type == "ResourcePool" {
+		rp, err := rootPathParticleHost.SplitRelative(pool.InventoryPath)
+		if err != nil {
+			return fmt.Errorf("error parsing resource pool path: %s", err)
+		}
+		d.Set("resource_pool", rp)
+	}
+	return nil
+}
+
+// importVirtualMachineDisks returns the disk set for an imported virtual
+// machine. Every disk is imported as a vmdk, or by its ID if it is a first
+// class disk, and the first disk is marked as bootable. Raw device mappings are
+// imported by the canonical name of their LUN, looked up in rdmNames.
+func importVirtualMachineDisks(devices object.VirtualDeviceList, rdmNames map[string]string) ([]interface{}, error) {
+	var disks []interface{}
+	for i, device := range devices.SelectByType((*types.VirtualDisk)(nil)) {
+		disk := device.(*types.VirtualDisk)
+		controllerType := "scsi"
+		if controller := devices.FindByKey(disk.ControllerKey); controller != nil {
+			controllerType = diskControllerType(controller)
+		}
+		var fileName string
+		switch backing := disk.Backing.(type) {
+		case *types.VirtualDiskRawDiskMappingVer1BackingInfo:
+			lun, ok := rdmNames[backing.LunUuid]
+			if !ok {
+				return nil, fmt.Errorf("could not find the LUN of raw device mapping %q", devices.Name(disk))
+			}
+			var dp object.DatastorePath
+			dp.FromString(backing.FileName)
+			sharing := string(types.VirtualDiskSharingSharingNone)
+			if backing.Sharing != "" {
+				sharing = backing.Sharing
+			}
+			bus, unit := virtualMachineDiskAddress(devices, disk)
+			disks = append(disks, map[string]interface{}{
+				"rdm_lun":                lun,
+				"rdm_compatibility_mode": backing.CompatibilityMode,
+				"datastore":              dp.Datastore,
+				"type":                   "",
+				"controller_type":        controllerType,
+				"controller_bus_number":  bus,
+				"unit_number":            unit,
+				"bootable":               i == 0,
+				"keep_on_remove":         false,
+				"attach":                 false,
+				"disk_mode":              backing.DiskMode,
+				"write_through":          false,
+				"sharing":                sharing,
+			})
+			continue
+		case *types.VirtualDiskFlatVer2BackingInfo:
+			fileName = backing.FileName
+		case *types.VirtualDiskSparseVer2BackingInfo:
+			fileName = backing.FileName
+		default:
+			return nil, fmt.Errorf("disk %q has an unsupported backing type %T", devices.Name(disk), disk.Backing)
+		}
+		var dp object.DatastorePath
+		if ok := dp.FromString(fileName); !ok {
+			return nil, fmt.Errorf("could not parse disk path %q", fileName)
+		}
+		diskMode := string(types.VirtualDiskModePersistent)
+		var writeThrough bool
+		sharing := string(types.VirtualDiskSharingSharingNone)
+		if backing, ok := disk.Backing.(*types.VirtualDiskFlatVer2BackingInfo); ok {
+			diskMode = backing.DiskMode
+			writeThrough = backing.WriteThrough != nil && *backing.WriteThrough
+			if backing.Sharing != "" {
+				sharing = backing.Sharing
+			}
+		}
+		var iops int
+		if alloc := disk.StorageIOAllocation; alloc != nil && alloc.Limit != nil && *alloc.Limit > 0 {
+			iops = int(*alloc.Limit)
+		}
+		// First class disks are imported by their ID, so that they are detached,
+		// and not deleted, with the virtual machine.
+		vmdk := dp.Path
+		var fcdID string
+		if disk.VDiskId != nil && disk.VDiskId.Id != "" {
+			vmdk = ""
+			fcdID = disk.VDiskId.Id
+		}
+		bus, unit := virtualMachineDiskAddress(devices, disk)
+		disks = append(disks, map[string]interface{}{
+			"vmdk":                  vmdk,
+			"first_class_disk_id":   fcdID,
+			"datastore":             dp.Datastore,
+			"type":                  virtualMachineDiskType(disk),
+			"iops":                  iops,
+			"controller_type":       controllerType,
+			"controller_bus_number": bus,
+			"unit_number":           unit,
+			"bootable":              i == 0,
+			"keep_on_remove":        false,
+			"attach":                false,
+			"disk_mode":             diskMode,
+			"write_through":         writeThrough,
+			"sharing":               sharing,
+		})
+	}
+	return disks, nil
+}
+
+// importVirtualMachineCdroms returns the cdrom list for an imported virtual
+// machine. Only CDROM devices backed by an ISO file on a datastore are
+// returned.
+func importVirtualMachineCdroms(devices object.VirtualDeviceList) []interface{} {
+	var cdroms []interface{}
+	for _, device := range devices.SelectByType((*types.VirtualCdrom)(nil)) {
+		backing, ok := device.GetVirtualDevice().Backing.(*types.VirtualCdromIsoBackingInfo)
+		if !ok {
+			continue
+		}
+		var dp object.DatastorePath
+		if ok := dp.FromString(backing.FileName); !ok {
+			continue
+		}
+		cdroms = append(cdroms, map[string]interface{}{
+			"datastore": dp.Datastore,
+			"path":      dp.Path,
+		})
+	}
+	return cdroms
+}
+
 func resourceVSphereVirtualMachineDelete(d *schema.ResourceData, meta interface{}) error {
 	client := meta.(*VSphereClient).vimClient
 	dc, err := getDatacenter(client, d.Get("datacenter").(string))
@@ -1346,13 +2864,7 @@ func resourceVSphereVirtualMachineDelete(d *schema.ResourceData, meta interface{
 	}
 
 	if state == types.VirtualMachinePowerStatePoweredOn {
-		task, err := vm.PowerOff(context.TODO())
-		if err != nil {
-			return err
-		}
-
-		err = task.Wait(context.TODO())
-		if err != nil {
+		if err := shutdownVirtualMachine(vm, d.Get("shutdown_wait_timeout").(int), d.Get("force_power_off").(bool)); err != nil {
 			return err
 		}
 	}
@@ -1365,7 +2877,17 @@ func resourceVSphereVirtualMachineDelete(d *schema.ResourceData, meta interface{
 			for _, value := range diskSetList {
 				disk := value.(map[string]interface{})
 
-				if v, ok := disk["keep_on_remove"].(bool); ok && v == true {
+				// First class disks must survive the deletion of the VM
+				if id, ok := disk["first_class_disk_id"].(string); ok && id != "" {
+					log.Printf("[DEBUG] detaching first class disk %v", id)
+					if err := detachFirstClassDisk(vm, id); err != nil {
+						log.Printf("[ERROR] Delete - Error detaching first class disk: %v", err)
+						return err
+					}
+					continue
+				}
+
+				if v, ok := disk["keep_on_remove"].(bool); (ok && v == true) || disk["attach"].(bool) {
 					log.Printf("[DEBUG] not destroying %v", disk["name"])
 					virtualDisk := devices.FindByKey(int32(disk["key"].(int)))
 					err = vm.RemoveDevice(context.TODO(), true, virtualDisk)
@@ -1422,14 +2944,204 @@ func resourceVSphereVirtualMachineDelete(d *schema.ResourceData, meta interface{
 	return nil
 }
 
-// addHardDisk adds a new Hard Disk to the VirtualMachine.
-func addHardDisk(vm *object.VirtualMachine, size, iops int64, diskType string, datastore *object.Datastore, diskPath string, controller_type string) error {
-	devices, err := vm.Device(context.TODO())
+// addHardDisk adds a new Hard Disk to the VirtualMachine. The disk is attached
+// to the controller of the supplied type on busNumber, at unitNumber. Negative
+// bus and unit numbers pick the first controller of the type with a free slot,
+// and the first free unit on it. The disk mode and sharing of the disk are
+// taken from options.
+func addHardDisk(vm *object.VirtualMachine, size, iops int64, diskType string, datastore *object.Datastore, diskPath string, controller_type string, busNumber, unitNumber int32, options diskBackingOptions) error {
+	devices, controller, err := diskControllerForType(vm, controller_type, busNumber)
 	if err != nil {
 		return err
 	}
+
+	log.Printf("[DEBUG] disk controller: %#v\n", controller)
+
+	// TODO Check if diskPath & datastore exist
+	// If diskPath is not specified, pass empty string to CreateDisk()
+	if diskPath == "" {
+		return fmt.Errorf("[ERROR] addHardDisk - No path proided")
+	} else {
+		diskPath = datastore.Path(diskPath)
+	}
+	log.Printf("[DEBUG] addHardDisk - diskPath: %v", diskPath)
+	disk := devices.CreateDisk(controller, datastore.Reference(), diskPath)
+
+	unit, err := getDiskUnitNumber(devices, controller, unitNumber)
+	if err != nil {
+		return err
+	}
+	*disk.UnitNumber = unit
+
+	existing := devices.SelectByBackingInfo(disk.Backing)
+	log.Printf("[DEBUG] disk: %#v\n", disk)
+
+	if len(existing) == 0 {
+		disk.CapacityInKB = int64(size * 1024 * 1024)
+		if iops != 0 {
+			disk.StorageIOAllocation = &types.StorageIOAllocationInfo{
+				Limit: &iops,
+			}
+		}
+		backing := disk.Backing.(*types.VirtualDiskFlatVer2BackingInfo)
+
+		if diskType == "eager_zeroed" {
+			// eager zeroed thick virtual disk
+			backing.ThinProvisioned = types.NewBool(false)
+			backing.EagerlyScrub = types.NewBool(true)
+		} else if diskType == "lazy" {
+			// lazy zeroed thick virtual disk
+			backing.ThinProvisioned = types.NewBool(false)
+			backing.EagerlyScrub = types.NewBool(false)
+		} else if diskType == "thin" {
+			// thin provisioned virtual disk
+			backing.ThinProvisioned = types.NewBool(true)
+		}
+		backing.DiskMode = options.diskMode
+		backing.WriteThrough = types.NewBool(options.writeThrough)
+		backing.Sharing = options.sharing
+
+		log.Printf("[DEBUG] addHardDisk: %#v\n", disk)
+		log.Printf("[DEBUG] addHardDisk capacity: %#v\n", disk.CapacityInKB)
+
+		return vm.AddDevice(context.TODO(), disk)
+	} else {
+		log.Printf("[DEBUG] addHardDisk: Disk already present.\n")
+
+		return nil
+	}
+}
+
+// addFirstClassDisk attaches the First Class Disk with the supplied ID, which
+// resides on the supplied datastore, to the VirtualMachine.
+func addFirstClassDisk(vm *object.VirtualMachine, id string, datastore *object.Datastore, controller_type string, busNumber, unitNumber int32) error {
+	devices, controller, err := diskControllerForType(vm, controller_type, busNumber)
+	if err != nil {
+		return err
+	}
+
+	for _, device := range devices.SelectByType((*types.VirtualDisk)(nil)) {
+		if vd := device.(*types.VirtualDisk); vd.VDiskId != nil && vd.VDiskId.Id == id {
+			log.Printf("[DEBUG] addFirstClassDisk: Disk %s already attached.", id)
+			return nil
+		}
+	}
+
+	unit, err := getDiskUnitNumber(devices, controller, unitNumber)
+	if err != nil {
+		return err
+	}
+
+	log.Printf("[DEBUG] addFirstClassDisk: attaching %s to controller %d", id, controller.GetVirtualController().Key)
+	return attachFirstClassDisk(vm, id, datastore, controller.GetVirtualController().Key, &unit)
+}
+
+// addRDMDisk attaches the LUN with the supplied canonical name to the
+// VirtualMachine as a raw device mapping, in the supplied compatibility mode,
+// which defaults to physical mode. The LUN must be visible to the host the
+// VirtualMachine runs on. The mapping file is created in the folder of the
+// VirtualMachine on the supplied datastore.
+func addRDMDisk(client *govmomi.Client, vm *object.VirtualMachine, lunName, compatibilityMode string, datastore *object.Datastore, controller_type string, busNumber, unitNumber int32, options diskBackingOptions) error {
+	host, err := vm.HostSystem(context.TODO())
+	if err != nil {
+		return fmt.Errorf("error fetching host of virtual machine: %s", err)
+	}
+	ss, err := hostStorageSystemFromHostSystemID(client, host.Reference().Value)
+	if err != nil {
+		return fmt.Errorf("error loading host storage system: %s", err)
+	}
+	lun, err := hostScsiDiskFromCanonicalName(ss, lunName)
+	if err != nil {
+		return err
+	}
+
+	devices, controller, err := diskControllerForType(vm, controller_type, busNumber)
+	if err != nil {
+		return err
+	}
+	for _, device := range devices.SelectByType((*types.VirtualDisk)(nil)) {
+		if b, ok := device.GetVirtualDevice().Backing.(*types.VirtualDiskRawDiskMappingVer1BackingInfo); ok && b.LunUuid == lun.Uuid {
+			log.Printf("[DEBUG] addRDMDisk: LUN %s already attached.", lunName)
+			return nil
+		}
+	}
+
+	if compatibilityMode == "" {
+		compatibilityMode = string(types.VirtualDiskCompatibilityModePhysicalMode)
+	}
+	dsRef := datastore.Reference()
+	disk := &types.VirtualDisk{
+		VirtualDevice: types.VirtualDevice{
+			Key: devices.NewKey(),
+			Backing: &types.VirtualDiskRawDiskMappingVer1BackingInfo{
+				VirtualDeviceFileBackingInfo: types.VirtualDeviceFileBackingInfo{
+					FileName:  datastore.Path(""),
+					Datastore: &dsRef,
+				},
+				LunUuid:           lun.Uuid,
+				DeviceName:        lun.DeviceName,
+				CompatibilityMode: compatibilityMode,
+				DiskMode:          options.diskMode,
+				Sharing:           options.sharing,
+			},
+		},
+		CapacityInKB: lun.Capacity.Block * int64(lun.Capacity.BlockSize) / 1024,
+	}
+	devices.AssignController(disk, controller)
+	unit, err := getDiskUnitNumber(devices, controller, unitNumber)
+	if err != nil {
+		return err
+	}
+	*disk.UnitNumber = unit
+
+	log.Printf("[DEBUG] addRDMDisk: %#v", disk)
+	return vm.AddDevice(context.TODO(), disk)
+}
+
+// virtualMachineRDMCanonicalNames returns the canonical names of the LUNs of
+// the SCSI disks on the supplied host, keyed by the UUID of the LUN, which is
+// what raw device mappings refer to them by.
+func virtualMachineRDMCanonicalNames(client *govmomi.Client, host *types.ManagedObjectReference) (map[string]string, error) {
+	names := make(map[string]string)
+	if host == nil {
+		return names, nil
+	}
+	ss, err := hostStorageSystemFromHostSystemID(client, host.Value)
+	if err != nil {
+		return nil, fmt.Errorf("error loading host storage system: %s", err)
+	}
+	disks, err := hostScsiDisks(ss)
+	if err != nil {
+		return nil, err
+	}
+	for _, disk := range disks {
+		names[disk.Uuid] = disk.CanonicalName
+	}
+	return names, nil
+}
+
+// diskControllerForType returns the device list of the VirtualMachine, along
+// with a controller of the supplied type to attach a new disk to. If busNumber
+// is not negative, the controller of the type on that bus is returned, and it
+// must exist. Otherwise a new controller is created if none with a free slot
+// can be found.
+func diskControllerForType(vm *object.VirtualMachine, controller_type string, busNumber int32) (object.VirtualDeviceList, types.BaseVirtualController, error) {
+	devices, err := vm.Device(context.TODO())
+	if err != nil {
+		return nil, nil, err
+	}
 	log.Printf("[DEBUG] vm devices: %#v\n", devices)
 
+	if busNumber >= 0 {
+		for _, device := range devices {
+			c, ok := device.(types.BaseVirtualController)
+			if ok && diskControllerIsType(device, controller_type) && c.GetVirtualController().BusNumber == busNumber {
+				return devices, c, nil
+			}
+		}
+		return nil, nil, fmt.Errorf("no %s controller found on bus %d", controller_type, busNumber)
+	}
+
 	var controller types.BaseVirtualController
 	switch controller_type {
 	case "scsi":
@@ -1444,15 +3156,19 @@ func addHardDisk(vm *object.VirtualMachine, size, iops int64, diskType string, d
 		controller = devices.PickController(&types.VirtualLsiLogicSASController{})
 	case "ide":
 		controller, err = devices.FindDiskController(controller_type)
+	case "sata":
+		controller = devices.PickController(&types.VirtualAHCIController{})
+	case "nvme":
+		controller = devices.PickController(&types.VirtualNVMEController{})
 	default:
-		return fmt.Errorf("[ERROR] Unsupported disk controller provided: %v", controller_type)
+		return nil, nil, fmt.Errorf("[ERROR] Unsupported disk controller provided: %v", controller_type)
 	}
 
 	if err != nil || controller == nil {
 		// Check if max number of scsi controller are already used
 		diskControllers := getSCSIControllers(devices)
-		if len(diskControllers) >= 4 {
-			return fmt.Errorf("[ERROR] Maximum number of SCSI controllers created")
+		if strings.HasPrefix(controller_type, "scsi") && len(diskControllers) >= 4 {
+			return nil, nil, fmt.Errorf("[ERROR] Maximum number of SCSI controllers created")
 		}
 
 		log.Printf("[DEBUG] Couldn't find a %v controller.  Creating one..", controller_type)
@@ -1463,109 +3179,68 @@ func addHardDisk(vm *object.VirtualMachine, size, iops int64, diskType string, d
 			// Create scsi controller
 			c, err = devices.CreateSCSIController("scsi")
 			if err != nil {
-				return fmt.Errorf("[ERROR] Failed creating SCSI controller: %v", err)
+				return nil, nil, fmt.Errorf("[ERROR] Failed creating SCSI controller: %v", err)
 			}
 		case "scsi-lsi-parallel":
 			// Create scsi controller
 			c, err = devices.CreateSCSIController("lsilogic")
 			if err != nil {
-				return fmt.Errorf("[ERROR] Failed creating SCSI controller: %v", err)
+				return nil, nil, fmt.Errorf("[ERROR] Failed creating SCSI controller: %v", err)
 			}
 		case "scsi-buslogic":
 			// Create scsi controller
 			c, err = devices.CreateSCSIController("buslogic")
 			if err != nil {
-				return fmt.Errorf("[ERROR] Failed creating SCSI controller: %v", err)
+				return nil, nil, fmt.Errorf("[ERROR] Failed creating SCSI controller: %v", err)
 			}
 		case "scsi-paravirtual":
 			// Create scsi controller
 			c, err = devices.CreateSCSIController("pvscsi")
 			if err != nil {
-				return fmt.Errorf("[ERROR] Failed creating SCSI controller: %v", err)
+				return nil, nil, fmt.Errorf("[ERROR] Failed creating SCSI controller: %v", err)
 			}
 		case "scsi-lsi-sas":
 			// Create scsi controller
 			c, err = devices.CreateSCSIController("lsilogic-sas")
 			if err != nil {
-				return fmt.Errorf("[ERROR] Failed creating SCSI controller: %v", err)
+				return nil, nil, fmt.Errorf("[ERROR] Failed creating SCSI controller: %v", err)
 			}
 		case "ide":
 			// Create ide controller
 			c, err = devices.CreateIDEController()
 			if err != nil {
-				return fmt.Errorf("[ERROR] Failed creating IDE controller: %v", err)
+				return nil, nil, fmt.Errorf("[ERROR] Failed creating IDE controller: %v", err)
+			}
+		case "sata":
+			// Create sata controller
+			c, err = newVirtualAHCIController(devices)
+			if err != nil {
+				return nil, nil, fmt.Errorf("[ERROR] Failed creating SATA controller: %v", err)
+			}
+		case "nvme":
+			// Create nvme controller
+			c, err = newVirtualNVMEController(devices)
+			if err != nil {
+				return nil, nil, fmt.Errorf("[ERROR] Failed creating NVMe controller: %v", err)
 			}
 		default:
-			return fmt.Errorf("[ERROR] Unsupported disk controller provided: %v", controller_type)
+			return nil, nil, fmt.Errorf("[ERROR] Unsupported disk controller provided: %v", controller_type)
 		}
 
 		vm.AddDevice(context.TODO(), c)
 		// Update our devices list
 		devices, err := vm.Device(context.TODO())
 		if err != nil {
-			return err
+			return nil, nil, err
 		}
 		controller = devices.PickController(c.(types.BaseVirtualController))
 		if controller == nil {
 			log.Printf("[ERROR] Could not find the new %v controller", controller_type)
-			return fmt.Errorf("Could not find the new %v controller", controller_type)
-		}
-	}
-
-	log.Printf("[DEBUG] disk controller: %#v\n", controller)
-
-	// TODO Check if diskPath & datastore exist
-	// If diskPath is not specified, pass empty string to CreateDisk()
-	if diskPath == "" {
-		return fmt.Errorf("[ERROR] addHardDisk - No path proided")
-	} else {
-		diskPath = datastore.Path(diskPath)
-	}
-	log.Printf("[DEBUG] addHardDisk - diskPath: %v", diskPath)
-	disk := devices.CreateDisk(controller, datastore.Reference(), diskPath)
-
-	if strings.Contains(controller_type, "scsi") {
-		unitNumber, err := getNextUnitNumber(devices, controller)
-		if err != nil {
-			return err
+			return nil, nil, fmt.Errorf("Could not find the new %v controller", controller_type)
 		}
-		*disk.UnitNumber = unitNumber
 	}
 
-	existing := devices.SelectByBackingInfo(disk.Backing)
-	log.Printf("[DEBUG] disk: %#v\n", disk)
-
-	if len(existing) == 0 {
-		disk.CapacityInKB = int64(size * 1024 * 1024)
-		if iops != 0 {
-			disk.StorageIOAllocation = &types.StorageIOAllocationInfo{
-				Limit: &iops,
-			}
-		}
-		backing := disk.Backing.(*types.VirtualDiskFlatVer2BackingInfo)
-
-		if diskType == "eager_zeroed" {
-			// eager zeroed thick virtual disk
-			backing.ThinProvisioned = types.NewBool(false)
-			backing.EagerlyScrub = types.NewBool(true)
-		} else if diskType == "lazy" {
-			// lazy zeroed thick virtual disk
-			backing.ThinProvisioned = types.NewBool(false)
-			backing.EagerlyScrub = types.NewBool(false)
-		} else if diskType == "thin" {
-			// thin provisioned virtual disk
-			backing.ThinProvisioned = types.NewBool(true)
-		}
-
-		log.Printf("[DEBUG] addHardDisk: %#v\n", disk)
-		log.Printf("[DEBUG] addHardDisk capacity: %#v\n", disk.CapacityInKB)
-
-		return vm.AddDevice(context.TODO(), disk)
-	} else {
-		log.Printf("[DEBUG] addHardDisk: Disk already present.\n")
-
-		return nil
-	}
+	return devices, controller, nil
 }
 
 func getSCSIControllers(vmDevices object.VirtualDeviceList) []*types.VirtualController {
@@ -1583,27 +3258,522 @@ func getSCSIControllers(vmDevices object.VirtualDeviceList) []*types.VirtualCont
 	return scsiControllers
 }
 
+// getDiskUnitNumber returns unitNumber if that unit is free on the supplied
+// controller, or the first free unit on the controller if unitNumber is
+// negative.
+func getDiskUnitNumber(devices object.VirtualDeviceList, c types.BaseVirtualController, unitNumber int32) (int32, error) {
+	if unitNumber < 0 {
+		return getNextUnitNumber(devices, c)
+	}
+	taken := diskControllerUnits(devices, c)
+	if int(unitNumber) >= len(taken) {
+		return -1, fmt.Errorf("unit number %d is out of range for a %s controller", unitNumber, devices.Type(c.(types.BaseVirtualDevice)))
+	}
+	if taken[unitNumber] {
+		return -1, fmt.Errorf("unit %d on %s is already in use", unitNumber, devices.Name(c.(types.BaseVirtualDevice)))
+	}
+	return unitNumber, nil
+}
+
 func getNextUnitNumber(devices object.VirtualDeviceList, c types.BaseVirtualController) (int32, error) {
-	key := c.GetVirtualController().Key
+	for i, taken := range diskControllerUnits(devices, c) {
+		if !taken {
+			return int32(i), nil
+		}
+	}
+	return -1, fmt.Errorf("[ERROR] getNextUnitNumber - controller is full")
+}
 
-	var unitNumbers [16]bool
-	unitNumbers[7] = true
+// diskControllerUnits returns the units of the supplied controller, and
+// whether they are taken. The unit of a SCSI controller itself is always
+// taken.
+func diskControllerUnits(devices object.VirtualDeviceList, c types.BaseVirtualController) []bool {
+	var units []bool
+	switch sc := c.(type) {
+	case types.BaseVirtualSCSIController:
+		units = make([]bool, 16)
+		units[sc.GetVirtualSCSIController().ScsiCtlrUnitNumber] = true
+	case types.BaseVirtualSATAController:
+		units = make([]bool, 30)
+	case *types.VirtualNVMEController:
+		units = make([]bool, 15)
+	default:
+		units = make([]bool, 2)
+	}
 
+	key := c.GetVirtualController().Key
 	for _, device := range devices {
 		d := device.GetVirtualDevice()
+		if d.ControllerKey == key && d.UnitNumber != nil && int(*d.UnitNumber) < len(units) {
+			units[*d.UnitNumber] = true
+		}
+	}
+	return units
+}
+
+// virtualMachineDiskSizeGB returns the capacity of a disk in GB, rounded up,
+// so that a disk that is not a whole number of GB can not be shrunk below its
+// capacity.
+func virtualMachineDiskSizeGB(disk *types.VirtualDisk) int {
+	return int((disk.CapacityInKB + 1024*1024 - 1) / (1024 * 1024))
+}
+
+// virtualMachineDiskType returns the provisioning type of a disk, or an empty
+// string if the backing of the disk does not have one.
+func virtualMachineDiskType(disk *types.VirtualDisk) string {
+	backing, ok := disk.Backing.(*types.VirtualDiskFlatVer2BackingInfo)
+	if !ok {
+		return ""
+	}
+	switch {
+	case backing.ThinProvisioned != nil && *backing.ThinProvisioned:
+		return "thin"
+	case backing.EagerlyScrub != nil && *backing.EagerlyScrub:
+		return "eager_zeroed"
+	}
+	return "lazy"
+}
+
+// virtualMachineDiskAddress returns the bus number of the controller of a
+// disk and the unit number of the disk on that controller, or -1 for either if
+// it is not known.
+func virtualMachineDiskAddress(devices object.VirtualDeviceList, disk *types.VirtualDisk) (int, int) {
+	bus, unit := -1, -1
+	if controller, ok := devices.FindByKey(disk.ControllerKey).(types.BaseVirtualController); ok {
+		bus = int(controller.GetVirtualController().BusNumber)
+	}
+	if disk.UnitNumber != nil {
+		unit = int(*disk.UnitNumber)
+	}
+	return bus, unit
+}
+
+// growHardDisk extends the disk with the supplied device key to size GB.
+func growHardDisk(vm *object.VirtualMachine, key int32, size int64) error {
+	devices, err := vm.Device(context.TODO())
+	if err != nil {
+		return fmt.Errorf("error getting virtual machine devices: %s", err)
+	}
+	disk, ok := devices.FindByKey(key).(*types.VirtualDisk)
+	if !ok {
+		return fmt.Errorf("could not find disk with key %d", key)
+	}
+	capacity := size * 1024 * 1024
+	if disk.CapacityInKB > capacity {
+		return fmt.Errorf("disk %q is larger than %d GB, disks can only be grown", devices.Name(disk), size)
+	}
+	if disk.CapacityInKB == capacity {
+		return nil
+	}
+	disk.CapacityInKB = capacity
+	disk.CapacityInBytes = capacity * 1024
+	if err := vm.EditDevice(context.TODO(), disk); err != nil {
+		return fmt.Errorf("error growing disk %q: %s", devices.Name(disk), err)
+	}
+	return nil
+}
+
+// updateHardDisk applies the IOPS limit and the mode, write-through, and
+// sharing settings of the supplied options to the disk with the supplied
+// device key. An iops of 0 removes the IOPS limit.
+func updateHardDisk(vm *object.VirtualMachine, key int32, iops int64, options diskBackingOptions) error {
+	devices, err := vm.Device(context.TODO())
+	if err != nil {
+		return fmt.Errorf("error getting virtual machine devices: %s", err)
+	}
+	disk, ok := devices.FindByKey(key).(*types.VirtualDisk)
+	if !ok {
+		return fmt.Errorf("could not find disk with key %d", key)
+	}
+	limit := int64(-1)
+	if iops != 0 {
+		limit = iops
+	}
+	if disk.StorageIOAllocation == nil {
+		disk.StorageIOAllocation = &types.StorageIOAllocationInfo{}
+	}
+	disk.StorageIOAllocation.Limit = &limit
+	switch backing := disk.Backing.(type) {
+	case *types.VirtualDiskFlatVer2BackingInfo:
+		backing.DiskMode = options.diskMode
+		backing.WriteThrough = types.NewBool(options.writeThrough)
+		backing.Sharing = options.sharing
+	case *types.VirtualDiskSparseVer2BackingInfo:
+		backing.DiskMode = options.diskMode
+		backing.WriteThrough = types.NewBool(options.writeThrough)
+	case *types.VirtualDiskRawDiskMappingVer1BackingInfo:
+		backing.DiskMode = options.diskMode
+		backing.Sharing = options.sharing
+	}
+	if err := vm.EditDevice(context.TODO(), disk); err != nil {
+		return fmt.Errorf("error updating disk %q: %s", devices.Name(disk), err)
+	}
+	return nil
+}
+
+// virtualMachineDiskHashKeys are the attributes that identify a disk in the
+// disk set: its source, which is the file, first class disk, LUN, template, or
+// package it comes from. At most one of them is set on a disk. All other attributes
+// are left out, so that changes to them are applied to the existing disk, or
+// rejected at plan time, instead of replacing the disk.
+var virtualMachineDiskHashKeys = []string{
+	"template",
+	"content_library_item_id",
+	"ovf_file",
+	"name",
+	"vmdk",
+	"first_class_disk_id",
+	"rdm_lun",
+}
+
+// virtualMachineDiskImmutableKeys are the disk attributes that cannot be
+// changed on an existing disk.
+var virtualMachineDiskImmutableKeys = []string{
+	"type",
+	"attach",
+	"rdm_compatibility_mode",
+	"controller_type",
+	"controller_bus_number",
+	"unit_number",
+}
+
+// virtualMachineDiskBackingKeys are the disk attributes that are applied by
+// editing the backing of an existing disk, which can only be done while the
+// virtual machine is powered off.
+var virtualMachineDiskBackingKeys = []string{
+	"disk_mode",
+	"write_through",
+	"sharing",
+}
+
+// resourceVSphereVirtualMachineDiskHash is the hash function of the disk set.
+func resourceVSphereVirtualMachineDiskHash(v interface{}) int {
+	m := v.(map[string]interface{})
+	var buf bytes.Buffer
+	for _, k := range virtualMachineDiskHashKeys {
+		buf.WriteString(fmt.Sprintf("%v;", m[k]))
+	}
+	return hashcode.String(buf.String())
+}
+
+// suppressVirtualMachineDiskUnset returns a DiffSuppressFunc for disk
+// attributes that are read from the virtual machine, which suppresses the
+// difference when the attribute is left at the supplied unset value in
+// configuration for a disk that already exists.
+func suppressVirtualMachineDiskUnset(unset string) schema.SchemaDiffSuppressFunc {
+	return func(k, old, new string, d *schema.ResourceData) bool {
+		return old != "" && (new == unset || new == "")
+	}
+}
+
+// suppressVirtualMachineDiskDatastore suppresses the diff on the datastore of
+// a disk that is read from the virtual machine when it is not set in
+// configuration, or when it is set to the inventory path of the same
+// datastore.
+func suppressVirtualMachineDiskDatastore(k, old, new string, d *schema.ResourceData) bool {
+	if old == "" {
+		return false
+	}
+	return new == "" || new == old || strings.HasSuffix(new, "/"+old)
+}
+
+// virtualMachineDiskDatastoreName returns the name of the datastore that backs
+// the supplied disk, looking it up through the supplied property collector.
+// Names are cached in names by datastore reference.
+func virtualMachineDiskDatastoreName(collector *property.Collector, vd *types.VirtualDisk, names map[string]string) (string, error) {
+	backing, ok := vd.Backing.(types.BaseVirtualDeviceFileBackingInfo)
+	if !ok || backing.GetVirtualDeviceFileBackingInfo().Datastore == nil {
+		return "", nil
+	}
+	ref := *backing.GetVirtualDeviceFileBackingInfo().Datastore
+	if name, ok := names[ref.Value]; ok {
+		return name, nil
+	}
+	var md mo.Datastore
+	if err := collector.RetrieveOne(context.TODO(), ref, []string{"name"}, &md); err != nil {
+		return "", fmt.Errorf("error fetching datastore of disk %d: %s", vd.Key, err)
+	}
+	names[ref.Value] = md.Name
+	return md.Name, nil
+}
+
+// virtualMachineDisksByHash returns the disks in the supplied disk set by
+// their hash.
+func virtualMachineDisksByHash(disks *schema.Set) map[int]map[string]interface{} {
+	m := make(map[int]map[string]interface{})
+	for _, v := range disks.List() {
+		disk := v.(map[string]interface{})
+		m[resourceVSphereVirtualMachineDiskHash(disk)] = disk
+	}
+	return m
+}
+
+// virtualMachineDiskSource returns the source of a disk in the disk set, as
+// the first of virtualMachineDiskHashKeys that is set, for use in messages.
+func virtualMachineDiskSource(disk map[string]interface{}) string {
+	for _, k := range virtualMachineDiskHashKeys {
+		if v, ok := disk[k].(string); ok && v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// expandDiskBackingOptions returns the mode and sharing settings of a disk in
+// the disk set.
+func expandDiskBackingOptions(disk map[string]interface{}) diskBackingOptions {
+	return diskBackingOptions{
+		diskMode:     disk["disk_mode"].(string),
+		writeThrough: disk["write_through"].(bool),
+		sharing:      disk["sharing"].(string),
+	}
+}
+
+// diskControllerIsType returns true if the supplied device is a disk
+// controller of the supplied controller_type.
+func diskControllerIsType(device types.BaseVirtualDevice, controllerType string) bool {
+	var ok bool
+	switch controllerType {
+	case "scsi":
+		_, ok = device.(types.BaseVirtualSCSIController)
+	case "scsi-lsi-parallel":
+		_, ok = device.(*types.VirtualLsiLogicController)
+	case "scsi-buslogic":
+		_, ok = device.(*types.VirtualBusLogicController)
+	case "scsi-paravirtual":
+		_, ok = device.(*types.ParaVirtualSCSIController)
+	case "scsi-lsi-sas":
+		_, ok = device.(*types.VirtualLsiLogicSASController)
+	case "ide":
+		_, ok = device.(*types.VirtualIDEController)
+	case "sata":
+		_, ok = device.(types.BaseVirtualSATAController)
+	case "nvme":
+		_, ok = device.(*types.VirtualNVMEController)
+	}
+	return ok
+}
 
-		if d.ControllerKey == key {
-			if d.UnitNumber != nil {
-				unitNumbers[*d.UnitNumber] = true
+// diskControllerType returns the controller_type of a disk attached to the
+// supplied controller.
+func diskControllerType(controller types.BaseVirtualDevice) string {
+	switch controller.(type) {
+	case *types.VirtualIDEController:
+		return "ide"
+	case types.BaseVirtualSATAController:
+		return "sata"
+	case *types.VirtualNVMEController:
+		return "nvme"
+	}
+	return "scsi"
+}
+
+// newVirtualAHCIController returns a new SATA controller on the first free
+// bus.
+func newVirtualAHCIController(devices object.VirtualDeviceList) (types.BaseVirtualDevice, error) {
+	bus, err := freeDiskControllerBusNumber(devices, (*types.VirtualSATAController)(nil))
+	if err != nil {
+		return nil, err
+	}
+	c := &types.VirtualAHCIController{}
+	c.BusNumber = bus
+	c.Key = devices.NewKey()
+	return c, nil
+}
+
+// newVirtualNVMEController returns a new NVMe controller on the first free
+// bus.
+func newVirtualNVMEController(devices object.VirtualDeviceList) (types.BaseVirtualDevice, error) {
+	bus, err := freeDiskControllerBusNumber(devices, (*types.VirtualNVMEController)(nil))
+	if err != nil {
+		return nil, err
+	}
+	c := &types.VirtualNVMEController{}
+	c.BusNumber = bus
+	c.Key = devices.NewKey()
+	return c, nil
+}
+
+// freeDiskControllerBusNumber returns the first bus number that is not in use
+// by a controller of the supplied kind. Each kind of disk controller has 4
+// buses.
+func freeDiskControllerBusNumber(devices object.VirtualDeviceList, kind types.BaseVirtualDevice) (int32, error) {
+	used := make(map[int32]bool)
+	for _, device := range devices.SelectByType(kind) {
+		used[device.(types.BaseVirtualController).GetVirtualController().BusNumber] = true
+	}
+	for bus := int32(0); bus < 4; bus++ {
+		if !used[bus] {
+			return bus, nil
+		}
+	}
+	return -1, fmt.Errorf("maximum number of %s controllers reached", devices.Type(kind))
+}
+
+// configureVirtualMachineDiskControllers brings the disk controllers of a
+// virtual machine in line with the supplied configuration. SCSI controllers
+// are matched to the supplied list by bus number, and SATA and NVMe
+// controllers are added or removed until there are as many as requested.
+// Controllers are only removed if no devices are attached to them. A nil SCSI
+// list or a negative count leaves the respective controllers as they are.
+func configureVirtualMachineDiskControllers(vm *object.VirtualMachine, scsi []scsiController, sataCount, nvmeCount int) error {
+	devices, err := vm.Device(context.TODO())
+	if err != nil {
+		return fmt.Errorf("error fetching devices: %s", err)
+	}
+	var spec []types.BaseVirtualDeviceConfigSpec
+	if scsi != nil {
+		var changes []types.BaseVirtualDeviceConfigSpec
+		devices, changes, err = scsiControllerDeviceChanges(devices, scsi)
+		if err != nil {
+			return err
+		}
+		spec = append(spec, changes...)
+	}
+	if sataCount >= 0 {
+		var changes []types.BaseVirtualDeviceConfigSpec
+		devices, changes, err = diskControllerCountDeviceChanges(devices, (*types.VirtualSATAController)(nil), sataCount, newVirtualAHCIController)
+		if err != nil {
+			return err
+		}
+		spec = append(spec, changes...)
+	}
+	if nvmeCount >= 0 {
+		var changes []types.BaseVirtualDeviceConfigSpec
+		devices, changes, err = diskControllerCountDeviceChanges(devices, (*types.VirtualNVMEController)(nil), nvmeCount, newVirtualNVMEController)
+		if err != nil {
+			return err
+		}
+		spec = append(spec, changes...)
+	}
+	if len(spec) == 0 {
+		return nil
+	}
+
+	log.Printf("[DEBUG] Configuring disk controllers on virtual machine %q", vm.InventoryPath)
+	task, err := vm.Reconfigure(context.TODO(), types.VirtualMachineConfigSpec{DeviceChange: spec})
+	if err != nil {
+		return fmt.Errorf("error configuring disk controllers: %s", err)
+	}
+	if err := task.Wait(context.TODO()); err != nil {
+		return fmt.Errorf("error configuring disk controllers: %s", err)
+	}
+	return nil
+}
+
+// scsiControllerDeviceChanges returns the device changes needed to bring the
+// SCSI controllers in the supplied device list in line with the supplied
+// configuration, along with the device list including any new controllers.
+func scsiControllerDeviceChanges(devices object.VirtualDeviceList, scsi []scsiController) (object.VirtualDeviceList, []types.BaseVirtualDeviceConfigSpec, error) {
+	var spec []types.BaseVirtualDeviceConfigSpec
+	existing := make(map[int32]types.BaseVirtualSCSIController)
+	for _, device := range devices.SelectByType((*types.VirtualSCSIController)(nil)) {
+		c := device.(types.BaseVirtualSCSIController)
+		existing[c.GetVirtualSCSIController().BusNumber] = c
+	}
+
+	for i, sc := range scsi {
+		bus := int32(i)
+		c, ok := existing[bus]
+		if !ok {
+			device, err := devices.CreateSCSIController(sc.controllerType)
+			if err != nil {
+				return nil, nil, fmt.Errorf("error creating SCSI controller: %s", err)
 			}
+			vc := device.(types.BaseVirtualSCSIController).GetVirtualSCSIController()
+			vc.BusNumber = bus
+			vc.SharedBus = types.VirtualSCSISharing(sc.busSharing)
+			devices = append(devices, device)
+			spec = append(spec, &types.VirtualDeviceConfigSpec{
+				Operation: types.VirtualDeviceConfigSpecOperationAdd,
+				Device:    device,
+			})
+			continue
 		}
+		if t := devices.Type(c.(types.BaseVirtualDevice)); t != sc.controllerType {
+			return nil, nil, fmt.Errorf("SCSI controller on bus %d is of type %q and cannot be changed to %q", bus, t, sc.controllerType)
+		}
+		if vc := c.GetVirtualSCSIController(); string(vc.SharedBus) != sc.busSharing {
+			vc.SharedBus = types.VirtualSCSISharing(sc.busSharing)
+			spec = append(spec, &types.VirtualDeviceConfigSpec{
+				Operation: types.VirtualDeviceConfigSpecOperationEdit,
+				Device:    c.(types.BaseVirtualDevice),
+			})
+		}
+	}
+
+	for bus, c := range existing {
+		if int(bus) < len(scsi) {
+			continue
+		}
+		if len(c.GetVirtualSCSIController().Device) > 0 {
+			return nil, nil, fmt.Errorf("cannot remove SCSI controller on bus %d, as devices are attached to it", bus)
+		}
+		spec = append(spec, &types.VirtualDeviceConfigSpec{
+			Operation: types.VirtualDeviceConfigSpecOperationRemove,
+			Device:    c.(types.BaseVirtualDevice),
+		})
+	}
+	return devices, spec, nil
+}
+
+// diskControllerCountDeviceChanges returns the device changes needed to have
+// count controllers of the supplied kind, along with the device list including
+// any new controllers. New controllers are created with newController, and
+// controllers are removed from the highest bus number down.
+func diskControllerCountDeviceChanges(devices object.VirtualDeviceList, kind types.BaseVirtualDevice, count int, newController func(object.VirtualDeviceList) (types.BaseVirtualDevice, error)) (object.VirtualDeviceList, []types.BaseVirtualDeviceConfigSpec, error) {
+	var spec []types.BaseVirtualDeviceConfigSpec
+	existing := devices.SelectByType(kind)
+	for n := len(existing); n < count; n++ {
+		device, err := newController(devices)
+		if err != nil {
+			return nil, nil, err
+		}
+		devices = append(devices, device)
+		spec = append(spec, &types.VirtualDeviceConfigSpec{
+			Operation: types.VirtualDeviceConfigSpecOperationAdd,
+			Device:    device,
+		})
 	}
-	for i, taken := range unitNumbers {
-		if !taken {
-			return int32(i), nil
+	sort.Slice(existing, func(i, j int) bool {
+		return existing[i].(types.BaseVirtualController).GetVirtualController().BusNumber > existing[j].(types.BaseVirtualController).GetVirtualController().BusNumber
+	})
+	for n := 0; n < len(existing)-count; n++ {
+		c := existing[n].(types.BaseVirtualController).GetVirtualController()
+		if len(c.Device) > 0 {
+			return nil, nil, fmt.Errorf("cannot remove %s controller on bus %d, as devices are attached to it", devices.Type(kind), c.BusNumber)
 		}
+		spec = append(spec, &types.VirtualDeviceConfigSpec{
+			Operation: types.VirtualDeviceConfigSpecOperationRemove,
+			Device:    existing[n],
+		})
 	}
-	return -1, fmt.Errorf("[ERROR] getNextUnitNumber - controller is full")
+	return devices, spec, nil
+}
+
+// flattenVirtualMachineDiskControllers sets the SCSI controllers, ordered by
+// bus number, and the number of SATA and NVMe controllers of a virtual
+// machine.
+func flattenVirtualMachineDiskControllers(d *schema.ResourceData, devices object.VirtualDeviceList) error {
+	scsi := devices.SelectByType((*types.VirtualSCSIController)(nil))
+	sort.Slice(scsi, func(i, j int) bool {
+		return scsi[i].(types.BaseVirtualSCSIController).GetVirtualSCSIController().BusNumber < scsi[j].(types.BaseVirtualSCSIController).GetVirtualSCSIController().BusNumber
+	})
+	var controllers []interface{}
+	for _, device := range scsi {
+		c := device.(types.BaseVirtualSCSIController).GetVirtualSCSIController()
+		controllers = append(controllers, map[string]interface{}{
+			"key":         int(c.Key),
+			"type":        devices.Type(device),
+			"bus_sharing": string(c.SharedBus),
+		})
+	}
+	if err := d.Set("scsi_controller", controllers); err != nil {
+		return fmt.Errorf("error setting SCSI controllers: %s", err)
+	}
+	d.Set("sata_controller_count", len(devices.SelectByType((*types.VirtualSATAController)(nil))))
+	d.Set("nvme_controller_count", len(devices.SelectByType((*types.VirtualNVMEController)(nil))))
+	return nil
 }
 
 // addCdrom adds a new virtual cdrom drive to the VirtualMachine and attaches an image (ISO) to it from a datastore path.
@@ -1663,7 +3833,7 @@ func addCdrom(client *govmomi.Client, vm *object.VirtualMachine, datacenter *obj
 }
 
 // buildNetworkDevice builds VirtualDeviceConfigSpec for Network Device.
-func buildNetworkDevice(f *find.Finder, label, adapterType string, macAddress string) (*types.VirtualDeviceConfigSpec, error) {
+func buildNetworkDevice(f *find.Finder, label, adapterType, physicalFunction, macAddress string) (*types.VirtualDeviceConfigSpec, error) {
 	network, err := f.Network(context.TODO(), label)
 	if err != nil {
 		return nil, err
@@ -1674,80 +3844,297 @@ func buildNetworkDevice(f *find.Finder, label, adapterType string, macAddress st
 		return nil, err
 	}
 
-	var address_type string
-	if macAddress == "" {
-		address_type = string(types.VirtualEthernetCardMacTypeGenerated)
-	} else {
-		address_type = string(types.VirtualEthernetCardMacTypeManual)
+	device, err := newVirtualEthernetCard(adapterType, physicalFunction, backing, macAddress)
+	if err != nil {
+		return nil, err
 	}
+	return &types.VirtualDeviceConfigSpec{
+		Operation: types.VirtualDeviceConfigSpecOperationAdd,
+		Device:    device,
+	}, nil
+}
 
-	if adapterType == "vmxnet3" {
-		return &types.VirtualDeviceConfigSpec{
-			Operation: types.VirtualDeviceConfigSpecOperationAdd,
-			Device: &types.VirtualVmxnet3{
-				VirtualVmxnet: types.VirtualVmxnet{
-					VirtualEthernetCard: types.VirtualEthernetCard{
-						VirtualDevice: types.VirtualDevice{
-							Key:     -1,
-							Backing: backing,
-						},
-						AddressType: address_type,
-						MacAddress:  macAddress,
-					},
+// newVirtualEthernetCard returns a new network adapter of the supplied type,
+// with the supplied backing. The adapter gets a generated MAC address if
+// macAddress is empty. physicalFunction is the host PCI ID of the physical
+// function that backs an sriov adapter, and is ignored for other types.
+func newVirtualEthernetCard(adapterType, physicalFunction string, backing types.BaseVirtualDeviceBackingInfo, macAddress string) (types.BaseVirtualDevice, error) {
+	card := types.VirtualEthernetCard{
+		VirtualDevice: types.VirtualDevice{
+			Key:     -1,
+			Backing: backing,
+		},
+		AddressType: string(types.VirtualEthernetCardMacTypeGenerated),
+	}
+	if macAddress != "" {
+		card.AddressType = string(types.VirtualEthernetCardMacTypeManual)
+		card.MacAddress = macAddress
+	}
+
+	switch adapterType {
+	case "vmxnet3":
+		return &types.VirtualVmxnet3{VirtualVmxnet: types.VirtualVmxnet{VirtualEthernetCard: card}}, nil
+	case "vmxnet2":
+		return &types.VirtualVmxnet2{VirtualVmxnet: types.VirtualVmxnet{VirtualEthernetCard: card}}, nil
+	case "e1000":
+		return &types.VirtualE1000{VirtualEthernetCard: card}, nil
+	case "e1000e":
+		return &types.VirtualE1000e{VirtualEthernetCard: card}, nil
+	case "pcnet32":
+		return &types.VirtualPCNet32{VirtualEthernetCard: card}, nil
+	case "sriov":
+		if physicalFunction == "" {
+			return nil, fmt.Errorf("sriov network adapters require a physical function")
+		}
+		return &types.VirtualSriovEthernetCard{
+			VirtualEthernetCard: card,
+			SriovBacking: &types.VirtualSriovEthernetCardSriovBackingInfo{
+				PhysicalFunctionBacking: &types.VirtualPCIPassthroughDeviceBackingInfo{
+					Id: physicalFunction,
 				},
 			},
 		}, nil
-	} else if adapterType == "e1000" {
-		return &types.VirtualDeviceConfigSpec{
-			Operation: types.VirtualDeviceConfigSpecOperationAdd,
-			Device: &types.VirtualE1000{
-				VirtualEthernetCard: types.VirtualEthernetCard{
-					VirtualDevice: types.VirtualDevice{
-						Key:     -1,
-						Backing: backing,
-					},
-					AddressType: address_type,
-					MacAddress:  macAddress,
+	}
+	return nil, fmt.Errorf("Invalid network adapter type %q", adapterType)
+}
+
+// virtualEthernetCardType returns the adapter type of a network adapter, as
+// used in the adapter_type attribute.
+func virtualEthernetCardType(device types.BaseVirtualDevice) string {
+	switch device.(type) {
+	case *types.VirtualVmxnet3:
+		return "vmxnet3"
+	case *types.VirtualVmxnet2:
+		return "vmxnet2"
+	case *types.VirtualE1000:
+		return "e1000"
+	case *types.VirtualE1000e:
+		return "e1000e"
+	case *types.VirtualPCNet32:
+		return "pcnet32"
+	case *types.VirtualSriovEthernetCard:
+		return "sriov"
+	}
+	return "unknown"
+}
+
+// updateVirtualMachineNetworkInterfaces applies changes to the network,
+// adapter type, and MAC address of the network interfaces of a virtual
+// machine. Network and MAC address changes are made by editing the existing
+// adapter. Adapter type changes replace the adapter, keeping its MAC address
+// if preserve_mac_address is set. The keys of replaced adapters are updated
+// in the network_interface list, so that Read keeps them in place.
+func updateVirtualMachineNetworkInterfaces(d *schema.ResourceData, finder *find.Finder, vm *object.VirtualMachine) error {
+	nics := d.Get("network_interface").([]interface{})
+	for i, v := range nics {
+		nic := v.(map[string]interface{})
+		prefix := fmt.Sprintf("network_interface.%d.", i)
+		if !d.HasChange(prefix+"label") && !d.HasChange(prefix+"adapter_type") && !d.HasChange(prefix+"physical_function") && !d.HasChange(prefix+"mac_address") {
+			continue
+		}
+		devices, err := vm.Device(context.TODO())
+		if err != nil {
+			return fmt.Errorf("error fetching devices: %s", err)
+		}
+		device := devices.FindByKey(int32(nic["key"].(int)))
+		if device == nil {
+			return fmt.Errorf("could not find network interface %d with key %d", i, nic["key"].(int))
+		}
+		card, ok := device.(types.BaseVirtualEthernetCard)
+		if !ok {
+			return fmt.Errorf("device with key %d is not a network interface", nic["key"].(int))
+		}
+		network, err := finder.Network(context.TODO(), nic["label"].(string))
+		if err != nil {
+			return fmt.Errorf("error locating network %q: %s", nic["label"].(string), err)
+		}
+		backing, err := network.EthernetCardBackingInfo(context.TODO())
+		if err != nil {
+			return fmt.Errorf("error building backing for network %q: %s", nic["label"].(string), err)
+		}
+		var macAddress string
+		if d.HasChange(prefix + "mac_address") {
+			macAddress = nic["mac_address"].(string)
+		}
+
+		if !d.HasChange(prefix+"adapter_type") && !d.HasChange(prefix+"physical_function") {
+			log.Printf("[DEBUG] Editing network interface %d on virtual machine %q", i, vm.InventoryPath)
+			c := card.GetVirtualEthernetCard()
+			c.Backing = backing
+			if macAddress != "" {
+				c.AddressType = string(types.VirtualEthernetCardMacTypeManual)
+				c.MacAddress = macAddress
+			}
+			if err := vm.EditDevice(context.TODO(), device); err != nil {
+				return fmt.Errorf("error editing network interface %d: %s", i, err)
+			}
+			continue
+		}
+
+		if macAddress == "" && nic["preserve_mac_address"].(bool) {
+			macAddress = card.GetVirtualEthernetCard().MacAddress
+		}
+		newDevice, err := newVirtualEthernetCard(nic["adapter_type"].(string), nic["physical_function"].(string), backing, macAddress)
+		if err != nil {
+			return err
+		}
+		log.Printf("[DEBUG] Replacing network interface %d on virtual machine %q with a %s adapter", i, vm.InventoryPath, nic["adapter_type"].(string))
+		spec := types.VirtualMachineConfigSpec{
+			DeviceChange: []types.BaseVirtualDeviceConfigSpec{
+				&types.VirtualDeviceConfigSpec{
+					Operation: types.VirtualDeviceConfigSpecOperationRemove,
+					Device:    device,
+				},
+				&types.VirtualDeviceConfigSpec{
+					Operation: types.VirtualDeviceConfigSpecOperationAdd,
+					Device:    newDevice,
 				},
 			},
-		}, nil
-	} else {
-		return nil, fmt.Errorf("Invalid network adapter type.")
+		}
+		task, err := vm.Reconfigure(context.TODO(), spec)
+		if err != nil {
+			return fmt.Errorf("error replacing network interface %d: %s", i, err)
+		}
+		if err := task.Wait(context.TODO()); err != nil {
+			return fmt.Errorf("error replacing network interface %d: %s", i, err)
+		}
+
+		// The new adapter is the only network interface that was not there
+		// before.
+		newDevices, err := vm.Device(context.TODO())
+		if err != nil {
+			return fmt.Errorf("error fetching devices: %s", err)
+		}
+		for _, nd := range newDevices.SelectByType((*types.VirtualEthernetCard)(nil)) {
+			if devices.FindByKey(nd.GetVirtualDevice().Key) == nil {
+				nic["key"] = int(nd.GetVirtualDevice().Key)
+				break
+			}
+		}
+	}
+	if err := d.Set("network_interface", nics); err != nil {
+		return fmt.Errorf("error setting network interfaces: %s", err)
 	}
+	return nil
 }
 
-// buildVMRelocateSpec builds VirtualMachineRelocateSpec to set a place for a new VirtualMachine.
-func buildVMRelocateSpec(rp *object.ResourcePool, ds *object.Datastore, vm *object.VirtualMachine, linkedClone bool, initType string) (types.VirtualMachineRelocateSpec, error) {
-	var key int32
-	var moveType string
-	if linkedClone {
-		moveType = "createNewChildDiskBacking"
-	} else {
-		moveType = "moveAllDiskBackingsAndDisallowSharing"
+// virtualMachineResourcePool returns the resource pool a virtual machine is
+// placed in. resourcePool takes precedence over cluster, and the root
+// resource pool of host is used if neither is set.
+func virtualMachineResourcePool(finder *find.Finder, cluster, resourcePool string, host *object.HostSystem) (*object.ResourcePool, error) {
+	switch {
+	case resourcePool != "":
+		return finder.ResourcePool(context.TODO(), resourcePool)
+	case cluster != "":
+		return finder.ResourcePool(context.TODO(), "*"+cluster+"/Resources")
+	case host != nil:
+		return host.ResourcePool(context.TODO())
+	}
+	return finder.DefaultResourcePool(context.TODO())
+}
+
+// expandVirtualMachineRelocation returns where a virtual machine needs to be
+// moved to after a change to cluster, resource_pool, host, or the datastore
+// of a disk. The datastore of the virtual machine itself follows the disk
+// that comes from a template or content library item, or the bootable disk.
+// ok is false if the virtual machine does not need to be moved.
+func expandVirtualMachineRelocation(d *schema.ResourceData, finder *find.Finder) (loc vmRelocation, ok bool, err error) {
+	if d.HasChange("cluster") || d.HasChange("resource_pool") || d.HasChange("host") {
+		if name := d.Get("host").(string); name != "" {
+			if loc.host, err = finder.HostSystem(context.TODO(), name); err != nil {
+				return loc, false, fmt.Errorf("error finding host %q: %s", name, err)
+			}
+		}
+		if loc.pool, err = virtualMachineResourcePool(finder, d.Get("cluster").(string), d.Get("resource_pool").(string), loc.host); err != nil {
+			return loc, false, fmt.Errorf("error finding resource pool: %s", err)
+		}
+		ok = true
+	}
+
+	if d.HasChange("disk") {
+		o, n := d.GetChange("disk")
+		oldDisks := virtualMachineDisksByHash(o.(*schema.Set))
+		for _, v := range n.(*schema.Set).List() {
+			disk := v.(map[string]interface{})
+			oldDisk, found := oldDisks[resourceVSphereVirtualMachineDiskHash(disk)]
+			if !found || disk["datastore"] == "" || disk["datastore"] == oldDisk["datastore"] {
+				continue
+			}
+			ds, err := finder.Datastore(context.TODO(), disk["datastore"].(string))
+			if err != nil {
+				return loc, false, fmt.Errorf("error finding datastore %q: %s", disk["datastore"], err)
+			}
+			if loc.disks == nil {
+				loc.disks = make(map[int32]*object.Datastore)
+			}
+			loc.disks[int32(oldDisk["key"].(int))] = ds
+			if disk["template"] != "" || disk["content_library_item_id"] != "" || disk["ovf_file"] != "" || disk["bootable"].(bool) {
+				loc.datastore = ds
+			}
+			ok = true
+		}
+	}
+	return loc, ok, nil
+}
+
+// vmRelocation describes where buildVMRelocateSpec places a virtual machine.
+// Fields that are not set are left out of the relocate spec, so that only the
+// compute resources, only the storage, or both are moved.
+type vmRelocation struct {
+	pool      *object.ResourcePool
+	host      *object.HostSystem
+	datastore *object.Datastore
+	// disks are the datastores of disks that are moved on their own, by device
+	// key.
+	disks map[int32]*object.Datastore
+}
+
+// buildVMRelocateSpec builds VirtualMachineRelocateSpec to set a place for a
+// new VirtualMachine cloned from vm, or to migrate vm. initType is only set
+// when cloning, and sets the backing of the disk of the clone. When
+// migrating, every disk gets a locator if the datastore of the virtual
+// machine changes, so that disks that are not in loc.disks stay where they
+// are.
+func buildVMRelocateSpec(loc vmRelocation, vm *object.VirtualMachine, linkedClone bool, initType string) (types.VirtualMachineRelocateSpec, error) {
+	var spec types.VirtualMachineRelocateSpec
+	if loc.pool != nil {
+		rpr := loc.pool.Reference()
+		spec.Pool = &rpr
+	}
+	if loc.host != nil {
+		hr := loc.host.Reference()
+		spec.Host = &hr
+	}
+	if loc.datastore != nil {
+		dsr := loc.datastore.Reference()
+		spec.Datastore = &dsr
 	}
-	log.Printf("[DEBUG] relocate type: [%s]", moveType)
 
 	devices, err := vm.Device(context.TODO())
 	if err != nil {
 		return types.VirtualMachineRelocateSpec{}, err
 	}
-	for _, d := range devices {
-		if devices.Type(d) == "disk" {
-			key = int32(d.GetVirtualDevice().Key)
+
+	if initType != "" {
+		var key int32
+		if linkedClone {
+			spec.DiskMoveType = "createNewChildDiskBacking"
+		} else {
+			spec.DiskMoveType = "moveAllDiskBackingsAndDisallowSharing"
 		}
-	}
+		log.Printf("[DEBUG] relocate type: [%s]", spec.DiskMoveType)
 
-	isThin := initType == "thin"
-	eagerScrub := initType == "eager_zeroed"
-	rpr := rp.Reference()
-	dsr := ds.Reference()
-	return types.VirtualMachineRelocateSpec{
-		Datastore:    &dsr,
-		Pool:         &rpr,
-		DiskMoveType: moveType,
-		Disk: []types.VirtualMachineRelocateSpecDiskLocator{
+		for _, d := range devices {
+			if devices.Type(d) == "disk" {
+				key = int32(d.GetVirtualDevice().Key)
+			}
+		}
+
+		isThin := initType == "thin"
+		eagerScrub := initType == "eager_zeroed"
+		spec.Disk = []types.VirtualMachineRelocateSpecDiskLocator{
 			{
-				Datastore: dsr,
+				Datastore: *spec.Datastore,
 				DiskBackingInfo: &types.VirtualDiskFlatVer2BackingInfo{
 					DiskMode:        "persistent",
 					ThinProvisioned: types.NewBool(isThin),
@@ -1755,8 +4142,32 @@ func buildVMRelocateSpec(rp *object.ResourcePool, ds *object.Datastore, vm *obje
 				},
 				DiskId: key,
 			},
-		},
-	}, nil
+		}
+		return spec, nil
+	}
+
+	for _, device := range devices.SelectByType((*types.VirtualDisk)(nil)) {
+		disk := device.GetVirtualDevice()
+		if ds, ok := loc.disks[disk.Key]; ok {
+			spec.Disk = append(spec.Disk, types.VirtualMachineRelocateSpecDiskLocator{
+				DiskId:    disk.Key,
+				Datastore: ds.Reference(),
+			})
+			continue
+		}
+		if loc.datastore == nil {
+			continue
+		}
+		backing, ok := disk.Backing.(types.BaseVirtualDeviceFileBackingInfo)
+		if !ok || backing.GetVirtualDeviceFileBackingInfo().Datastore == nil {
+			continue
+		}
+		spec.Disk = append(spec.Disk, types.VirtualMachineRelocateSpecDiskLocator{
+			DiskId:    disk.Key,
+			Datastore: *backing.GetVirtualDeviceFileBackingInfo().Datastore,
+		})
+	}
+	return spec, nil
 }
 
 // getDatastoreObject gets datastore object.
@@ -1878,7 +4289,7 @@ func createCdroms(client *govmomi.Client, vm *object.VirtualMachine, datacenter
 	return nil
 }
 
-func (vm *virtualMachine) setupVirtualMachine(c *govmomi.Client) error {
+func (vm *virtualMachine) setupVirtualMachine(c *govmomi.Client, clc *contentLibraryClient) error {
 	var cw *virtualMachineCustomizationWaiter
 	dc, err := getDatacenter(c, vm.datacenter)
 
@@ -1904,25 +4315,18 @@ func (vm *virtualMachine) setupVirtualMachine(c *govmomi.Client) error {
 		}
 	}
 
-	var resourcePool *object.ResourcePool
-	if vm.resourcePool == "" {
-		if vm.cluster == "" {
-			resourcePool, err = finder.DefaultResourcePool(context.TODO())
-			if err != nil {
-				return err
-			}
-		} else {
-			resourcePool, err = finder.ResourcePool(context.TODO(), "*"+vm.cluster+"/Resources")
-			if err != nil {
-				return err
-			}
-		}
-	} else {
-		resourcePool, err = finder.ResourcePool(context.TODO(), vm.resourcePool)
+	var host *object.HostSystem
+	if vm.host != "" {
+		host, err = finder.HostSystem(context.TODO(), vm.host)
 		if err != nil {
 			return err
 		}
 	}
+
+	resourcePool, err := virtualMachineResourcePool(finder, vm.cluster, vm.resourcePool, host)
+	if err != nil {
+		return err
+	}
 	log.Printf("[DEBUG] resource pool: %#v", resourcePool)
 
 	dcFolders, err := dc.Folders(context.TODO())
@@ -1947,19 +4351,25 @@ func (vm *virtualMachine) setupVirtualMachine(c *govmomi.Client) error {
 
 	// make config spec
 	configSpec := types.VirtualMachineConfigSpec{
-		Name:              vm.name,
-		NumCPUs:           vm.vcpu,
-		NumCoresPerSocket: 1,
-		MemoryMB:          vm.memoryMb,
-		MemoryAllocation: &types.ResourceAllocationInfo{
-			Reservation: &vm.memoryAllocation.reservation,
-		},
+		Name:                         vm.name,
+		NumCPUs:                      vm.vcpu,
+		NumCoresPerSocket:            1,
+		MemoryMB:                     vm.memoryMb,
+		CpuAllocation:                vm.cpuAllocation,
+		MemoryAllocation:             vm.memoryAllocation,
+		MemoryReservationLockedToMax: &vm.memoryReservationLocked,
 		Flags: &types.VirtualMachineFlagInfo{
 			DiskUuidEnabled: &vm.enableDiskUUID,
 		},
-		Annotation: vm.annotation,
+		CpuHotAddEnabled:    &vm.cpuHotAddEnabled,
+		CpuHotRemoveEnabled: &vm.cpuHotRemoveEnabled,
+		MemoryHotAddEnabled: &vm.memoryHotAddEnabled,
+		Firmware:            vm.firmware,
+		NestedHVEnabled:     vm.nestedHVEnabled,
+		BootOptions:         vm.bootOptions,
+		Annotation:          vm.annotation,
 	}
-	if vm.template == "" {
+	if !vm.hasTemplateDisk() {
 		configSpec.GuestId = "otherLinux64Guest"
 	}
 	log.Printf("[DEBUG] virtual machine config spec: %v", configSpec)
@@ -1998,6 +4408,12 @@ func (vm *virtualMachine) setupVirtualMachine(c *govmomi.Client) error {
 			}
 
 			if d.Type == "StoragePod" {
+				if vm.contentLibraryItemID != "" {
+					return fmt.Errorf("datastore clusters are not supported when deploying from a content library item")
+				}
+				if vm.ovfFile != "" {
+					return fmt.Errorf("datastore clusters are not supported when deploying from an OVF file")
+				}
 				sp := object.StoragePod{
 					Folder: object.NewFolder(c.Client, d),
 				}
@@ -2026,14 +4442,14 @@ func (vm *virtualMachine) setupVirtualMachine(c *govmomi.Client) error {
 	networkConfigs := []types.CustomizationAdapterMapping{}
 	for _, network := range vm.networkInterfaces {
 		// network device
-		nd, err := buildNetworkDevice(finder, network.label, network.adapterType, network.macAddress)
+		nd, err := buildNetworkDevice(finder, network.label, network.adapterType, network.physicalFunction, network.macAddress)
 		if err != nil {
 			return err
 		}
 		log.Printf("[DEBUG] network device: %+v", nd.Device)
 		networkDevices = append(networkDevices, nd)
 
-		if vm.template != "" {
+		if vm.hasTemplateDisk() {
 			var ipSetting types.CustomizationIPSettings
 			if network.ipv4Address == "" {
 				ipSetting.Ip = &types.CustomizationDhcpIpGenerator{}
@@ -2088,25 +4504,47 @@ func (vm *virtualMachine) setupVirtualMachine(c *govmomi.Client) error {
 	log.Printf("[DEBUG] network configs: %#v", networkConfigs)
 
 	var task *object.Task
-	if vm.template == "" {
+	if vm.contentLibraryItemID != "" {
+		deployed, err := vm.deployContentLibraryItem(clc, finder, resourcePool, host, folder, datastore, configSpec)
+		if err != nil {
+			return err
+		}
+		// The deployed virtual machine takes the place of the template for
+		// customization.
+		err = deployed.Properties(context.TODO(), deployed.Reference(), []string{"config.guestId"}, &template_mo)
+		if err != nil {
+			return err
+		}
+	} else if vm.ovfFile != "" {
+		deployed, err := vm.deployOVF(c, finder, resourcePool, host, folder, datastore, configSpec)
+		if err != nil {
+			return err
+		}
+		err = deployed.Properties(context.TODO(), deployed.Reference(), []string{"config.guestId"}, &template_mo)
+		if err != nil {
+			return err
+		}
+	} else if vm.template == "" {
 		var mds mo.Datastore
 		if err = datastore.Properties(context.TODO(), datastore.Reference(), []string{"name"}, &mds); err != nil {
 			return err
 		}
 		log.Printf("[DEBUG] datastore: %#v", mds.Name)
-		scsi, err := object.SCSIControllerTypes().CreateSCSIController("scsi")
-		if err != nil {
-			log.Printf("[ERROR] %s", err)
-		}
+		if len(vm.scsiControllers) == 0 {
+			scsi, err := object.SCSIControllerTypes().CreateSCSIController("scsi")
+			if err != nil {
+				log.Printf("[ERROR] %s", err)
+			}
 
-		configSpec.DeviceChange = append(configSpec.DeviceChange, &types.VirtualDeviceConfigSpec{
-			Operation: types.VirtualDeviceConfigSpecOperationAdd,
-			Device:    scsi,
-		})
+			configSpec.DeviceChange = append(configSpec.DeviceChange, &types.VirtualDeviceConfigSpec{
+				Operation: types.VirtualDeviceConfigSpecOperationAdd,
+				Device:    scsi,
+			})
+		}
 
 		configSpec.Files = &types.VirtualMachineFileInfo{VmPathName: fmt.Sprintf("[%s]", mds.Name)}
 
-		task, err = folder.CreateVM(context.TODO(), configSpec, resourcePool, nil)
+		task, err = folder.CreateVM(context.TODO(), configSpec, resourcePool, host)
 		if err != nil {
 			log.Printf("[ERROR] %s", err)
 		}
@@ -2118,7 +4556,7 @@ func (vm *virtualMachine) setupVirtualMachine(c *govmomi.Client) error {
 
 	} else {
 
-		relocateSpec, err := buildVMRelocateSpec(resourcePool, datastore, template, vm.linkedClone, vm.hardDisks[0].initType)
+		relocateSpec, err := buildVMRelocateSpec(vmRelocation{pool: resourcePool, host: host, datastore: datastore}, template, vm.linkedClone, vm.hardDisks[0].initType)
 		if err != nil {
 			return err
 		}
@@ -2146,9 +4584,11 @@ func (vm *virtualMachine) setupVirtualMachine(c *govmomi.Client) error {
 		}
 	}
 
-	err = task.Wait(context.TODO())
-	if err != nil {
-		log.Printf("[ERROR] %s", err)
+	if task != nil {
+		err = task.Wait(context.TODO())
+		if err != nil {
+			log.Printf("[ERROR] %s", err)
+		}
 	}
 
 	newVM, err := finder.VirtualMachine(context.TODO(), vm.Path())
@@ -2186,14 +4626,44 @@ func (vm *virtualMachine) setupVirtualMachine(c *govmomi.Client) error {
 		return err
 	}
 
+	if err := configureVirtualMachineDiskControllers(newVM, vm.scsiControllers, vm.sataControllerCount, vm.nvmeControllerCount); err != nil {
+		return err
+	}
+
 	newVM.Properties(context.TODO(), newVM.Reference(), []string{"summary", "config"}, &vm_mo)
 	firstDisk := 0
-	if vm.template != "" {
+	if vm.hasTemplateDisk() {
 		firstDisk++
 	}
+	// Disks with an explicit unit number are attached first, so that their
+	// units are not taken by disks that are placed automatically.
+	sort.SliceStable(vm.hardDisks[firstDisk:], func(i, j int) bool {
+		return vm.hardDisks[firstDisk+i].unitNumber >= 0 && vm.hardDisks[firstDisk+j].unitNumber < 0
+	})
 	for i := firstDisk; i < len(vm.hardDisks); i++ {
 		log.Printf("[DEBUG] disk index: %v", i)
 
+		diskDatastore := datastore
+		if (vm.hardDisks[i].fcdID != "" || vm.hardDisks[i].rdmLUN != "") && vm.hardDisks[i].datastore != "" {
+			if diskDatastore, err = finder.Datastore(context.TODO(), vm.hardDisks[i].datastore); err != nil {
+				return fmt.Errorf("error finding datastore %q: %s", vm.hardDisks[i].datastore, err)
+			}
+		}
+
+		if vm.hardDisks[i].fcdID != "" {
+			if err := addFirstClassDisk(newVM, vm.hardDisks[i].fcdID, diskDatastore, vm.hardDisks[i].controller, vm.hardDisks[i].busNumber, vm.hardDisks[i].unitNumber); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if vm.hardDisks[i].rdmLUN != "" {
+			if err := addRDMDisk(c, newVM, vm.hardDisks[i].rdmLUN, vm.hardDisks[i].rdmMode, diskDatastore, vm.hardDisks[i].controller, vm.hardDisks[i].busNumber, vm.hardDisks[i].unitNumber, vm.hardDisks[i].backing); err != nil {
+				return err
+			}
+			continue
+		}
+
 		var diskPath string
 		switch {
 		case vm.hardDisks[i].vmdkPath != "":
@@ -2209,9 +4679,9 @@ func (vm *virtualMachine) setupVirtualMachine(c *govmomi.Client) error {
 		default:
 			return fmt.Errorf("[ERROR] setupVirtualMachine - Neither vmdk path nor vmdk name was given: %#v", vm.hardDisks[i])
 		}
-		err = addHardDisk(newVM, vm.hardDisks[i].size, vm.hardDisks[i].iops, vm.hardDisks[i].initType, datastore, diskPath, vm.hardDisks[i].controller)
+		err = addHardDisk(newVM, vm.hardDisks[i].size, vm.hardDisks[i].iops, vm.hardDisks[i].initType, datastore, diskPath, vm.hardDisks[i].controller, vm.hardDisks[i].busNumber, vm.hardDisks[i].unitNumber, vm.hardDisks[i].backing)
 		if err != nil {
-			err2 := addHardDisk(newVM, vm.hardDisks[i].size, vm.hardDisks[i].iops, vm.hardDisks[i].initType, datastore, diskPath, vm.hardDisks[i].controller)
+			err2 := addHardDisk(newVM, vm.hardDisks[i].size, vm.hardDisks[i].iops, vm.hardDisks[i].initType, datastore, diskPath, vm.hardDisks[i].controller, vm.hardDisks[i].busNumber, vm.hardDisks[i].unitNumber, vm.hardDisks[i].backing)
 			if err2 != nil {
 				return err2
 			}
@@ -2219,7 +4689,7 @@ func (vm *virtualMachine) setupVirtualMachine(c *govmomi.Client) error {
 		}
 	}
 
-	if vm.skipCustomization || vm.template == "" {
+	if vm.skipCustomization || !vm.hasTemplateDisk() {
 		log.Printf("[DEBUG] VM customization skipped")
 	} else {
 		var identity_options types.BaseCustomizationIdentitySettings
@@ -2314,7 +4784,22 @@ func (vm *virtualMachine) setupVirtualMachine(c *govmomi.Client) error {
 		}
 	}
 
-	if vm.hasBootableVmdk || vm.template != "" {
+	// The boot order refers to devices by key, so it can only be set once all
+	// devices have been added.
+	if len(vm.bootOrder) > 0 {
+		devices, err := newVM.Device(context.TODO())
+		if err != nil {
+			return err
+		}
+		if err := newVM.SetBootOptions(context.TODO(), &types.VirtualMachineBootOptions{BootOrder: devices.BootOrder(vm.bootOrder)}); err != nil {
+			return fmt.Errorf("error setting boot order: %s", err)
+		}
+	}
+
+	// The virtual machine is only powered on here if customization needs it to
+	// run, or if it is meant to be running. Any other power state is set once
+	// the virtual machine has been created.
+	if (vm.hasBootableVmdk || vm.hasTemplateDisk()) && (cw != nil || vm.powerState == types.VirtualMachinePowerStatePoweredOn) {
 		t, err := newVM.PowerOn(context.TODO())
 		if err != nil {
 			return err
@@ -2343,6 +4828,138 @@ func (vm *virtualMachine) setupVirtualMachine(c *govmomi.Client) error {
 	return nil
 }
 
+// deployContentLibraryItem deploys the OVF package in the content library item
+// of the virtual machine to the supplied resource pool, folder, and datastore,
+// and applies the supplied config spec to it. The networks in the package are
+// mapped to the network of the first network interface, which is replaced
+// along with the other network interfaces after deployment.
+func (vm *virtualMachine) deployContentLibraryItem(clc *contentLibraryClient, finder *find.Finder, pool *object.ResourcePool, host *object.HostSystem, folder *object.Folder, datastore *object.Datastore, configSpec types.VirtualMachineConfigSpec) (*object.VirtualMachine, error) {
+	target := contentLibraryDeploymentTarget{
+		ResourcePoolID: pool.Reference().Value,
+		FolderID:       folder.Reference().Value,
+	}
+	if host != nil {
+		target.HostID = host.Reference().Value
+	}
+	spec := contentLibraryDeploymentSpec{
+		Name:                vm.name,
+		AcceptAllEULA:       true,
+		DefaultDatastoreID:  datastore.Reference().Value,
+		StorageProvisioning: contentLibraryDiskProvisioning(vm.hardDisks[0].initType),
+	}
+	if len(vm.networkInterfaces) > 0 {
+		network, err := finder.Network(context.TODO(), vm.networkInterfaces[0].label)
+		if err != nil {
+			return nil, err
+		}
+		networks, err := contentLibraryItemNetworks(clc, vm.contentLibraryItemID, target)
+		if err != nil {
+			return nil, fmt.Errorf("error reading networks from content library item %q: %s", vm.contentLibraryItemID, err)
+		}
+		for _, n := range networks {
+			spec.NetworkMappings = append(spec.NetworkMappings, contentLibraryMapEntry{
+				Key:   n,
+				Value: network.Reference().Value,
+			})
+		}
+	}
+
+	log.Printf("[DEBUG] Deploying content library item %q as %q", vm.contentLibraryItemID, vm.name)
+	id, err := deployContentLibraryItem(clc, vm.contentLibraryItemID, target, spec)
+	if err != nil {
+		return nil, fmt.Errorf("error deploying content library item %q: %s", vm.contentLibraryItemID, err)
+	}
+	deployed := object.NewVirtualMachine(pool.Client(), types.ManagedObjectReference{
+		Type:  "VirtualMachine",
+		Value: id,
+	})
+
+	log.Printf("[DEBUG] Reconfiguring deployed virtual machine %q", id)
+	task, err := deployed.Reconfigure(context.TODO(), configSpec)
+	if err != nil {
+		return nil, err
+	}
+	if err := task.Wait(context.TODO()); err != nil {
+		return nil, err
+	}
+	return deployed, nil
+}
+
+// deployOVF deploys the OVF package or OVA archive in ovf_file of the virtual
+// machine to the supplied resource pool, folder, and datastore, and applies the
+// supplied config spec to it. As with content library items, the networks in
+// the package are mapped to the network of the first network interface.
+func (vm *virtualMachine) deployOVF(c *govmomi.Client, finder *find.Finder, pool *object.ResourcePool, host *object.HostSystem, folder *object.Folder, datastore *object.Datastore, configSpec types.VirtualMachineConfigSpec) (*object.VirtualMachine, error) {
+	src := newLocalOVFSource(vm.ovfFile)
+	if vm.ovfDatastore != "" {
+		ds, err := getDatastore(finder, vm.ovfDatastore)
+		if err != nil {
+			return nil, fmt.Errorf("error fetching datastore %q: %s", vm.ovfDatastore, err)
+		}
+		src = newDatastoreOVFSource(ds, vm.ovfFile)
+	}
+	descriptor, err := src.Descriptor()
+	if err != nil {
+		return nil, err
+	}
+	common := types.OvfManagerCommonParams{
+		DeploymentOption: vm.ovfDeploymentOption,
+	}
+	parsed, err := parseOVFDescriptor(c, descriptor, types.OvfParseDescriptorParams{OvfManagerCommonParams: common})
+	if err != nil {
+		return nil, err
+	}
+	if parsed.VirtualApp {
+		return nil, fmt.Errorf("OVF packages containing a vApp are not supported")
+	}
+
+	params := types.OvfCreateImportSpecParams{
+		OvfManagerCommonParams: common,
+		EntityName:             vm.name,
+		DiskProvisioning:       contentLibraryDiskProvisioning(vm.hardDisks[0].initType),
+	}
+	if host != nil {
+		ref := host.Reference()
+		params.HostSystem = &ref
+	}
+	if len(vm.networkInterfaces) > 0 {
+		network, err := finder.Network(context.TODO(), vm.networkInterfaces[0].label)
+		if err != nil {
+			return nil, err
+		}
+		for _, n := range parsed.Network {
+			params.NetworkMapping = append(params.NetworkMapping, types.OvfNetworkMapping{
+				Name:    n.Name,
+				Network: network.Reference(),
+			})
+		}
+	}
+	for k, v := range vm.ovfProperties {
+		params.PropertyMapping = append(params.PropertyMapping, types.KeyValue{Key: k, Value: v})
+	}
+
+	spec, err := createOVFImportSpec(c, descriptor, pool, datastore, params)
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("[DEBUG] Deploying OVF file %q as %q", vm.ovfFile, vm.name)
+	ref, err := importOVF(src, spec, pool, folder, host)
+	if err != nil {
+		return nil, fmt.Errorf("error deploying OVF file %q: %s", vm.ovfFile, err)
+	}
+	deployed := object.NewVirtualMachine(c.Client, ref)
+
+	log.Printf("[DEBUG] Reconfiguring deployed virtual machine %q", ref.Value)
+	task, err := deployed.Reconfigure(context.TODO(), configSpec)
+	if err != nil {
+		return nil, err
+	}
+	if err := task.Wait(context.TODO()); err != nil {
+		return nil, err
+	}
+	return deployed, nil
+}
+
 func getNetworkName(c *govmomi.Client, vm *object.VirtualMachine, nic types.BaseVirtualEthernetCard) (string, error) {
 	backingInfo := nic.GetVirtualEthernetCard().Backing
 	var deviceName string
@@ -2369,6 +4986,112 @@ func getNetworkName(c *govmomi.Client, vm *object.VirtualMachine, nic types.Base
 	return deviceName, nil
 }
 
+// expandVirtualMachineResourceAllocation reads the reservation, limit, and
+// shares of the resource supplied by key, either cpu or memory, and returns a
+// ResourceAllocationInfo for the config spec. The share count is only sent
+// when the share level is custom.
+func expandVirtualMachineResourceAllocation(d *schema.ResourceData, key string) *types.ResourceAllocationInfo {
+	shares := &types.SharesInfo{
+		Level: types.SharesLevel(d.Get(key + "_share_level").(string)),
+	}
+	if shares.Level == types.SharesLevelCustom {
+		shares.Shares = int32(d.Get(key + "_share_count").(int))
+	}
+	return &types.ResourceAllocationInfo{
+		Reservation: int64Ptr(int64(d.Get(key + "_reservation").(int))),
+		Limit:       int64Ptr(int64(d.Get(key + "_limit").(int))),
+		Shares:      shares,
+	}
+}
+
+// flattenVirtualMachineResourceAllocation sets the reservation, limit, and
+// shares of the resource supplied by key, either cpu or memory, from a
+// ResourceAllocationInfo.
+func flattenVirtualMachineResourceAllocation(d *schema.ResourceData, key string, base types.BaseResourceAllocationInfo) error {
+	if base == nil {
+		return nil
+	}
+	obj := base.GetResourceAllocationInfo()
+	if err := setInt64Ptr(d, key+"_reservation", obj.Reservation); err != nil {
+		return fmt.Errorf("error setting %s_reservation: %s", key, err)
+	}
+	if err := setInt64Ptr(d, key+"_limit", obj.Limit); err != nil {
+		return fmt.Errorf("error setting %s_limit: %s", key, err)
+	}
+	if obj.Shares != nil {
+		d.Set(key+"_share_level", obj.Shares.Level)
+		d.Set(key+"_share_count", obj.Shares.Shares)
+	}
+	return nil
+}
+
+// expandVirtualMachineBootOptions reads the boot options of the virtual
+// machine. Options that are not set are left out, so that they are inherited
+// from templates. The boot order is only included if devices is not nil, as
+// the bootable devices are referred to by key.
+func expandVirtualMachineBootOptions(d *schema.ResourceData, devices object.VirtualDeviceList) *types.VirtualMachineBootOptions {
+	obj := &types.VirtualMachineBootOptions{
+		BootDelay:            int64(d.Get("boot_delay").(int)),
+		BootRetryEnabled:     getBoolPtr(d, "boot_retry_enabled"),
+		BootRetryDelay:       int64(d.Get("boot_retry_delay").(int)),
+		EfiSecureBootEnabled: getBoolPtr(d, "efi_secure_boot_enabled"),
+		EnterBIOSSetup:       boolPtr(d.Get("enter_bios_setup").(bool)),
+	}
+	if devices != nil {
+		var order []string
+		for _, v := range d.Get("boot_order").([]interface{}) {
+			order = append(order, v.(string))
+		}
+		if len(order) < 1 {
+			// Clears the boot order.
+			order = []string{object.DeviceTypeNone}
+		}
+		obj.BootOrder = devices.BootOrder(order)
+	}
+	return obj
+}
+
+// flattenVirtualMachineFirmwareAndBootOptions sets the firmware, nested
+// hardware virtualization, and boot options of the virtual machine.
+// enter_bios_setup is not read, as vSphere resets it after the next boot.
+func flattenVirtualMachineFirmwareAndBootOptions(d *schema.ResourceData, config *types.VirtualMachineConfigInfo) error {
+	d.Set("firmware", config.Firmware)
+	d.Set("nested_hv_enabled", config.NestedHVEnabled != nil && *config.NestedHVEnabled)
+	if config.BootOptions == nil {
+		return nil
+	}
+	d.Set("boot_delay", config.BootOptions.BootDelay)
+	d.Set("boot_retry_enabled", config.BootOptions.BootRetryEnabled != nil && *config.BootOptions.BootRetryEnabled)
+	d.Set("boot_retry_delay", config.BootOptions.BootRetryDelay)
+	d.Set("efi_secure_boot_enabled", config.BootOptions.EfiSecureBootEnabled != nil && *config.BootOptions.EfiSecureBootEnabled)
+	// The boot order lists every bootable device, so consecutive devices of
+	// the same type are collapsed into one entry.
+	var order []string
+	for _, device := range config.BootOptions.BootOrder {
+		var t string
+		switch device.(type) {
+		case *types.VirtualMachineBootOptionsBootableDiskDevice:
+			t = object.DeviceTypeDisk
+		case *types.VirtualMachineBootOptionsBootableCdromDevice:
+			t = object.DeviceTypeCdrom
+		case *types.VirtualMachineBootOptionsBootableEthernetDevice:
+			t = object.DeviceTypeEthernet
+		case *types.VirtualMachineBootOptionsBootableFloppyDevice:
+			t = object.DeviceTypeFloppy
+		default:
+			continue
+		}
+		if len(order) > 0 && order[len(order)-1] == t {
+			continue
+		}
+		order = append(order, t)
+	}
+	if err := d.Set("boot_order", order); err != nil {
+		return fmt.Errorf("error setting boot_order: %s", err)
+	}
+	return nil
+}
+
 // Suppress Diff on equal ip
 func suppressIpDifferences(k, old, new string, d *schema.ResourceData) bool {
 	o := net.ParseIP(old)
@@ -3,8 +3,10 @@ package vsphere
 import (
 	"fmt"
 	"log"
+	"sort"
 	"strings"
 
+	"github.com/hashicorp/terraform/helper/schema"
 	"github.com/hashicorp/terraform/terraform"
 )
 
@@ -18,8 +20,25 @@ func resourceVSphereVirtualMachineMigrateState(
 	switch v {
 	case 0:
 		log.Println("[INFO] Found Compute Instance State v0; migrating to v1")
-		is, err := migrateVSphereVirtualMachineStateV0toV1(is)
-		if err != nil {
+		if _, err := migrateVSphereVirtualMachineStateV0toV1(is); err != nil {
+			return is, err
+		}
+		fallthrough
+	case 1:
+		log.Println("[INFO] Found Compute Instance State v1; migrating to v2")
+		if _, err := migrateVSphereVirtualMachineStateV1toV2(is); err != nil {
+			return is, err
+		}
+		fallthrough
+	case 2:
+		log.Println("[INFO] Found Compute Instance State v2; migrating to v3")
+		if _, err := migrateVSphereVirtualMachineStateV2toV3(is); err != nil {
+			return is, err
+		}
+		fallthrough
+	case 3:
+		log.Println("[INFO] Found Compute Instance State v3; migrating to v4")
+		if _, err := migrateVSphereVirtualMachineDiskHashes(is); err != nil {
 			return is, err
 		}
 		return is, nil
@@ -60,3 +79,139 @@ func migrateVSphereVirtualMachineStateV0toV1(is *terraform.InstanceState) (*terr
 	log.Printf("[DEBUG] Attributes after migration: %#v", is.Attributes)
 	return is, nil
 }
+
+// migrateVSphereVirtualMachineStateV1toV2 sets controller_bus_number and
+// unit_number of existing disks to -1, so that existing disks keep being
+// placed automatically, and are not seen as changed.
+func migrateVSphereVirtualMachineStateV1toV2(is *terraform.InstanceState) (*terraform.InstanceState, error) {
+	return migrateVSphereVirtualMachineDiskDefaults(is, map[string]string{
+		"controller_bus_number": "-1",
+		"unit_number":           "-1",
+	})
+}
+
+// migrateVSphereVirtualMachineStateV2toV3 sets the attach, disk_mode,
+// write_through, and sharing attributes of existing disks to their defaults,
+// which match how disks were created before these attributes existed.
+func migrateVSphereVirtualMachineStateV2toV3(is *terraform.InstanceState) (*terraform.InstanceState, error) {
+	return migrateVSphereVirtualMachineDiskDefaults(is, map[string]string{
+		"attach":        "false",
+		"disk_mode":     "persistent",
+		"write_through": "false",
+		"sharing":       "sharingNone",
+	})
+}
+
+// migrateVSphereVirtualMachineDiskDefaults sets the supplied attributes on
+// every disk in the disk set that does not have them yet. New disk attributes
+// need to be in the state of existing disks, or the hash of the disks changes
+// and they are seen as replaced.
+func migrateVSphereVirtualMachineDiskDefaults(is *terraform.InstanceState, defaults map[string]string) (*terraform.InstanceState, error) {
+	if is.Empty() || is.Attributes == nil {
+		log.Println("[DEBUG] Empty VSphere Virtual Machine State; nothing to migrate.")
+		return is, nil
+	}
+
+	log.Printf("[DEBUG] Attributes before migration: %#v", is.Attributes)
+
+	for k := range is.Attributes {
+		diskParts := strings.Split(k, ".")
+		if len(diskParts) != 3 || diskParts[0] != "disk" || diskParts[1] == "#" {
+			continue
+		}
+		for field, value := range defaults {
+			s := strings.Join([]string{diskParts[0], diskParts[1], field}, ".")
+			if _, ok := is.Attributes[s]; !ok {
+				is.Attributes[s] = value
+			}
+		}
+	}
+
+	log.Printf("[DEBUG] Attributes after migration: %#v", is.Attributes)
+	return is, nil
+}
+
+// migrateVSphereVirtualMachineDiskHashes moves the disks in the state to their
+// hash under resourceVSphereVirtualMachineDiskHash. Version 4 only keeps the
+// source and controller address of a disk in its hash, so that disks are
+// grown, migrated, and reconfigured in place. Without this, every existing
+// disk would be seen as replaced.
+func migrateVSphereVirtualMachineDiskHashes(is *terraform.InstanceState) (*terraform.InstanceState, error) {
+	if is.Empty() || is.Attributes == nil {
+		log.Println("[DEBUG] Empty VSphere Virtual Machine State; nothing to migrate.")
+		return is, nil
+	}
+
+	log.Printf("[DEBUG] Attributes before migration: %#v", is.Attributes)
+
+	disks := make(map[string]map[string]string)
+	for k, v := range is.Attributes {
+		diskParts := strings.Split(k, ".")
+		if len(diskParts) != 3 || diskParts[0] != "disk" || diskParts[1] == "#" {
+			continue
+		}
+		if _, ok := disks[diskParts[1]]; !ok {
+			disks[diskParts[1]] = make(map[string]string)
+		}
+		disks[diskParts[1]][diskParts[2]] = v
+	}
+
+	// Disks are checked in a fixed order, so that the same disk is reported
+	// every time if two disks end up with the same hash.
+	var codes []string
+	for code := range disks {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+
+	diskSchema := resourceVSphereVirtualMachine().Schema["disk"].Elem.(*schema.Resource).Schema
+	newCodes := make(map[string]string)
+	taken := make(map[string]string)
+	for _, code := range codes {
+		disk := make(map[string]interface{})
+		for _, k := range virtualMachineDiskHashKeys {
+			if v, ok := disks[code][k]; ok {
+				disk[k] = v
+			} else {
+				disk[k] = diskSchema[k].Type.Zero()
+			}
+		}
+		newCode := fmt.Sprintf("%d", resourceVSphereVirtualMachineDiskHash(disk))
+		if other, ok := taken[newCode]; ok {
+			// Keeping either disk under its old hash would show it as replaced in
+			// the next plan, which deletes it, so the migration stops instead.
+			return is, fmt.Errorf(
+				"cannot migrate disks %s and %s: disks are now identified by their name, vmdk, first_class_disk_id, rdm_lun, "+
+					"template, content_library_item_id, or ovf_file, which these disks share. Keep using the previous version of the "+
+					"provider for this virtual machine until one of these disks has a name of its own",
+				migrateVSphereVirtualMachineDiskDescription(disks[other]), migrateVSphereVirtualMachineDiskDescription(disks[code]))
+		}
+		newCodes[code] = newCode
+		taken[newCode] = code
+	}
+
+	for _, code := range codes {
+		for field := range disks[code] {
+			delete(is.Attributes, strings.Join([]string{"disk", code, field}, "."))
+		}
+	}
+	for _, code := range codes {
+		for field, v := range disks[code] {
+			is.Attributes[strings.Join([]string{"disk", newCodes[code], field}, ".")] = v
+		}
+	}
+
+	log.Printf("[DEBUG] Attributes after migration: %#v", is.Attributes)
+	return is, nil
+}
+
+// migrateVSphereVirtualMachineDiskDescription describes a disk in the state by
+// its name, path, or source, and its datastore, for error messages.
+func migrateVSphereVirtualMachineDiskDescription(disk map[string]string) string {
+	for _, k := range []string{"name", "vmdk", "first_class_disk_id", "rdm_lun", "template", "content_library_item_id", "ovf_file"} {
+		if disk[k] != "" {
+			return fmt.Sprintf("%s %q on datastore %q", k, disk[k], disk["datastore"])
+		}
+	}
+	return fmt.Sprintf("with key %q on datastore %q", disk["key"], disk["datastore"])
+}
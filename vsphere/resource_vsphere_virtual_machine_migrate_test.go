@@ -1,12 +1,18 @@
 package vsphere
 
 import (
+	"strconv"
+	"strings"
 	"testing"
 
+	"github.com/hashicorp/terraform/helper/schema"
 	"github.com/hashicorp/terraform/terraform"
 )
 
 func TestVSphereVirtualMachineMigrateState(t *testing.T) {
+	templateDisk := testVSphereVirtualMachineDiskHash(map[string]interface{}{
+		"template": "template",
+	})
 	cases := map[string]struct {
 		StateVersion int
 		Attributes   map[string]string
@@ -27,21 +33,23 @@ func TestVSphereVirtualMachineMigrateState(t *testing.T) {
 				"enable_disk_uuid": "false",
 			},
 		},
-		"disk controller_type": {
+		"disk from v0": {
 			StateVersion: 0,
 			Attributes: map[string]string{
-				"disk.1234.size":            "0",
-				"disk.5678.size":            "0",
-				"disk.9999.size":            "0",
-				"disk.9999.controller_type": "ide",
+				"disk.#":             "1",
+				"disk.1234.template": "template",
+				"disk.1234.type":     "eager_zeroed",
+				"disk.1234.size":     "0",
 			},
 			Expected: map[string]string{
-				"disk.1234.size":            "0",
-				"disk.1234.controller_type": "scsi",
-				"disk.5678.size":            "0",
-				"disk.5678.controller_type": "scsi",
-				"disk.9999.size":            "0",
-				"disk.9999.controller_type": "ide",
+				"disk.#":                                          "1",
+				"disk.1234.template":                              "",
+				"disk." + templateDisk + ".template":              "template",
+				"disk." + templateDisk + ".controller_type":       "scsi",
+				"disk." + templateDisk + ".controller_bus_number": "-1",
+				"disk." + templateDisk + ".unit_number":           "-1",
+				"disk." + templateDisk + ".disk_mode":             "persistent",
+				"disk." + templateDisk + ".sharing":               "sharingNone",
 			},
 		},
 	}
@@ -68,6 +76,155 @@ func TestVSphereVirtualMachineMigrateState(t *testing.T) {
 	}
 }
 
+func TestVSphereVirtualMachineMigrateStateV0toV1(t *testing.T) {
+	testVSphereVirtualMachineMigrateStep(t, migrateVSphereVirtualMachineStateV0toV1, map[string]testVSphereVirtualMachineMigrateCase{
+		"disk controller_type": {
+			Attributes: map[string]string{
+				"disk.1234.size":            "0",
+				"disk.5678.size":            "0",
+				"disk.9999.size":            "0",
+				"disk.9999.controller_type": "ide",
+			},
+			Expected: map[string]string{
+				"disk.1234.size":            "0",
+				"disk.1234.controller_type": "scsi",
+				"disk.5678.size":            "0",
+				"disk.5678.controller_type": "scsi",
+				"disk.9999.size":            "0",
+				"disk.9999.controller_type": "ide",
+			},
+		},
+	})
+}
+
+func TestVSphereVirtualMachineMigrateStateV1toV2(t *testing.T) {
+	testVSphereVirtualMachineMigrateStep(t, migrateVSphereVirtualMachineStateV1toV2, map[string]testVSphereVirtualMachineMigrateCase{
+		"disk controller_bus_number and unit_number": {
+			Attributes: map[string]string{
+				"disk.#":                    "2",
+				"disk.1234.size":            "0",
+				"disk.1234.controller_type": "scsi",
+				"disk.5678.size":            "0",
+				"disk.5678.controller_type": "ide",
+				"disk.5678.unit_number":     "1",
+			},
+			Expected: map[string]string{
+				"disk.#":                          "2",
+				"disk.1234.controller_bus_number": "-1",
+				"disk.1234.unit_number":           "-1",
+				"disk.5678.controller_bus_number": "-1",
+				"disk.5678.unit_number":           "1",
+			},
+		},
+	})
+}
+
+func TestVSphereVirtualMachineMigrateStateV2toV3(t *testing.T) {
+	testVSphereVirtualMachineMigrateStep(t, migrateVSphereVirtualMachineStateV2toV3, map[string]testVSphereVirtualMachineMigrateCase{
+		"disk mode and sharing": {
+			Attributes: map[string]string{
+				"disk.#":                "2",
+				"disk.1234.size":        "0",
+				"disk.5678.size":        "0",
+				"disk.5678.disk_mode":   "independent_persistent",
+				"disk.5678.sharing":     "sharingMultiWriter",
+				"disk.5678.unit_number": "-1",
+				"disk.5678.attach":      "true",
+			},
+			Expected: map[string]string{
+				"disk.1234.attach":        "false",
+				"disk.1234.disk_mode":     "persistent",
+				"disk.1234.write_through": "false",
+				"disk.1234.sharing":       "sharingNone",
+				"disk.5678.attach":        "true",
+				"disk.5678.disk_mode":     "independent_persistent",
+				"disk.5678.write_through": "false",
+				"disk.5678.sharing":       "sharingMultiWriter",
+			},
+		},
+	})
+}
+
+func TestVSphereVirtualMachineMigrateDiskHashes(t *testing.T) {
+	templateDisk := testVSphereVirtualMachineDiskHash(map[string]interface{}{
+		"template": "template",
+	})
+	dataDisk := testVSphereVirtualMachineDiskHash(map[string]interface{}{
+		"name": "data",
+		"type": "thin",
+	})
+	testVSphereVirtualMachineMigrateStep(t, migrateVSphereVirtualMachineDiskHashes, map[string]testVSphereVirtualMachineMigrateCase{
+		"disk hash with size and datastore": {
+			Attributes: map[string]string{
+				"disk.#":                          "2",
+				"disk.1111.template":              "template",
+				"disk.1111.type":                  "eager_zeroed",
+				"disk.1111.datastore":             "datastore1",
+				"disk.1111.size":                  "0",
+				"disk.1111.key":                   "2000",
+				"disk.1111.controller_type":       "scsi",
+				"disk.1111.controller_bus_number": "-1",
+				"disk.1111.unit_number":           "-1",
+				"disk.1111.attach":                "false",
+				"disk.1111.disk_mode":             "persistent",
+				"disk.1111.write_through":         "false",
+				"disk.1111.sharing":               "sharingNone",
+				"disk.2222.name":                  "data",
+				"disk.2222.type":                  "thin",
+				"disk.2222.datastore":             "datastore1",
+				"disk.2222.size":                  "10",
+				"disk.2222.key":                   "2001",
+				"disk.2222.controller_type":       "scsi",
+				"disk.2222.controller_bus_number": "-1",
+				"disk.2222.unit_number":           "-1",
+				"disk.2222.attach":                "false",
+				"disk.2222.disk_mode":             "persistent",
+				"disk.2222.write_through":         "false",
+				"disk.2222.sharing":               "sharingNone",
+			},
+			Expected: map[string]string{
+				"disk.#":                              "2",
+				"disk.1111.template":                  "",
+				"disk.2222.name":                      "",
+				"disk." + templateDisk + ".template":  "template",
+				"disk." + templateDisk + ".datastore": "datastore1",
+				"disk." + templateDisk + ".key":       "2000",
+				"disk." + dataDisk + ".name":          "data",
+				"disk." + dataDisk + ".datastore":     "datastore1",
+				"disk." + dataDisk + ".size":          "10",
+				"disk." + dataDisk + ".key":           "2001",
+			},
+		},
+	})
+}
+
+func TestVSphereVirtualMachineMigrateDiskHashesCollision(t *testing.T) {
+	is := &terraform.InstanceState{
+		ID: "i-abc123",
+		Attributes: map[string]string{
+			"disk.#":              "2",
+			"disk.1111.name":      "data",
+			"disk.1111.type":      "eager_zeroed",
+			"disk.1111.datastore": "datastore1",
+			"disk.1111.size":      "10",
+			"disk.2222.name":      "data",
+			"disk.2222.type":      "eager_zeroed",
+			"disk.2222.datastore": "datastore2",
+			"disk.2222.size":      "10",
+		},
+	}
+	_, err := migrateVSphereVirtualMachineDiskHashes(is)
+	if err == nil {
+		t.Fatalf("expected an error migrating disks with the same name on different datastores, got: %#v", is.Attributes)
+	}
+	if !strings.Contains(err.Error(), `"datastore1"`) || !strings.Contains(err.Error(), `"datastore2"`) {
+		t.Fatalf("expected error to name both disks, got: %s", err)
+	}
+	if is.Attributes["disk.1111.name"] != "data" || is.Attributes["disk.2222.name"] != "data" {
+		t.Fatalf("expected disks to be left in place, got: %#v", is.Attributes)
+	}
+}
+
 func TestComputeInstanceMigrateState_empty(t *testing.T) {
 	var is *terraform.InstanceState
 	var meta interface{}
@@ -90,3 +247,60 @@ func TestComputeInstanceMigrateState_empty(t *testing.T) {
 		t.Fatalf("err: %#v", err)
 	}
 }
+
+// testVSphereVirtualMachineMigrateCase is a test case for a single step of
+// the vsphere_virtual_machine state migration.
+type testVSphereVirtualMachineMigrateCase struct {
+	Attributes map[string]string
+	Expected   map[string]string
+}
+
+// testVSphereVirtualMachineMigrateStep runs the supplied migration step on the
+// attributes of each case, and checks the expected attributes. An expected
+// value of "" checks that the attribute is not set.
+func testVSphereVirtualMachineMigrateStep(t *testing.T, migrate func(*terraform.InstanceState) (*terraform.InstanceState, error), cases map[string]testVSphereVirtualMachineMigrateCase) {
+	for tn, tc := range cases {
+		is := &terraform.InstanceState{
+			ID:         "i-abc123",
+			Attributes: tc.Attributes,
+		}
+		is, err := migrate(is)
+		if err != nil {
+			t.Fatalf("bad: %s, err: %#v", tn, err)
+		}
+
+		for k, v := range tc.Expected {
+			if is.Attributes[k] != v {
+				t.Fatalf(
+					"bad: %s\n\n expected: %#v -> %#v\n got: %#v -> %#v\n in: %#v",
+					tn, k, v, k, is.Attributes[k], is.Attributes)
+			}
+		}
+	}
+}
+
+// testVSphereVirtualMachineDisk returns a disk with the supplied attributes,
+// as read from configuration, with all other attributes at their schema
+// defaults.
+func testVSphereVirtualMachineDisk(attrs map[string]interface{}) map[string]interface{} {
+	diskSchema := resourceVSphereVirtualMachine().Schema["disk"].Elem.(*schema.Resource).Schema
+	disk := make(map[string]interface{})
+	for k, s := range diskSchema {
+		if s.Default != nil {
+			disk[k] = s.Default
+		} else {
+			disk[k] = s.Type.Zero()
+		}
+	}
+	for k, v := range attrs {
+		disk[k] = v
+	}
+	return disk
+}
+
+// testVSphereVirtualMachineDiskHash returns the hash code of a disk with the
+// supplied attributes, as computed from configuration, with all other
+// attributes at their schema defaults.
+func testVSphereVirtualMachineDiskHash(attrs map[string]interface{}) string {
+	return strconv.Itoa(resourceVSphereVirtualMachineDiskHash(testVSphereVirtualMachineDisk(attrs)))
+}
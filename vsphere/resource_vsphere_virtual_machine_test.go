@@ -10,9 +10,12 @@ import (
 	"strconv"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/hashicorp/terraform/helper/resource"
+	"github.com/hashicorp/terraform/helper/schema"
 	"github.com/hashicorp/terraform/terraform"
+	"github.com/vmware/govmomi/object"
 	"github.com/vmware/govmomi/vim25/types"
 )
 
@@ -20,6 +23,11 @@ const (
 	testAccResourceVSphereVirtualMachineDiskNameEager     = "terraform-test-extra-eager"
 	testAccResourceVSphereVirtualMachineDiskNameLazy      = "terraform-test-extra-lazy"
 	testAccResourceVSphereVirtualMachineDiskNameThin      = "terraform-test-extra-thin"
+	testAccResourceVSphereVirtualMachineDiskNameSCSI      = "terraform-test-extra-scsi"
+	testAccResourceVSphereVirtualMachineDiskNameSATA      = "terraform-test-extra-sata"
+	testAccResourceVSphereVirtualMachineDiskNameShared    = "terraform-test-extra-shared"
+	testAccResourceVSphereVirtualMachineDiskNameGrow      = "terraform-test-extra-grow"
+	testAccResourceVSphereVirtualMachineDiskNameMigrate   = "terraform-test-extra-migrate"
 	testAccResourceVSphereVirtualMachineDiskNameExtraVmdk = "terraform-test-vm-extra-disk.vmdk"
 	testAccResourceVSphereVirtualMachineStaticMacAddr     = "06:5c:89:2b:a0:64"
 	testAccResourceVSphereVirtualMachineAnnotation        = "Managed by Terraform"
@@ -29,6 +37,10 @@ const (
 func TestAccResourceVSphereVirtualMachine(t *testing.T) {
 	var tp *testing.T
 	var state *terraform.State
+	var bootTime time.Time
+	var macAddress string
+	var diskKey int32
+	var migratedDiskKey int32
 	testAccResourceVSphereVirtualMachineCases := []struct {
 		name     string
 		testCase resource.TestCase
@@ -452,366 +464,2372 @@ func TestAccResourceVSphereVirtualMachine(t *testing.T) {
 				},
 			},
 		},
+		{
+			"from content library item",
+			resource.TestCase{
+				PreCheck: func() {
+					testAccPreCheck(tp)
+					testAccResourceVSphereVirtualMachinePreCheck(tp)
+					testAccResourceVSphereContentLibraryItemPreCheck(tp)
+				},
+				Providers:    testAccProviders,
+				CheckDestroy: testAccResourceVSphereVirtualMachineCheckExists(false),
+				Steps: []resource.TestStep{
+					{
+						Config: testAccResourceVSphereVirtualMachineConfigContentLibraryItem(),
+						Check: resource.ComposeTestCheckFunc(
+							testAccResourceVSphereVirtualMachineCheckExists(true),
+							testAccResourceVSphereVirtualMachineCheckCPUMem(2, 1024),
+							testAccResourceVSphereVirtualMachineCheckPowerState(types.VirtualMachinePowerStatePoweredOn),
+							resource.TestCheckResourceAttr("vsphere_virtual_machine.vm", "disk.#", "1"),
+						),
+					},
+				},
+			},
+		},
+		{
+			"from ovf file",
+			resource.TestCase{
+				PreCheck: func() {
+					testAccPreCheck(tp)
+					testAccResourceVSphereVirtualMachinePreCheck(tp)
+					if os.Getenv("VSPHERE_OVF_FILE") == "" {
+						tp.Skip("set VSPHERE_OVF_FILE to run vsphere_virtual_machine OVF acceptance tests")
+					}
+				},
+				Providers:    testAccProviders,
+				CheckDestroy: testAccResourceVSphereVirtualMachineCheckExists(false),
+				Steps: []resource.TestStep{
+					{
+						Config: testAccResourceVSphereVirtualMachineConfigOvfFile(),
+						Check: resource.ComposeTestCheckFunc(
+							testAccResourceVSphereVirtualMachineCheckExists(true),
+							testAccResourceVSphereVirtualMachineCheckCPUMem(2, 1024),
+							testAccResourceVSphereVirtualMachineCheckPowerState(types.VirtualMachinePowerStatePoweredOn),
+							resource.TestCheckResourceAttr("vsphere_virtual_machine.vm", "disk.#", "1"),
+						),
+					},
+				},
+			},
+		},
+		{
+			"import",
+			resource.TestCase{
+				PreCheck: func() {
+					testAccPreCheck(tp)
+					testAccResourceVSphereVirtualMachinePreCheck(tp)
+				},
+				Providers:    testAccProviders,
+				CheckDestroy: testAccResourceVSphereVirtualMachineCheckExists(false),
+				Steps: []resource.TestStep{
+					{
+						Config: testAccResourceVSphereVirtualMachineConfigBasic(),
+						Check: resource.ComposeTestCheckFunc(
+							testAccResourceVSphereVirtualMachineCheckExists(true),
+						),
+					},
+					{
+						ResourceName:      "vsphere_virtual_machine.vm",
+						ImportState:       true,
+						ImportStateVerify: true,
+						// The template disk is imported as a vmdk disk, linked_clone is
+						// only known at creation, and resource_pool is not imported when
+						// the virtual machine is in the root resource pool of the
+						// cluster.
+						ImportStateVerifyIgnore: []string{"disk", "linked_clone", "resource_pool"},
+						ImportStateIdFunc: func(s *terraform.State) (string, error) {
+							rs, ok := s.RootModule().Resources["vsphere_virtual_machine.vm"]
+							if !ok {
+								return "", errors.New("vsphere_virtual_machine.vm not found in state")
+							}
+							return rs.Primary.Attributes["uuid"], nil
+						},
+						Config: testAccResourceVSphereVirtualMachineConfigBasic(),
+					},
+					{
+						ResourceName: "vsphere_virtual_machine.vm",
+						ImportState:  true,
+						ImportStateIdFunc: func(s *terraform.State) (string, error) {
+							vm, err := testGetVirtualMachine(s, "vm")
+							if err != nil {
+								return "", err
+							}
+							return vm.InventoryPath, nil
+						},
+						ImportStateCheck: func(s []*terraform.InstanceState) error {
+							if len(s) != 1 {
+								return fmt.Errorf("expected 1 imported resource, got %d", len(s))
+							}
+							attrs := s[0].Attributes
+							if attrs["name"] != "terraform-test" {
+								return fmt.Errorf("expected name to be terraform-test, got %q", attrs["name"])
+							}
+							if attrs["disk.#"] != "1" {
+								return fmt.Errorf("expected 1 disk, got %s", attrs["disk.#"])
+							}
+							return nil
+						},
+						Config: testAccResourceVSphereVirtualMachineConfigBasic(),
+					},
+				},
+			},
+		},
+		{
+			"import vmdk disk",
+			resource.TestCase{
+				PreCheck: func() {
+					testAccPreCheck(tp)
+					testAccResourceVSphereVirtualMachinePreCheck(tp)
+				},
+				Providers:    testAccProviders,
+				CheckDestroy: testAccResourceVSphereVirtualMachineCheckExists(false),
+				Steps: []resource.TestStep{
+					{
+						Config: testAccResourceVSphereVirtualMachineConfigImportVmdk(),
+						Check: resource.ComposeTestCheckFunc(
+							testAccResourceVSphereVirtualMachineCheckExists(true),
+						),
+					},
+					{
+						ResourceName:      "vsphere_virtual_machine.vm",
+						ImportState:       true,
+						ImportStateVerify: true,
+						// Disks are imported with keep_on_remove unset, and resource_pool
+						// is not imported when the virtual machine is in the root resource
+						// pool of the cluster.
+						ImportStateVerifyIgnore: []string{
+							"disk." + testVSphereVirtualMachineDiskHash(map[string]interface{}{
+								"vmdk": testAccResourceVSphereVirtualMachineDiskNameExtraVmdk,
+							}) + ".keep_on_remove",
+							"resource_pool",
+						},
+						ImportStateIdFunc: func(s *terraform.State) (string, error) {
+							rs, ok := s.RootModule().Resources["vsphere_virtual_machine.vm"]
+							if !ok {
+								return "", errors.New("vsphere_virtual_machine.vm not found in state")
+							}
+							return rs.Primary.Attributes["uuid"], nil
+						},
+						Config: testAccResourceVSphereVirtualMachineConfigImportVmdk(),
+					},
+				},
+			},
+		},
+		{
+			"hot add cpu and memory",
+			resource.TestCase{
+				PreCheck: func() {
+					testAccPreCheck(tp)
+					testAccResourceVSphereVirtualMachinePreCheck(tp)
+				},
+				Providers:    testAccProviders,
+				CheckDestroy: testAccResourceVSphereVirtualMachineCheckExists(false),
+				Steps: []resource.TestStep{
+					{
+						Config: testAccResourceVSphereVirtualMachineConfigHotAdd(2, 1024),
+						Check: resource.ComposeTestCheckFunc(
+							testAccResourceVSphereVirtualMachineCheckExists(true),
+							testAccResourceVSphereVirtualMachineCheckBootTime(&bootTime),
+						),
+					},
+					{
+						Config: testAccResourceVSphereVirtualMachineConfigHotAdd(4, 2048),
+						Check: resource.ComposeTestCheckFunc(
+							testAccResourceVSphereVirtualMachineCheckCPUMem(4, 2048),
+							testAccResourceVSphereVirtualMachineCheckBootTime(&bootTime),
+							resource.TestCheckResourceAttr("vsphere_virtual_machine.vm", "reboot_required", "false"),
+						),
+					},
+				},
+			},
+		},
+		{
+			"graceful shutdown",
+			resource.TestCase{
+				PreCheck: func() {
+					testAccPreCheck(tp)
+					testAccResourceVSphereVirtualMachinePreCheck(tp)
+				},
+				Providers:    testAccProviders,
+				CheckDestroy: testAccResourceVSphereVirtualMachineCheckExists(false),
+				Steps: []resource.TestStep{
+					{
+						Config: testAccResourceVSphereVirtualMachineConfigGracefulShutdown(2),
+						Check: resource.ComposeTestCheckFunc(
+							testAccResourceVSphereVirtualMachineCheckExists(true),
+						),
+					},
+					{
+						Config: testAccResourceVSphereVirtualMachineConfigGracefulShutdown(4),
+						Check: resource.ComposeTestCheckFunc(
+							testAccResourceVSphereVirtualMachineCheckCPUMem(4, 1024),
+							testAccResourceVSphereVirtualMachineCheckPowerState(types.VirtualMachinePowerStatePoweredOn),
+						),
+					},
+				},
+			},
+		},
+		{
+			"power state",
+			resource.TestCase{
+				PreCheck: func() {
+					testAccPreCheck(tp)
+					testAccResourceVSphereVirtualMachinePreCheck(tp)
+				},
+				Providers:    testAccProviders,
+				CheckDestroy: testAccResourceVSphereVirtualMachineCheckExists(false),
+				Steps: []resource.TestStep{
+					{
+						Config: testAccResourceVSphereVirtualMachineConfigPowerState(types.VirtualMachinePowerStatePoweredOff),
+						Check: resource.ComposeTestCheckFunc(
+							copyStatePtr(&state),
+							testAccResourceVSphereVirtualMachineCheckPowerState(types.VirtualMachinePowerStatePoweredOff),
+						),
+					},
+					{
+						PreConfig: func() {
+							if err := testPowerOnVM(state, "vm"); err != nil {
+								panic(err)
+							}
+						},
+						Config: testAccResourceVSphereVirtualMachineConfigPowerState(types.VirtualMachinePowerStatePoweredOff),
+						Check: resource.ComposeTestCheckFunc(
+							testAccResourceVSphereVirtualMachineCheckPowerState(types.VirtualMachinePowerStatePoweredOff),
+						),
+					},
+					{
+						Config: testAccResourceVSphereVirtualMachineConfigPowerState(types.VirtualMachinePowerStateSuspended),
+						Check: resource.ComposeTestCheckFunc(
+							testAccResourceVSphereVirtualMachineCheckPowerState(types.VirtualMachinePowerStateSuspended),
+						),
+					},
+					{
+						Config: testAccResourceVSphereVirtualMachineConfigPowerState(types.VirtualMachinePowerStatePoweredOn),
+						Check: resource.ComposeTestCheckFunc(
+							testAccResourceVSphereVirtualMachineCheckPowerState(types.VirtualMachinePowerStatePoweredOn),
+						),
+					},
+				},
+			},
+		},
+		{
+			"resource allocation",
+			resource.TestCase{
+				PreCheck: func() {
+					testAccPreCheck(tp)
+					testAccResourceVSphereVirtualMachinePreCheck(tp)
+				},
+				Providers:    testAccProviders,
+				CheckDestroy: testAccResourceVSphereVirtualMachineCheckExists(false),
+				Steps: []resource.TestStep{
+					{
+						Config: testAccResourceVSphereVirtualMachineConfigResourceAllocation(500, "normal", 512),
+						Check: resource.ComposeTestCheckFunc(
+							testAccResourceVSphereVirtualMachineCheckExists(true),
+							testAccResourceVSphereVirtualMachineCheckBootTime(&bootTime),
+							resource.TestCheckResourceAttr("vsphere_virtual_machine.vm", "cpu_reservation", "500"),
+							resource.TestCheckResourceAttr("vsphere_virtual_machine.vm", "cpu_share_level", "normal"),
+							resource.TestCheckResourceAttr("vsphere_virtual_machine.vm", "memory_limit", "512"),
+						),
+					},
+					{
+						Config: testAccResourceVSphereVirtualMachineConfigResourceAllocation(1000, "high", 1024),
+						Check: resource.ComposeTestCheckFunc(
+							testAccResourceVSphereVirtualMachineCheckBootTime(&bootTime),
+							resource.TestCheckResourceAttr("vsphere_virtual_machine.vm", "cpu_reservation", "1000"),
+							resource.TestCheckResourceAttr("vsphere_virtual_machine.vm", "cpu_share_level", "high"),
+							resource.TestCheckResourceAttr("vsphere_virtual_machine.vm", "memory_limit", "1024"),
+						),
+					},
+				},
+			},
+		},
+		{
+			"boot options",
+			resource.TestCase{
+				PreCheck: func() {
+					testAccPreCheck(tp)
+					testAccResourceVSphereVirtualMachinePreCheck(tp)
+				},
+				Providers:    testAccProviders,
+				CheckDestroy: testAccResourceVSphereVirtualMachineCheckExists(false),
+				Steps: []resource.TestStep{
+					{
+						Config: testAccResourceVSphereVirtualMachineConfigBootOptions(5000, true),
+						Check: resource.ComposeTestCheckFunc(
+							testAccResourceVSphereVirtualMachineCheckExists(true),
+							resource.TestCheckResourceAttr("vsphere_virtual_machine.vm", "boot_delay", "5000"),
+							resource.TestCheckResourceAttr("vsphere_virtual_machine.vm", "nested_hv_enabled", "true"),
+							resource.TestCheckResourceAttr("vsphere_virtual_machine.vm", "boot_order.#", "2"),
+							resource.TestCheckResourceAttr("vsphere_virtual_machine.vm", "boot_order.0", "disk"),
+						),
+					},
+					{
+						Config: testAccResourceVSphereVirtualMachineConfigBootOptions(10000, false),
+						Check: resource.ComposeTestCheckFunc(
+							resource.TestCheckResourceAttr("vsphere_virtual_machine.vm", "boot_delay", "10000"),
+							resource.TestCheckResourceAttr("vsphere_virtual_machine.vm", "nested_hv_enabled", "false"),
+							testAccResourceVSphereVirtualMachineCheckPowerState(types.VirtualMachinePowerStatePoweredOn),
+						),
+					},
+				},
+			},
+		},
+		{
+			"secure boot without efi",
+			resource.TestCase{
+				PreCheck: func() {
+					testAccPreCheck(tp)
+					testAccResourceVSphereVirtualMachinePreCheck(tp)
+				},
+				Providers: testAccProviders,
+				Steps: []resource.TestStep{
+					{
+						Config:      testAccResourceVSphereVirtualMachineConfigSecureBootBIOS(),
+						ExpectError: regexp.MustCompile("efi_secure_boot_enabled requires firmware to be set to \"efi\""),
+						PlanOnly:    true,
+					},
+				},
+			},
+		},
+		{
+			"change network adapter type",
+			resource.TestCase{
+				PreCheck: func() {
+					testAccPreCheck(tp)
+					testAccResourceVSphereVirtualMachinePreCheck(tp)
+				},
+				Providers:    testAccProviders,
+				CheckDestroy: testAccResourceVSphereVirtualMachineCheckExists(false),
+				Steps: []resource.TestStep{
+					{
+						Config: testAccResourceVSphereVirtualMachineConfigNetworkAdapterType("vmxnet3"),
+						Check: resource.ComposeTestCheckFunc(
+							testAccResourceVSphereVirtualMachineCheckExists(true),
+							testAccResourceVSphereVirtualMachineCheckMACAddress(&macAddress),
+							resource.TestCheckResourceAttr("vsphere_virtual_machine.vm", "network_interface.0.adapter_type", "vmxnet3"),
+						),
+					},
+					{
+						Config: testAccResourceVSphereVirtualMachineConfigNetworkAdapterType("e1000e"),
+						Check: resource.ComposeTestCheckFunc(
+							testAccResourceVSphereVirtualMachineCheckMACAddress(&macAddress),
+							resource.TestCheckResourceAttr("vsphere_virtual_machine.vm", "network_interface.#", "1"),
+							resource.TestCheckResourceAttr("vsphere_virtual_machine.vm", "network_interface.0.adapter_type", "e1000e"),
+						),
+					},
+				},
+			},
+		},
+		{
+			"multiple disk controllers",
+			resource.TestCase{
+				PreCheck: func() {
+					testAccPreCheck(tp)
+					testAccResourceVSphereVirtualMachinePreCheck(tp)
+				},
+				Providers:    testAccProviders,
+				CheckDestroy: testAccResourceVSphereVirtualMachineCheckExists(false),
+				Steps: []resource.TestStep{
+					{
+						Config: testAccResourceVSphereVirtualMachineConfigDiskControllers(),
+						Check: resource.ComposeTestCheckFunc(
+							testAccResourceVSphereVirtualMachineCheckExists(true),
+							resource.TestCheckResourceAttr("vsphere_virtual_machine.vm", "scsi_controller.#", "2"),
+							resource.TestCheckResourceAttr("vsphere_virtual_machine.vm", "scsi_controller.1.type", "pvscsi"),
+							resource.TestCheckResourceAttr("vsphere_virtual_machine.vm", "sata_controller_count", "1"),
+							testAccResourceVSphereVirtualMachineCheckDiskAddress(testAccResourceVSphereVirtualMachineDiskNameSCSI, "scsi-paravirtual", 1, 3),
+							testAccResourceVSphereVirtualMachineCheckDiskAddress(testAccResourceVSphereVirtualMachineDiskNameSATA, "sata", 0, 0),
+						),
+					},
+				},
+			},
+		},
+		{
+			"shared multi-writer disk",
+			resource.TestCase{
+				PreCheck: func() {
+					testAccPreCheck(tp)
+					testAccResourceVSphereVirtualMachinePreCheck(tp)
+				},
+				Providers:    testAccProviders,
+				CheckDestroy: testAccResourceVSphereVirtualMachineCheckExists(false),
+				Steps: []resource.TestStep{
+					{
+						Config: testAccResourceVSphereVirtualMachineConfigSharedDisk(),
+						Check: resource.ComposeTestCheckFunc(
+							testAccResourceVSphereVirtualMachineCheckExists(true),
+							testAccResourceVSphereVirtualMachineCheckDiskBacking("vm", testAccResourceVSphereVirtualMachineDiskNameShared, "independent_persistent", "sharingMultiWriter"),
+							testAccResourceVSphereVirtualMachineCheckDiskBacking("vm2", testAccResourceVSphereVirtualMachineDiskNameShared, "independent_persistent", "sharingMultiWriter"),
+						),
+					},
+				},
+			},
+		},
+		{
+			"raw device mapping",
+			resource.TestCase{
+				PreCheck: func() {
+					testAccPreCheck(tp)
+					testAccResourceVSphereVirtualMachinePreCheck(tp)
+					if os.Getenv("VSPHERE_RDM_LUN") == "" {
+						tp.Skip("set VSPHERE_RDM_LUN to run vsphere_virtual_machine raw device mapping acceptance tests")
+					}
+				},
+				Providers:    testAccProviders,
+				CheckDestroy: testAccResourceVSphereVirtualMachineCheckExists(false),
+				Steps: []resource.TestStep{
+					{
+						Config: testAccResourceVSphereVirtualMachineConfigRDM(),
+						Check: resource.ComposeTestCheckFunc(
+							testAccResourceVSphereVirtualMachineCheckExists(true),
+							testAccResourceVSphereVirtualMachineCheckRDM(os.Getenv("VSPHERE_RDM_LUN"), "virtualMode"),
+						),
+					},
+				},
+			},
+		},
+		{
+			"grow disk",
+			resource.TestCase{
+				PreCheck: func() {
+					testAccPreCheck(tp)
+					testAccResourceVSphereVirtualMachinePreCheck(tp)
+				},
+				Providers:    testAccProviders,
+				CheckDestroy: testAccResourceVSphereVirtualMachineCheckExists(false),
+				Steps: []resource.TestStep{
+					{
+						Config: testAccResourceVSphereVirtualMachineConfigGrowDisk(1, 0, "thin"),
+						Check: resource.ComposeTestCheckFunc(
+							testAccResourceVSphereVirtualMachineCheckExists(true),
+							testAccResourceVSphereVirtualMachineCheckDiskSize(testAccResourceVSphereVirtualMachineDiskNameGrow, 1, &diskKey),
+						),
+					},
+					{
+						Config: testAccResourceVSphereVirtualMachineConfigGrowDisk(2, 0, "thin"),
+						Check: resource.ComposeTestCheckFunc(
+							testAccResourceVSphereVirtualMachineCheckDiskSize(testAccResourceVSphereVirtualMachineDiskNameGrow, 2, &diskKey),
+						),
+					},
+					{
+						Config: testAccResourceVSphereVirtualMachineConfigGrowDisk(2, 100, "thin"),
+						Check: resource.ComposeTestCheckFunc(
+							testAccResourceVSphereVirtualMachineCheckDiskSize(testAccResourceVSphereVirtualMachineDiskNameGrow, 2, &diskKey),
+						),
+					},
+					{
+						Config:      testAccResourceVSphereVirtualMachineConfigGrowDisk(1, 100, "thin"),
+						ExpectError: regexp.MustCompile("size cannot be reduced"),
+					},
+					{
+						Config:      testAccResourceVSphereVirtualMachineConfigGrowDisk(2, 100, "lazy"),
+						ExpectError: regexp.MustCompile("type cannot be changed on an existing disk"),
+					},
+				},
+			},
+		},
+		{
+			"vmotion and storage vmotion",
+			resource.TestCase{
+				PreCheck: func() {
+					testAccPreCheck(tp)
+					testAccResourceVSphereVirtualMachinePreCheck(tp)
+					if os.Getenv("VSPHERE_ESXI_HOST") == "" || os.Getenv("VSPHERE_ESXI_HOST2") == "" {
+						tp.Skip("set VSPHERE_ESXI_HOST and VSPHERE_ESXI_HOST2 to run vsphere_virtual_machine migration acceptance tests")
+					}
+					if os.Getenv("VSPHERE_DATASTORE2") == "" {
+						tp.Skip("set VSPHERE_DATASTORE2 to run vsphere_virtual_machine migration acceptance tests")
+					}
+				},
+				Providers:    testAccProviders,
+				CheckDestroy: testAccResourceVSphereVirtualMachineCheckExists(false),
+				Steps: []resource.TestStep{
+					{
+						Config: testAccResourceVSphereVirtualMachineConfigMigrate(os.Getenv("VSPHERE_ESXI_HOST"), os.Getenv("VSPHERE_DATASTORE")),
+						Check: resource.ComposeTestCheckFunc(
+							testAccResourceVSphereVirtualMachineCheckExists(true),
+							testAccResourceVSphereVirtualMachineCheckPlacement(os.Getenv("VSPHERE_ESXI_HOST"), testAccResourceVSphereVirtualMachineDiskNameMigrate, os.Getenv("VSPHERE_DATASTORE")),
+							testAccResourceVSphereVirtualMachineCheckDiskSize(testAccResourceVSphereVirtualMachineDiskNameMigrate, 1, &migratedDiskKey),
+						),
+					},
+					{
+						Config: testAccResourceVSphereVirtualMachineConfigMigrate(os.Getenv("VSPHERE_ESXI_HOST2"), os.Getenv("VSPHERE_DATASTORE2")),
+						Check: resource.ComposeTestCheckFunc(
+							testAccResourceVSphereVirtualMachineCheckPlacement(os.Getenv("VSPHERE_ESXI_HOST2"), testAccResourceVSphereVirtualMachineDiskNameMigrate, os.Getenv("VSPHERE_DATASTORE2")),
+							testAccResourceVSphereVirtualMachineCheckDiskSize(testAccResourceVSphereVirtualMachineDiskNameMigrate, 1, &migratedDiskKey),
+						),
+					},
+				},
+			},
+		},
+	}
+
+	for _, tc := range testAccResourceVSphereVirtualMachineCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tp = t
+			resource.Test(t, tc.testCase)
+		})
+	}
+}
+
+func TestVirtualMachineRebootRequired(t *testing.T) {
+	dataDisk := func(attrs map[string]interface{}) *schema.Set {
+		attrs["name"] = "data"
+		return schema.NewSet(resourceVSphereVirtualMachineDiskHash, []interface{}{testVSphereVirtualMachineDisk(attrs)})
+	}
+	networkInterface := func(adapterType, physicalFunction string) []interface{} {
+		return []interface{}{
+			map[string]interface{}{
+				"adapter_type":      adapterType,
+				"physical_function": physicalFunction,
+			},
+		}
+	}
+	cases := []struct {
+		Name     string
+		Old      map[string]interface{}
+		New      map[string]interface{}
+		HotPlug  *virtualMachineHotPlugSupport
+		Expected bool
+	}{
+		{
+			Name:     "no changes",
+			Expected: false,
+		},
+		{
+			Name:     "cpu hot add",
+			Old:      map[string]interface{}{"cpu_hot_add_enabled": true},
+			New:      map[string]interface{}{"vcpu": 4},
+			Expected: false,
+		},
+		{
+			Name:     "cpu add without hot add",
+			New:      map[string]interface{}{"vcpu": 4},
+			Expected: true,
+		},
+		{
+			Name:     "cpu hot add not supported by guest",
+			Old:      map[string]interface{}{"cpu_hot_add_enabled": true},
+			New:      map[string]interface{}{"vcpu": 4},
+			HotPlug:  &virtualMachineHotPlugSupport{cpuRemove: true, memoryAdd: true},
+			Expected: true,
+		},
+		{
+			Name:     "cpu hot remove",
+			Old:      map[string]interface{}{"cpu_hot_remove_enabled": true, "vcpu": 4},
+			New:      map[string]interface{}{"vcpu": 2},
+			Expected: false,
+		},
+		{
+			Name:     "cpu remove without hot remove",
+			Old:      map[string]interface{}{"cpu_hot_add_enabled": true, "vcpu": 4},
+			New:      map[string]interface{}{"vcpu": 2},
+			Expected: true,
+		},
+		{
+			Name:     "enabling cpu hot add",
+			New:      map[string]interface{}{"cpu_hot_add_enabled": true},
+			Expected: true,
+		},
+		{
+			Name:     "memory hot add",
+			Old:      map[string]interface{}{"memory_hot_add_enabled": true},
+			New:      map[string]interface{}{"memory": 2048},
+			Expected: false,
+		},
+		{
+			Name:     "memory add without hot add",
+			New:      map[string]interface{}{"memory": 2048},
+			Expected: true,
+		},
+		{
+			Name:     "memory hot add within limit",
+			Old:      map[string]interface{}{"memory_hot_add_enabled": true},
+			New:      map[string]interface{}{"memory": 2048},
+			HotPlug:  &virtualMachineHotPlugSupport{cpuAdd: true, cpuRemove: true, memoryAdd: true, memoryLimit: 4096},
+			Expected: false,
+		},
+		{
+			Name:     "memory hot add over limit",
+			Old:      map[string]interface{}{"memory_hot_add_enabled": true},
+			New:      map[string]interface{}{"memory": 8192},
+			HotPlug:  &virtualMachineHotPlugSupport{cpuAdd: true, cpuRemove: true, memoryAdd: true, memoryLimit: 4096},
+			Expected: true,
+		},
+		{
+			Name:     "memory remove",
+			Old:      map[string]interface{}{"memory_hot_add_enabled": true, "memory": 2048},
+			New:      map[string]interface{}{"memory": 1024},
+			Expected: true,
+		},
+		{
+			Name:     "suspended with hot add",
+			Old:      map[string]interface{}{"power_state": "suspended", "cpu_hot_add_enabled": true},
+			New:      map[string]interface{}{"vcpu": 4},
+			Expected: true,
+		},
+		{
+			Name:     "suspended without hardware changes",
+			Old:      map[string]interface{}{"power_state": "suspended"},
+			Expected: false,
+		},
+		{
+			Name:     "powered off",
+			Old:      map[string]interface{}{"power_state": "poweredOff"},
+			New:      map[string]interface{}{"vcpu": 4, "firmware": "efi"},
+			Expected: false,
+		},
+		{
+			Name:     "powering off",
+			New:      map[string]interface{}{"power_state": "poweredOff", "vcpu": 4},
+			Expected: false,
+		},
+		{
+			Name:     "powering on",
+			Old:      map[string]interface{}{"power_state": "poweredOff"},
+			New:      map[string]interface{}{"power_state": "poweredOn", "vcpu": 4},
+			Expected: false,
+		},
+		{
+			Name:     "firmware",
+			New:      map[string]interface{}{"firmware": "efi"},
+			Expected: true,
+		},
+		{
+			Name:     "scsi disk grow",
+			Old:      map[string]interface{}{"disk": dataDisk(map[string]interface{}{"size": 1})},
+			New:      map[string]interface{}{"disk": dataDisk(map[string]interface{}{"size": 2})},
+			Expected: false,
+		},
+		{
+			Name:     "ide disk grow",
+			Old:      map[string]interface{}{"disk": dataDisk(map[string]interface{}{"size": 1, "controller_type": "ide"})},
+			New:      map[string]interface{}{"disk": dataDisk(map[string]interface{}{"size": 2, "controller_type": "ide"})},
+			Expected: true,
+		},
+		{
+			Name:     "disk mode",
+			Old:      map[string]interface{}{"disk": dataDisk(map[string]interface{}{"size": 1})},
+			New:      map[string]interface{}{"disk": dataDisk(map[string]interface{}{"size": 1, "disk_mode": "independent_persistent"})},
+			Expected: true,
+		},
+		{
+			Name:     "scsi controller removed",
+			Old:      map[string]interface{}{"scsi_controller": []interface{}{map[string]interface{}{"bus_sharing": "noSharing"}}},
+			New:      map[string]interface{}{"scsi_controller": []interface{}{}},
+			Expected: true,
+		},
+		{
+			Name:     "adapter type",
+			New:      map[string]interface{}{"network_interface": networkInterface("e1000e", "")},
+			Expected: false,
+		},
+		{
+			Name:     "adapter type to sriov",
+			New:      map[string]interface{}{"network_interface": networkInterface("sriov", "0000:04:00.1")},
+			Expected: true,
+		},
+		{
+			Name:     "sriov physical function",
+			Old:      map[string]interface{}{"network_interface": networkInterface("sriov", "0000:04:00.1")},
+			New:      map[string]interface{}{"network_interface": networkInterface("sriov", "0000:04:00.2")},
+			Expected: true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			d := testVirtualMachineChangeGetter{
+				old: map[string]interface{}{
+					"power_state":             "poweredOn",
+					"cpu_hot_add_enabled":     false,
+					"cpu_hot_remove_enabled":  false,
+					"memory_hot_add_enabled":  false,
+					"firmware":                "bios",
+					"efi_secure_boot_enabled": false,
+					"nested_hv_enabled":       false,
+					"vcpu":                    2,
+					"memory":                  1024,
+					"disk":                    schema.NewSet(resourceVSphereVirtualMachineDiskHash, nil),
+					"scsi_controller":         []interface{}{},
+					"sata_controller_count":   0,
+					"nvme_controller_count":   0,
+					"network_interface":       networkInterface("vmxnet3", ""),
+				},
+				new: make(map[string]interface{}),
+			}
+			for k, v := range tc.Old {
+				d.old[k] = v
+			}
+			for k, v := range d.old {
+				d.new[k] = v
+			}
+			for k, v := range tc.New {
+				d.new[k] = v
+			}
+			if actual := virtualMachineRebootRequired(d, tc.HotPlug); actual != tc.Expected {
+				t.Fatalf("expected %t, got %t", tc.Expected, actual)
+			}
+		})
+	}
+}
+
+// testVirtualMachineChangeGetter is a virtualMachineChangeGetter that returns
+// the old and new values of each key from a map.
+type testVirtualMachineChangeGetter struct {
+	old map[string]interface{}
+	new map[string]interface{}
+}
+
+func (g testVirtualMachineChangeGetter) GetChange(k string) (interface{}, interface{}) {
+	return g.old[k], g.new[k]
+}
+
+func testAccResourceVSphereVirtualMachinePreCheck(t *testing.T) {
+	// Note that VSPHERE_USE_LINKED_CLONE is also a variable and its presence
+	// speeds up tests greatly, but it's not a necessary variable, so we don't
+	// enforce it here.
+	if os.Getenv("VSPHERE_DATACENTER") == "" {
+		t.Skip("set VSPHERE_DATACENTER to run vsphere_virtual_machine acceptance tests")
+	}
+	if os.Getenv("VSPHERE_CLUSTER") == "" {
+		t.Skip("set VSPHERE_CLUSTER to run vsphere_virtual_machine acceptance tests")
+	}
+	if os.Getenv("VSPHERE_RESOURCE_POOL") == "" {
+		t.Skip("set VSPHERE_RESOURCE_POOL to run vsphere_virtual_machine acceptance tests")
+	}
+	if os.Getenv("VSPHERE_NETWORK_LABEL") == "" {
+		t.Skip("set VSPHERE_NETWORK_LABEL to run vsphere_virtual_machine acceptance tests")
+	}
+	if os.Getenv("VSPHERE_IPV4_ADDRESS") == "" {
+		t.Skip("set VSPHERE_IPV4_ADDRESS to run vsphere_virtual_machine acceptance tests")
+	}
+	if os.Getenv("VSPHERE_IPV4_PREFIX") == "" {
+		t.Skip("set VSPHERE_IPV4_PREFIX to run vsphere_virtual_machine acceptance tests")
+	}
+	if os.Getenv("VSPHERE_IPV4_GATEWAY") == "" {
+		t.Skip("set VSPHERE_IPV4_GATEWAY to run vsphere_virtual_machine acceptance tests")
+	}
+	if os.Getenv("VSPHERE_DATASTORE") == "" {
+		t.Skip("set VSPHERE_DATASTORE to run vsphere_virtual_machine acceptance tests")
+	}
+	if os.Getenv("VSPHERE_TEMPLATE") == "" {
+		t.Skip("set VSPHERE_TEMPLATE to run vsphere_virtual_machine acceptance tests")
+	}
+	if os.Getenv("VSPHERE_TEMPLATE_WINDOWS") == "" {
+		t.Skip("set VSPHERE_TEMPLATE_WINDOWS to run vsphere_virtual_machine acceptance tests")
+	}
+	if os.Getenv("VSPHERE_ESXI_HOST") == "" {
+		t.Skip("set VSPHERE_ESXI_HOST to run vsphere_virtual_machine acceptance tests")
+	}
+	if os.Getenv("VSPHERE_ESXI_HOST2") == "" {
+		t.Skip("set VSPHERE_ESXI_HOST2 to run vsphere_virtual_machine acceptance tests")
+	}
+	if os.Getenv("VSPHERE_ESXI_HOST3") == "" {
+		t.Skip("set VSPHERE_ESXI_HOST3 to run vsphere_virtual_machine acceptance tests")
+	}
+	if os.Getenv("VSPHERE_HOST_NIC0") == "" {
+		t.Skip("set VSPHERE_HOST_NIC0 to run vsphere_virtual_machine acceptance tests")
+	}
+}
+
+func testAccResourceVSphereVirtualMachineCheckExists(expected bool) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		_, err := testGetVirtualMachine(s, "vm")
+		if err != nil {
+			if ok, _ := regexp.MatchString("virtual machine with UUID \"[-a-f0-9]+\" not found", err.Error()); ok && !expected {
+				// Expected missing
+				return nil
+			}
+			return err
+		}
+		if !expected {
+			return errors.New("expected VM to be missing")
+		}
+		return nil
+	}
+}
+
+// testAccResourceVSphereVirtualMachineCheckPowerState is a check to check for
+// a VirtualMachine's power state.
+func testAccResourceVSphereVirtualMachineCheckPowerState(expected types.VirtualMachinePowerState) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		props, err := testGetVirtualMachineProperties(s, "vm")
+		if err != nil {
+			return err
+		}
+		actual := props.Runtime.PowerState
+		if expected != actual {
+			return fmt.Errorf("expected power state to be %s, got %s", expected, actual)
+		}
+		return nil
+	}
+}
+
+// testAccResourceVSphereVirtualMachineCheckHostname is a check to check for a
+// VirtualMachine's hostname. The check uses guest info, so VMware tools needs
+// to be installed.
+func testAccResourceVSphereVirtualMachineCheckHostname(expected string) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		props, err := testGetVirtualMachineProperties(s, "vm")
+		if err != nil {
+			return err
+		}
+		actual := props.Guest.HostName
+		if expected != actual {
+			return fmt.Errorf("expected hostname to be %s, got %s", expected, actual)
+		}
+		return nil
+	}
+}
+
+// testAccResourceVSphereVirtualMachineCheckExtraDisks is a check for proper
+// parameters on the vsphere_virtual_machine extra disks test. This is a very
+// specific check that checks for the specific disk devices and respective
+// backings, and expects them in the exact order outlined in the function.
+func testAccResourceVSphereVirtualMachineCheckExtraDisks() resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		props, err := testGetVirtualMachineProperties(s, "vm")
+		if err != nil {
+			return err
+		}
+
+		var foundEager, foundLazy, foundThin bool
+		expectedEagerName := testAccResourceVSphereVirtualMachineDiskNameEager + ".vmdk"
+		expectedLazyName := testAccResourceVSphereVirtualMachineDiskNameLazy + ".vmdk"
+		expectedThinName := testAccResourceVSphereVirtualMachineDiskNameThin + ".vmdk"
+
+		for _, dev := range props.Config.Hardware.Device {
+			if disk, ok := dev.(*types.VirtualDisk); ok {
+				if info, ok := disk.Backing.(*types.VirtualDiskFlatVer2BackingInfo); ok {
+					var eager bool
+					if info.EagerlyScrub != nil {
+						eager = *info.EagerlyScrub
+					}
+					switch {
+					case strings.HasSuffix(info.FileName, expectedEagerName) && eager:
+						foundEager = true
+					case strings.HasSuffix(info.FileName, expectedLazyName) && !eager:
+						foundLazy = true
+					case strings.HasSuffix(info.FileName, expectedThinName) && *info.ThinProvisioned:
+						foundThin = true
+					}
+				}
+			}
+		}
+
+		if !foundEager {
+			return fmt.Errorf("could not locate disk: %s", expectedEagerName)
+		}
+		if !foundLazy {
+			return fmt.Errorf("could not locate disk: %s", expectedLazyName)
+		}
+		if !foundThin {
+			return fmt.Errorf("could not locate disk: %s", expectedThinName)
+		}
+
+		return nil
+	}
+}
+
+// testAccResourceVSphereVirtualMachineCheckFolder checks to make sure a
+// virtual machine's folder matches the folder supplied with expected.
+func testAccResourceVSphereVirtualMachineCheckFolder(expected string) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		vm, err := testGetVirtualMachine(s, "vm")
+		if err != nil {
+			return fmt.Errorf("bad: %s", err)
+		}
+		expected, err := rootPathParticleVM.PathFromNewRoot(vm.InventoryPath, rootPathParticleVM, expected)
+		actual := path.Dir(vm.InventoryPath)
+		if err != nil {
+			return fmt.Errorf("bad: %s", err)
+		}
+		if expected != actual {
+			return fmt.Errorf("expected path to be %s, got %s", expected, actual)
+		}
+		return nil
+	}
+}
+
+// testAccResourceVSphereVirtualMachineCheckExistingVmdk is a check to make
+// sure that the appropriate disk is attached in the existing VMDK test.
+func testAccResourceVSphereVirtualMachineCheckExistingVmdk() resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		props, err := testGetVirtualMachineProperties(s, "vm")
+		if err != nil {
+			return err
+		}
+
+		expected := testAccResourceVSphereVirtualMachineDiskNameExtraVmdk
+
+		for _, dev := range props.Config.Hardware.Device {
+			if disk, ok := dev.(*types.VirtualDisk); ok {
+				if info, ok := disk.Backing.(*types.VirtualDiskFlatVer2BackingInfo); ok {
+					if strings.HasSuffix(info.FileName, expected) {
+						return nil
+					}
+				}
+			}
+		}
+
+		return fmt.Errorf("could not find attached disk matching %q", expected)
+	}
+}
+
+// testAccResourceVSphereVirtualMachineCheckCPUMem checks the CPU and RAM for a
+// VM.
+func testAccResourceVSphereVirtualMachineCheckCPUMem(expectedCPU, expectedMem int32) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		props, err := testGetVirtualMachineProperties(s, "vm")
+		if err != nil {
+			return err
+		}
+		actualCPU := props.Summary.Config.NumCpu
+		actualMem := props.Summary.Config.MemorySizeMB
+		if expectedCPU != actualCPU {
+			return fmt.Errorf("expected CPU count to be %d, got %d", expectedCPU, actualCPU)
+		}
+		if expectedMem != actualMem {
+			return fmt.Errorf("expected memory size to be %d, got %d", expectedMem, actualMem)
+		}
+		return nil
+	}
+}
+
+// testAccResourceVSphereVirtualMachineCheckBootTime checks that a virtual
+// machine has not been power cycled. The boot time of the virtual machine is
+// saved to bootTime on the first call, and compared against it on subsequent
+// calls.
+func testAccResourceVSphereVirtualMachineCheckBootTime(bootTime *time.Time) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		props, err := testGetVirtualMachineProperties(s, "vm")
+		if err != nil {
+			return err
+		}
+		if props.Runtime.BootTime == nil {
+			return errors.New("virtual machine has no boot time")
+		}
+		if bootTime.IsZero() {
+			*bootTime = *props.Runtime.BootTime
+			return nil
+		}
+		if !bootTime.Equal(*props.Runtime.BootTime) {
+			return fmt.Errorf("expected boot time to be %s, got %s", bootTime, props.Runtime.BootTime)
+		}
+		return nil
+	}
+}
+
+// testAccResourceVSphereVirtualMachineCheckDiskAddress checks that the disk
+// with the supplied name is attached to a controller of the supplied
+// controller_type, at the supplied bus and unit number.
+func testAccResourceVSphereVirtualMachineCheckDiskAddress(name, controllerType string, bus, unit int32) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		props, err := testGetVirtualMachineProperties(s, "vm")
+		if err != nil {
+			return err
+		}
+		devices := object.VirtualDeviceList(props.Config.Hardware.Device)
+		for _, device := range devices.SelectByType((*types.VirtualDisk)(nil)) {
+			disk := device.(*types.VirtualDisk)
+			info, ok := disk.Backing.(*types.VirtualDiskFlatVer2BackingInfo)
+			if !ok || !strings.HasSuffix(info.FileName, name+".vmdk") {
+				continue
+			}
+			controller := devices.FindByKey(disk.ControllerKey)
+			if controller == nil || !diskControllerIsType(controller, controllerType) {
+				return fmt.Errorf("expected disk %q to be attached to a %s controller", name, controllerType)
+			}
+			if actual := controller.(types.BaseVirtualController).GetVirtualController().BusNumber; actual != bus {
+				return fmt.Errorf("expected disk %q to be on bus %d, got %d", name, bus, actual)
+			}
+			if disk.UnitNumber == nil || *disk.UnitNumber != unit {
+				return fmt.Errorf("expected disk %q to be on unit %d", name, unit)
+			}
+			return nil
+		}
+		return fmt.Errorf("could not find disk %q", name)
+	}
+}
+
+// testAccResourceVSphereVirtualMachineCheckDiskBacking checks the disk mode
+// and sharing of the disk with the supplied name on the virtual machine
+// resource with the supplied name.
+func testAccResourceVSphereVirtualMachineCheckDiskBacking(resourceName, name, diskMode, sharing string) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		props, err := testGetVirtualMachineProperties(s, resourceName)
+		if err != nil {
+			return err
+		}
+		for _, device := range object.VirtualDeviceList(props.Config.Hardware.Device).SelectByType((*types.VirtualDisk)(nil)) {
+			info, ok := device.(*types.VirtualDisk).Backing.(*types.VirtualDiskFlatVer2BackingInfo)
+			if !ok || !strings.HasSuffix(info.FileName, name+".vmdk") {
+				continue
+			}
+			if info.DiskMode != diskMode {
+				return fmt.Errorf("expected disk %q to have mode %s, got %s", name, diskMode, info.DiskMode)
+			}
+			if info.Sharing != sharing {
+				return fmt.Errorf("expected disk %q to have sharing %s, got %s", name, sharing, info.Sharing)
+			}
+			return nil
+		}
+		return fmt.Errorf("could not find disk %q on %s", name, resourceName)
+	}
+}
+
+// testAccResourceVSphereVirtualMachineCheckRDM checks that the virtual
+// machine has a raw device mapping to the LUN with the supplied canonical name
+// in the supplied compatibility mode.
+func testAccResourceVSphereVirtualMachineCheckRDM(lun, compatibilityMode string) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		props, err := testGetVirtualMachineProperties(s, "vm")
+		if err != nil {
+			return err
+		}
+		for _, device := range object.VirtualDeviceList(props.Config.Hardware.Device).SelectByType((*types.VirtualDisk)(nil)) {
+			info, ok := device.(*types.VirtualDisk).Backing.(*types.VirtualDiskRawDiskMappingVer1BackingInfo)
+			if !ok || !strings.Contains(info.DeviceName, lun) {
+				continue
+			}
+			if info.CompatibilityMode != compatibilityMode {
+				return fmt.Errorf("expected raw device mapping to %s to have compatibility mode %s, got %s", lun, compatibilityMode, info.CompatibilityMode)
+			}
+			return nil
+		}
+		return fmt.Errorf("could not find a raw device mapping to %s", lun)
+	}
+}
+
+// testAccResourceVSphereVirtualMachineCheckDiskSize checks the size in GB of
+// the disk with the supplied name. If key is 0, it's populated with the device
+// key of the disk, so that later steps can check that the disk was not
+// replaced.
+func testAccResourceVSphereVirtualMachineCheckDiskSize(name string, size int64, key *int32) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		props, err := testGetVirtualMachineProperties(s, "vm")
+		if err != nil {
+			return err
+		}
+		for _, device := range object.VirtualDeviceList(props.Config.Hardware.Device).SelectByType((*types.VirtualDisk)(nil)) {
+			disk := device.(*types.VirtualDisk)
+			info, ok := disk.Backing.(*types.VirtualDiskFlatVer2BackingInfo)
+			if !ok || !strings.HasSuffix(info.FileName, name+".vmdk") {
+				continue
+			}
+			if disk.CapacityInKB != size*1024*1024 {
+				return fmt.Errorf("expected disk %q to be %d GB, got %d KB", name, size, disk.CapacityInKB)
+			}
+			if *key == 0 {
+				*key = disk.Key
+				return nil
+			}
+			if *key != disk.Key {
+				return fmt.Errorf("expected disk %q to have key %d, got %d", name, *key, disk.Key)
+			}
+			return nil
+		}
+		return fmt.Errorf("could not find disk %q", name)
+	}
+}
+
+// testAccResourceVSphereVirtualMachineCheckPlacement checks that the virtual
+// machine runs on the host with the supplied name, and that the disk with the
+// supplied name is on the supplied datastore.
+func testAccResourceVSphereVirtualMachineCheckPlacement(host, diskName, datastore string) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		props, err := testGetVirtualMachineProperties(s, "vm")
+		if err != nil {
+			return err
+		}
+		client := testAccProvider.Meta().(*VSphereClient).vimClient
+		actual, err := hostSystemNameFromID(client, props.Runtime.Host.Value)
+		if err != nil {
+			return err
+		}
+		if actual != host {
+			return fmt.Errorf("expected virtual machine to run on %s, got %s", host, actual)
+		}
+		for _, device := range object.VirtualDeviceList(props.Config.Hardware.Device).SelectByType((*types.VirtualDisk)(nil)) {
+			info, ok := device.(*types.VirtualDisk).Backing.(*types.VirtualDiskFlatVer2BackingInfo)
+			if !ok || !strings.HasSuffix(info.FileName, diskName+".vmdk") {
+				continue
+			}
+			if !strings.HasPrefix(info.FileName, "["+datastore+"]") {
+				return fmt.Errorf("expected disk %q to be on datastore %s, got %s", diskName, datastore, info.FileName)
+			}
+			return nil
+		}
+		return fmt.Errorf("could not find disk %q", diskName)
+	}
+}
+
+// testAccResourceVSphereVirtualMachineCheckMACAddress checks the MAC address
+// of the first network interface of the virtual machine against macAddress.
+// If macAddress is empty, it's populated with the current MAC address, so
+// that later steps can check that it has not changed.
+func testAccResourceVSphereVirtualMachineCheckMACAddress(macAddress *string) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		props, err := testGetVirtualMachineProperties(s, "vm")
+		if err != nil {
+			return err
+		}
+		nics := object.VirtualDeviceList(props.Config.Hardware.Device).SelectByType((*types.VirtualEthernetCard)(nil))
+		if len(nics) < 1 {
+			return errors.New("virtual machine has no network interfaces")
+		}
+		actual := nics[0].(types.BaseVirtualEthernetCard).GetVirtualEthernetCard().MacAddress
+		if *macAddress == "" {
+			*macAddress = actual
+			return nil
+		}
+		if *macAddress != actual {
+			return fmt.Errorf("expected MAC address to be %s, got %s", *macAddress, actual)
+		}
+		return nil
+	}
+}
+
+// testAccResourceVSphereVirtualMachineCheckNet checks to make sure a virtual
+// machine's primary NIC has the given IP address and netmask assigned to it,
+// and that the appropriate gateway is present.
+//
+// This uses VMware tools to check this, so it needs to be installed on the
+// guest.
+func testAccResourceVSphereVirtualMachineCheckNet(expectedAddr, expectedPrefix, expectedGW string) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		res, err := strconv.Atoi(expectedPrefix)
+		if err != nil {
+			return fmt.Errorf("bad: %s", err)
+		}
+		expectedPrefixInt := int32(res)
+
+		props, err := testGetVirtualMachineProperties(s, "vm")
+		if err != nil {
+			return err
+		}
+		var v4gw, v6gw net.IP
+		for _, s := range props.Guest.IpStack {
+			if s.IpRouteConfig != nil {
+				for _, r := range s.IpRouteConfig.IpRoute {
+					switch r.Network {
+					case "0.0.0.0":
+						v4gw = net.ParseIP(r.Gateway.IpAddress)
+					case "::":
+						v6gw = net.ParseIP(r.Gateway.IpAddress)
+					}
+				}
+			}
+		}
+		for _, n := range props.Guest.Net {
+			if n.IpConfig != nil {
+				for _, addr := range n.IpConfig.IpAddress {
+					ip := net.ParseIP(addr.IpAddress)
+					if !ip.Equal(net.ParseIP(expectedAddr)) {
+						continue
+					}
+					if addr.PrefixLength != expectedPrefixInt {
+						continue
+					}
+					var mask net.IPMask
+					if ip.To4() != nil {
+						mask = net.CIDRMask(int(addr.PrefixLength), 32)
+					} else {
+						mask = net.CIDRMask(int(addr.PrefixLength), 128)
+					}
+					switch {
+					case ip.Mask(mask).Equal(v4gw.Mask(mask)):
+						if net.ParseIP(expectedGW).Equal(v4gw) {
+							return nil
+						}
+					case ip.Mask(mask).Equal(v6gw.Mask(mask)):
+						if net.ParseIP(expectedGW).Equal(v6gw) {
+							return nil
+						}
+					}
+				}
+			}
+		}
+
+		return fmt.Errorf("could not find IP %s/%s, gateway %s", expectedAddr, expectedPrefix, expectedGW)
+	}
+}
+
+// testAccResourceVSphereVirtualMachineCheckStaticMACAddr is a check to look
+// for the MAC address defined in the static MAC address test on the first
+// network interface.
+func testAccResourceVSphereVirtualMachineCheckStaticMACAddr() resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		props, err := testGetVirtualMachineProperties(s, "vm")
+		if err != nil {
+			return err
+		}
+		actual := props.Guest.Net[0].MacAddress
+		expected := testAccResourceVSphereVirtualMachineStaticMacAddr
+		if expected != actual {
+			return fmt.Errorf("expected MAC address to be %s, got %s", expected, actual)
+		}
+		return nil
 	}
+}
+
+// testAccResourceVSphereVirtualMachineCheckAnnotation is a check to ensure
+// that a VM's annotation is correctly set in the annotation test.
+func testAccResourceVSphereVirtualMachineCheckAnnotation() resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		props, err := testGetVirtualMachineProperties(s, "vm")
+		if err != nil {
+			return err
+		}
+		expected := testAccResourceVSphereVirtualMachineAnnotation
+		actual := props.Config.Annotation
+		if expected != actual {
+			return fmt.Errorf("expected annotation to be %q, got %q", expected, actual)
+		}
+		return nil
+	}
+}
+
+// testAccResourceVSphereVirtualMachineCheckCustomizationSucceeded is a check
+// to ensure that events have been received for customization success on a VM.
+func testAccResourceVSphereVirtualMachineCheckCustomizationSucceeded() resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		vm, err := testGetVirtualMachine(s, "vm")
+		if err != nil {
+			return err
+		}
+		client := testAccProvider.Meta().(*VSphereClient).vimClient
+		actual, err := selectEventsForReference(client, vm.Reference(), []string{eventTypeCustomizationSucceeded})
+		if err != nil {
+			return err
+		}
+		if len(actual) < 1 {
+			return errors.New("customization success event was not received")
+		}
+		return nil
+	}
+}
+
+// testAccResourceVSphereVirtualMachineCheckTags is a check to ensure that any
+// tags that have been created with supplied resource name have been attached
+// to the virtual machine.
+func testAccResourceVSphereVirtualMachineCheckTags(tagResName string) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		vm, err := testGetVirtualMachine(s, "vm")
+		if err != nil {
+			return err
+		}
+		tagsClient, err := testAccProvider.Meta().(*VSphereClient).TagsClient()
+		if err != nil {
+			return err
+		}
+		return testObjectHasTags(s, tagsClient, vm, tagResName)
+	}
+}
+
+func testAccResourceVSphereVirtualMachineConfigBasic() string {
+	return fmt.Sprintf(`
+variable "datacenter" {
+  default = "%s"
+}
+
+variable "cluster" {
+  default = "%s"
+}
+
+variable "resource_pool" {
+  default = "%s"
+}
+
+variable "network_label" {
+  default = "%s"
+}
+
+variable "ipv4_address" {
+  default = "%s"
+}
+
+variable "ipv4_prefix" {
+  default = "%s"
+}
+
+variable "ipv4_gateway" {
+  default = "%s"
+}
+
+variable "datastore" {
+  default = "%s"
+}
+
+variable "template" {
+  default = "%s"
+}
+
+variable "linked_clone" {
+  default = "%s"
+}
+
+resource "vsphere_virtual_machine" "vm" {
+  name          = "terraform-test"
+  datacenter    = "${var.datacenter}"
+  cluster       = "${var.cluster}"
+  resource_pool = "${var.resource_pool}"
+
+  vcpu   = 2
+  memory = 1024
+
+  network_interface {
+    label              = "${var.network_label}"
+    ipv4_address       = "${var.ipv4_address}"
+    ipv4_prefix_length = "${var.ipv4_prefix}"
+    ipv4_gateway       = "${var.ipv4_gateway}"
+  }
+
+  disk {
+    datastore = "${var.datastore}"
+    template  = "${var.template}"
+    iops      = 500
+  }
+
+  linked_clone = "${var.linked_clone != "" ? "true" : "false" }"
+}
+`,
+		os.Getenv("VSPHERE_DATACENTER"),
+		os.Getenv("VSPHERE_CLUSTER"),
+		os.Getenv("VSPHERE_RESOURCE_POOL"),
+		os.Getenv("VSPHERE_NETWORK_LABEL"),
+		os.Getenv("VSPHERE_IPV4_ADDRESS"),
+		os.Getenv("VSPHERE_IPV4_PREFIX"),
+		os.Getenv("VSPHERE_IPV4_GATEWAY"),
+		os.Getenv("VSPHERE_DATASTORE"),
+		os.Getenv("VSPHERE_TEMPLATE"),
+		os.Getenv("VSPHERE_USE_LINKED_CLONE"),
+	)
+}
+
+func testAccResourceVSphereVirtualMachineConfigBeefy() string {
+	return fmt.Sprintf(`
+variable "datacenter" {
+  default = "%s"
+}
+
+variable "cluster" {
+  default = "%s"
+}
+
+variable "resource_pool" {
+  default = "%s"
+}
+
+variable "network_label" {
+  default = "%s"
+}
+
+variable "ipv4_address" {
+  default = "%s"
+}
+
+variable "ipv4_prefix" {
+  default = "%s"
+}
+
+variable "ipv4_gateway" {
+  default = "%s"
+}
+
+variable "datastore" {
+  default = "%s"
+}
+
+variable "template" {
+  default = "%s"
+}
+
+variable "linked_clone" {
+  default = "%s"
+}
+
+resource "vsphere_virtual_machine" "vm" {
+  name          = "terraform-test"
+  datacenter    = "${var.datacenter}"
+  cluster       = "${var.cluster}"
+  resource_pool = "${var.resource_pool}"
+
+  vcpu   = 4
+  memory = 8192
+
+  network_interface {
+    label              = "${var.network_label}"
+    ipv4_address       = "${var.ipv4_address}"
+    ipv4_prefix_length = "${var.ipv4_prefix}"
+    ipv4_gateway       = "${var.ipv4_gateway}"
+  }
+
+  disk {
+    datastore = "${var.datastore}"
+    template  = "${var.template}"
+    iops      = 500
+  }
+
+  linked_clone = "${var.linked_clone != "" ? "true" : "false" }"
+}
+`,
+		os.Getenv("VSPHERE_DATACENTER"),
+		os.Getenv("VSPHERE_CLUSTER"),
+		os.Getenv("VSPHERE_RESOURCE_POOL"),
+		os.Getenv("VSPHERE_NETWORK_LABEL"),
+		os.Getenv("VSPHERE_IPV4_ADDRESS"),
+		os.Getenv("VSPHERE_IPV4_PREFIX"),
+		os.Getenv("VSPHERE_IPV4_GATEWAY"),
+		os.Getenv("VSPHERE_DATASTORE"),
+		os.Getenv("VSPHERE_TEMPLATE"),
+		os.Getenv("VSPHERE_USE_LINKED_CLONE"),
+	)
+}
+
+func testAccResourceVSphereVirtualMachineConfigSeparateHostname() string {
+	return fmt.Sprintf(`
+variable "datacenter" {
+  default = "%s"
+}
+
+variable "cluster" {
+  default = "%s"
+}
+
+variable "resource_pool" {
+  default = "%s"
+}
+
+variable "network_label" {
+  default = "%s"
+}
+
+variable "ipv4_address" {
+  default = "%s"
+}
+
+variable "ipv4_prefix" {
+  default = "%s"
+}
+
+variable "ipv4_gateway" {
+  default = "%s"
+}
+
+variable "datastore" {
+  default = "%s"
+}
+
+variable "template" {
+  default = "%s"
+}
+
+variable "linked_clone" {
+  default = "%s"
+}
+
+resource "vsphere_virtual_machine" "vm" {
+  name          = "terraform-test"
+  datacenter    = "${var.datacenter}"
+  cluster       = "${var.cluster}"
+  resource_pool = "${var.resource_pool}"
+
+  vcpu   = 2
+  memory = 1024
+
+  hostname = "terraform-test-renamed"
+
+  network_interface {
+    label              = "${var.network_label}"
+    ipv4_address       = "${var.ipv4_address}"
+    ipv4_prefix_length = "${var.ipv4_prefix}"
+    ipv4_gateway       = "${var.ipv4_gateway}"
+  }
+
+  disk {
+    datastore = "${var.datastore}"
+    template  = "${var.template}"
+    iops      = 500
+  }
+
+  linked_clone = "${var.linked_clone != "" ? "true" : "false" }"
+}
+`,
+		os.Getenv("VSPHERE_DATACENTER"),
+		os.Getenv("VSPHERE_CLUSTER"),
+		os.Getenv("VSPHERE_RESOURCE_POOL"),
+		os.Getenv("VSPHERE_NETWORK_LABEL"),
+		os.Getenv("VSPHERE_IPV4_ADDRESS"),
+		os.Getenv("VSPHERE_IPV4_PREFIX"),
+		os.Getenv("VSPHERE_IPV4_GATEWAY"),
+		os.Getenv("VSPHERE_DATASTORE"),
+		os.Getenv("VSPHERE_TEMPLATE"),
+		os.Getenv("VSPHERE_USE_LINKED_CLONE"),
+	)
+}
+
+func testAccResourceVSphereVirtualMachineConfigExtraDisks() string {
+	return fmt.Sprintf(`
+variable "datacenter" {
+  default = "%s"
+}
+
+variable "cluster" {
+  default = "%s"
+}
+
+variable "resource_pool" {
+  default = "%s"
+}
+
+variable "network_label" {
+  default = "%s"
+}
+
+variable "ipv4_address" {
+  default = "%s"
+}
+
+variable "ipv4_prefix" {
+  default = "%s"
+}
+
+variable "ipv4_gateway" {
+  default = "%s"
+}
+
+variable "datastore" {
+  default = "%s"
+}
+
+variable "template" {
+  default = "%s"
+}
+
+variable "linked_clone" {
+  default = "%s"
+}
+
+variable "disk_name_eager" {
+  default = "%s"
+}
+
+variable "disk_name_lazy" {
+  default = "%s"
+}
+
+variable "disk_name_thin" {
+  default = "%s"
+}
+
+resource "vsphere_virtual_machine" "vm" {
+  name          = "terraform-test"
+  datacenter    = "${var.datacenter}"
+  cluster       = "${var.cluster}"
+  resource_pool = "${var.resource_pool}"
+
+  vcpu   = 2
+  memory = 1024
+
+  network_interface {
+    label              = "${var.network_label}"
+    ipv4_address       = "${var.ipv4_address}"
+    ipv4_prefix_length = "${var.ipv4_prefix}"
+    ipv4_gateway       = "${var.ipv4_gateway}"
+  }
+
+  disk {
+    datastore = "${var.datastore}"
+    template  = "${var.template}"
+    iops      = 500
+  }
+
+  disk {
+    size = 1
+    type = "eager_zeroed"
+    name = "${var.disk_name_eager}"
+  }
+
+  disk {
+    size = 1
+    type = "lazy"
+    name = "${var.disk_name_lazy}"
+  }
+  
+	disk {
+    size = 1
+    type = "thin"
+    name = "${var.disk_name_thin}"
+  }
+
+  linked_clone = "${var.linked_clone != "" ? "true" : "false" }"
+}
+`,
+		os.Getenv("VSPHERE_DATACENTER"),
+		os.Getenv("VSPHERE_CLUSTER"),
+		os.Getenv("VSPHERE_RESOURCE_POOL"),
+		os.Getenv("VSPHERE_NETWORK_LABEL"),
+		os.Getenv("VSPHERE_IPV4_ADDRESS"),
+		os.Getenv("VSPHERE_IPV4_PREFIX"),
+		os.Getenv("VSPHERE_IPV4_GATEWAY"),
+		os.Getenv("VSPHERE_DATASTORE"),
+		os.Getenv("VSPHERE_TEMPLATE"),
+		os.Getenv("VSPHERE_USE_LINKED_CLONE"),
+		testAccResourceVSphereVirtualMachineDiskNameEager,
+		testAccResourceVSphereVirtualMachineDiskNameLazy,
+		testAccResourceVSphereVirtualMachineDiskNameThin,
+	)
+}
+
+func testAccResourceVSphereVirtualMachineConfigCustomConfig() string {
+	return fmt.Sprintf(`
+variable "datacenter" {
+  default = "%s"
+}
+
+variable "cluster" {
+  default = "%s"
+}
+
+variable "resource_pool" {
+  default = "%s"
+}
+
+variable "network_label" {
+  default = "%s"
+}
+
+variable "ipv4_address" {
+  default = "%s"
+}
+
+variable "ipv4_prefix" {
+  default = "%s"
+}
+
+variable "ipv4_gateway" {
+  default = "%s"
+}
+
+variable "datastore" {
+  default = "%s"
+}
+
+variable "template" {
+  default = "%s"
+}
+
+variable "linked_clone" {
+  default = "%s"
+}
+
+resource "vsphere_virtual_machine" "vm" {
+  name          = "terraform-test"
+  datacenter    = "${var.datacenter}"
+  cluster       = "${var.cluster}"
+  resource_pool = "${var.resource_pool}"
+
+  vcpu   = 2
+  memory = 1024
+
+  network_interface {
+    label              = "${var.network_label}"
+    ipv4_address       = "${var.ipv4_address}"
+    ipv4_prefix_length = "${var.ipv4_prefix}"
+    ipv4_gateway       = "${var.ipv4_gateway}"
+  }
+
+  custom_configuration_parameters {
+    "foo" = "bar"
+    "baz" = "qux"
+  }
+
+  disk {
+    datastore = "${var.datastore}"
+    template  = "${var.template}"
+    iops      = 500
+  }
+
+  linked_clone = "${var.linked_clone != "" ? "true" : "false" }"
+}
+`,
+		os.Getenv("VSPHERE_DATACENTER"),
+		os.Getenv("VSPHERE_CLUSTER"),
+		os.Getenv("VSPHERE_RESOURCE_POOL"),
+		os.Getenv("VSPHERE_NETWORK_LABEL"),
+		os.Getenv("VSPHERE_IPV4_ADDRESS"),
+		os.Getenv("VSPHERE_IPV4_PREFIX"),
+		os.Getenv("VSPHERE_IPV4_GATEWAY"),
+		os.Getenv("VSPHERE_DATASTORE"),
+		os.Getenv("VSPHERE_TEMPLATE"),
+		os.Getenv("VSPHERE_USE_LINKED_CLONE"),
+	)
+}
+
+func testAccResourceVSphereVirtualMachineConfigInFolder() string {
+	return fmt.Sprintf(`
+variable "datacenter" {
+  default = "%s"
+}
+
+variable "cluster" {
+  default = "%s"
+}
+
+variable "resource_pool" {
+  default = "%s"
+}
+
+variable "network_label" {
+  default = "%s"
+}
+
+variable "ipv4_address" {
+  default = "%s"
+}
+
+variable "ipv4_prefix" {
+  default = "%s"
+}
+
+variable "ipv4_gateway" {
+  default = "%s"
+}
+
+variable "datastore" {
+  default = "%s"
+}
+
+variable "template" {
+  default = "%s"
+}
+
+variable "linked_clone" {
+  default = "%s"
+}
+
+data "vsphere_datacenter" "datacenter" {
+  name = "${var.datacenter}"
+}
+
+resource "vsphere_folder" "folder" {
+  path          = "terraform-test-vms"
+  type          = "vm"
+  datacenter_id = "${data.vsphere_datacenter.datacenter.id}"
+}
+
+resource "vsphere_virtual_machine" "vm" {
+  name          = "terraform-test"
+  datacenter    = "${var.datacenter}"
+  cluster       = "${var.cluster}"
+  resource_pool = "${var.resource_pool}"
+  folder        = "${vsphere_folder.folder.path}"
+
+  vcpu   = 2
+  memory = 1024
+
+  network_interface {
+    label              = "${var.network_label}"
+    ipv4_address       = "${var.ipv4_address}"
+    ipv4_prefix_length = "${var.ipv4_prefix}"
+    ipv4_gateway       = "${var.ipv4_gateway}"
+  }
+
+  disk {
+    datastore = "${var.datastore}"
+    template  = "${var.template}"
+    iops      = 500
+  }
+
+  linked_clone = "${var.linked_clone != "" ? "true" : "false" }"
+}
+`,
+		os.Getenv("VSPHERE_DATACENTER"),
+		os.Getenv("VSPHERE_CLUSTER"),
+		os.Getenv("VSPHERE_RESOURCE_POOL"),
+		os.Getenv("VSPHERE_NETWORK_LABEL"),
+		os.Getenv("VSPHERE_IPV4_ADDRESS"),
+		os.Getenv("VSPHERE_IPV4_PREFIX"),
+		os.Getenv("VSPHERE_IPV4_GATEWAY"),
+		os.Getenv("VSPHERE_DATASTORE"),
+		os.Getenv("VSPHERE_TEMPLATE"),
+		os.Getenv("VSPHERE_USE_LINKED_CLONE"),
+	)
+}
+
+func testAccResourceVSphereVirtualMachineConfigExistingVmdk() string {
+	return fmt.Sprintf(`
+variable "datacenter" {
+  default = "%s"
+}
+
+variable "cluster" {
+  default = "%s"
+}
+
+variable "resource_pool" {
+  default = "%s"
+}
+
+variable "network_label" {
+  default = "%s"
+}
+
+variable "ipv4_address" {
+  default = "%s"
+}
+
+variable "ipv4_prefix" {
+  default = "%s"
+}
+
+variable "ipv4_gateway" {
+  default = "%s"
+}
+
+variable "datastore" {
+  default = "%s"
+}
+
+variable "template" {
+  default = "%s"
+}
+
+variable "linked_clone" {
+  default = "%s"
+}
+
+variable "extra_vmdk_name" {
+  default = "%s"
+}
+
+resource "vsphere_virtual_disk" "disk" {
+  size         = 1
+  vmdk_path    = "${var.extra_vmdk_name}"
+  datacenter   = "${var.datacenter}"
+  datastore    = "${var.datastore}"
+  type         = "thin"
+  adapter_type = "lsiLogic"
+}
+
+resource "vsphere_virtual_machine" "vm" {
+  name          = "terraform-test"
+  datacenter    = "${var.datacenter}"
+  cluster       = "${var.cluster}"
+  resource_pool = "${var.resource_pool}"
+
+  vcpu   = 2
+  memory = 1024
+
+  network_interface {
+    label              = "${var.network_label}"
+    ipv4_address       = "${var.ipv4_address}"
+    ipv4_prefix_length = "${var.ipv4_prefix}"
+    ipv4_gateway       = "${var.ipv4_gateway}"
+  }
+
+  disk {
+    datastore = "${var.datastore}"
+    template  = "${var.template}"
+    iops      = 500
+  }
+
+  disk {
+    datastore      = "${var.datastore}"
+    vmdk           = "${vsphere_virtual_disk.disk.vmdk_path}"
+    keep_on_remove = true
+  }
+
+  linked_clone = "${var.linked_clone != "" ? "true" : "false" }"
+}
+`,
+		os.Getenv("VSPHERE_DATACENTER"),
+		os.Getenv("VSPHERE_CLUSTER"),
+		os.Getenv("VSPHERE_RESOURCE_POOL"),
+		os.Getenv("VSPHERE_NETWORK_LABEL"),
+		os.Getenv("VSPHERE_IPV4_ADDRESS"),
+		os.Getenv("VSPHERE_IPV4_PREFIX"),
+		os.Getenv("VSPHERE_IPV4_GATEWAY"),
+		os.Getenv("VSPHERE_DATASTORE"),
+		os.Getenv("VSPHERE_TEMPLATE"),
+		os.Getenv("VSPHERE_USE_LINKED_CLONE"),
+		testAccResourceVSphereVirtualMachineDiskNameExtraVmdk,
+	)
+}
+
+func testAccResourceVSphereVirtualMachineConfigImportVmdk() string {
+	return fmt.Sprintf(`
+variable "datacenter" {
+  default = "%s"
+}
+
+variable "cluster" {
+  default = "%s"
+}
+
+variable "resource_pool" {
+  default = "%s"
+}
+
+variable "network_label" {
+  default = "%s"
+}
+
+variable "datastore" {
+  default = "%s"
+}
+
+variable "extra_vmdk_name" {
+  default = "%s"
+}
+
+resource "vsphere_virtual_disk" "disk" {
+  size         = 1
+  vmdk_path    = "${var.extra_vmdk_name}"
+  datacenter   = "${var.datacenter}"
+  datastore    = "${var.datastore}"
+  type         = "thin"
+  adapter_type = "lsiLogic"
+}
+
+resource "vsphere_virtual_machine" "vm" {
+  name          = "terraform-test"
+  datacenter    = "${var.datacenter}"
+  cluster       = "${var.cluster}"
+  resource_pool = "${var.resource_pool}"
+  power_state   = "poweredOff"
+
+  vcpu   = 2
+  memory = 1024
+
+  network_interface {
+    label = "${var.network_label}"
+  }
+
+  disk {
+    datastore      = "${var.datastore}"
+    vmdk           = "${vsphere_virtual_disk.disk.vmdk_path}"
+    bootable       = true
+    keep_on_remove = true
+  }
+}
+`,
+		os.Getenv("VSPHERE_DATACENTER"),
+		os.Getenv("VSPHERE_CLUSTER"),
+		os.Getenv("VSPHERE_RESOURCE_POOL"),
+		os.Getenv("VSPHERE_NETWORK_LABEL"),
+		os.Getenv("VSPHERE_DATASTORE"),
+		testAccResourceVSphereVirtualMachineDiskNameExtraVmdk,
+	)
+}
+
+func testAccResourceVSphereVirtualMachineConfigDualStack() string {
+	return fmt.Sprintf(`
+variable "datacenter" {
+  default = "%s"
+}
+
+variable "cluster" {
+  default = "%s"
+}
+
+variable "resource_pool" {
+  default = "%s"
+}
+
+variable "network_label" {
+  default = "%s"
+}
+
+variable "ipv4_address" {
+  default = "%s"
+}
+
+variable "ipv4_prefix" {
+  default = "%s"
+}
+
+variable "ipv4_gateway" {
+  default = "%s"
+}
+
+variable "datastore" {
+  default = "%s"
+}
+
+variable "template" {
+  default = "%s"
+}
+
+variable "linked_clone" {
+  default = "%s"
+}
+
+resource "vsphere_virtual_machine" "vm" {
+  name          = "terraform-test"
+  datacenter    = "${var.datacenter}"
+  cluster       = "${var.cluster}"
+  resource_pool = "${var.resource_pool}"
+
+  vcpu   = 2
+  memory = 1024
+
+  network_interface {
+    label              = "${var.network_label}"
+    ipv4_address       = "${var.ipv4_address}"
+    ipv4_prefix_length = "${var.ipv4_prefix}"
+    ipv4_gateway       = "${var.ipv4_gateway}"
+    ipv6_address       = "fd00::2"
+    ipv6_prefix_length = "32"
+    ipv6_gateway       = "fd00::1"
+  }
+
+  disk {
+    datastore = "${var.datastore}"
+    template  = "${var.template}"
+    iops      = 500
+  }
+
+  linked_clone = "${var.linked_clone != "" ? "true" : "false" }"
+}
+`,
+		os.Getenv("VSPHERE_DATACENTER"),
+		os.Getenv("VSPHERE_CLUSTER"),
+		os.Getenv("VSPHERE_RESOURCE_POOL"),
+		os.Getenv("VSPHERE_NETWORK_LABEL"),
+		os.Getenv("VSPHERE_IPV4_ADDRESS"),
+		os.Getenv("VSPHERE_IPV4_PREFIX"),
+		os.Getenv("VSPHERE_IPV4_GATEWAY"),
+		os.Getenv("VSPHERE_DATASTORE"),
+		os.Getenv("VSPHERE_TEMPLATE"),
+		os.Getenv("VSPHERE_USE_LINKED_CLONE"),
+	)
+}
+
+func testAccResourceVSphereVirtualMachineConfigStaticMAC() string {
+	return fmt.Sprintf(`
+variable "datacenter" {
+  default = "%s"
+}
+
+variable "cluster" {
+  default = "%s"
+}
+
+variable "resource_pool" {
+  default = "%s"
+}
+
+variable "network_label" {
+  default = "%s"
+}
+
+variable "ipv4_address" {
+  default = "%s"
+}
+
+variable "ipv4_prefix" {
+  default = "%s"
+}
+
+variable "ipv4_gateway" {
+  default = "%s"
+}
+
+variable "datastore" {
+  default = "%s"
+}
+
+variable "template" {
+  default = "%s"
+}
+
+variable "linked_clone" {
+  default = "%s"
+}
+
+variable "static_mac_addr" {
+  default = "%s"
+}
+
+resource "vsphere_virtual_machine" "vm" {
+  name          = "terraform-test"
+  datacenter    = "${var.datacenter}"
+  cluster       = "${var.cluster}"
+  resource_pool = "${var.resource_pool}"
+
+  vcpu   = 2
+  memory = 1024
+
+  network_interface {
+    label              = "${var.network_label}"
+    mac_address        = "${var.static_mac_addr}"
+    ipv4_address       = "${var.ipv4_address}"
+    ipv4_prefix_length = "${var.ipv4_prefix}"
+    ipv4_gateway       = "${var.ipv4_gateway}"
+  }
+
+  disk {
+    datastore = "${var.datastore}"
+    template  = "${var.template}"
+    iops      = 500
+  }
 
-	for _, tc := range testAccResourceVSphereVirtualMachineCases {
-		t.Run(tc.name, func(t *testing.T) {
-			tp = t
-			resource.Test(t, tc.testCase)
-		})
-	}
+  linked_clone = "${var.linked_clone != "" ? "true" : "false" }"
+}
+`,
+		os.Getenv("VSPHERE_DATACENTER"),
+		os.Getenv("VSPHERE_CLUSTER"),
+		os.Getenv("VSPHERE_RESOURCE_POOL"),
+		os.Getenv("VSPHERE_NETWORK_LABEL"),
+		os.Getenv("VSPHERE_IPV4_ADDRESS"),
+		os.Getenv("VSPHERE_IPV4_PREFIX"),
+		os.Getenv("VSPHERE_IPV4_GATEWAY"),
+		os.Getenv("VSPHERE_DATASTORE"),
+		os.Getenv("VSPHERE_TEMPLATE"),
+		os.Getenv("VSPHERE_USE_LINKED_CLONE"),
+		testAccResourceVSphereVirtualMachineStaticMacAddr,
+	)
 }
 
-func testAccResourceVSphereVirtualMachinePreCheck(t *testing.T) {
-	// Note that VSPHERE_USE_LINKED_CLONE is also a variable and its presence
-	// speeds up tests greatly, but it's not a necessary variable, so we don't
-	// enforce it here.
-	if os.Getenv("VSPHERE_DATACENTER") == "" {
-		t.Skip("set VSPHERE_DATACENTER to run vsphere_virtual_machine acceptance tests")
-	}
-	if os.Getenv("VSPHERE_CLUSTER") == "" {
-		t.Skip("set VSPHERE_CLUSTER to run vsphere_virtual_machine acceptance tests")
-	}
-	if os.Getenv("VSPHERE_RESOURCE_POOL") == "" {
-		t.Skip("set VSPHERE_RESOURCE_POOL to run vsphere_virtual_machine acceptance tests")
-	}
-	if os.Getenv("VSPHERE_NETWORK_LABEL") == "" {
-		t.Skip("set VSPHERE_NETWORK_LABEL to run vsphere_virtual_machine acceptance tests")
-	}
-	if os.Getenv("VSPHERE_IPV4_ADDRESS") == "" {
-		t.Skip("set VSPHERE_IPV4_ADDRESS to run vsphere_virtual_machine acceptance tests")
-	}
-	if os.Getenv("VSPHERE_IPV4_PREFIX") == "" {
-		t.Skip("set VSPHERE_IPV4_PREFIX to run vsphere_virtual_machine acceptance tests")
-	}
-	if os.Getenv("VSPHERE_IPV4_GATEWAY") == "" {
-		t.Skip("set VSPHERE_IPV4_GATEWAY to run vsphere_virtual_machine acceptance tests")
-	}
-	if os.Getenv("VSPHERE_DATASTORE") == "" {
-		t.Skip("set VSPHERE_DATASTORE to run vsphere_virtual_machine acceptance tests")
-	}
-	if os.Getenv("VSPHERE_TEMPLATE") == "" {
-		t.Skip("set VSPHERE_TEMPLATE to run vsphere_virtual_machine acceptance tests")
-	}
-	if os.Getenv("VSPHERE_TEMPLATE_WINDOWS") == "" {
-		t.Skip("set VSPHERE_TEMPLATE_WINDOWS to run vsphere_virtual_machine acceptance tests")
-	}
-	if os.Getenv("VSPHERE_ESXI_HOST") == "" {
-		t.Skip("set VSPHERE_ESXI_HOST to run vsphere_virtual_machine acceptance tests")
-	}
-	if os.Getenv("VSPHERE_ESXI_HOST2") == "" {
-		t.Skip("set VSPHERE_ESXI_HOST2 to run vsphere_virtual_machine acceptance tests")
-	}
-	if os.Getenv("VSPHERE_ESXI_HOST3") == "" {
-		t.Skip("set VSPHERE_ESXI_HOST3 to run vsphere_virtual_machine acceptance tests")
-	}
-	if os.Getenv("VSPHERE_HOST_NIC0") == "" {
-		t.Skip("set VSPHERE_HOST_NIC0 to run vsphere_virtual_machine acceptance tests")
-	}
+func testAccResourceVSphereVirtualMachineConfigWithAnnotation() string {
+	return fmt.Sprintf(`
+variable "datacenter" {
+  default = "%s"
 }
 
-func testAccResourceVSphereVirtualMachineCheckExists(expected bool) resource.TestCheckFunc {
-	return func(s *terraform.State) error {
-		_, err := testGetVirtualMachine(s, "vm")
-		if err != nil {
-			if ok, _ := regexp.MatchString("virtual machine with UUID \"[-a-f0-9]+\" not found", err.Error()); ok && !expected {
-				// Expected missing
-				return nil
-			}
-			return err
-		}
-		if !expected {
-			return errors.New("expected VM to be missing")
-		}
-		return nil
-	}
+variable "cluster" {
+  default = "%s"
 }
 
-// testAccResourceVSphereVirtualMachineCheckPowerState is a check to check for
-// a VirtualMachine's power state.
-func testAccResourceVSphereVirtualMachineCheckPowerState(expected types.VirtualMachinePowerState) resource.TestCheckFunc {
-	return func(s *terraform.State) error {
-		props, err := testGetVirtualMachineProperties(s, "vm")
-		if err != nil {
-			return err
-		}
-		actual := props.Runtime.PowerState
-		if expected != actual {
-			return fmt.Errorf("expected power state to be %s, got %s", expected, actual)
-		}
-		return nil
-	}
+variable "resource_pool" {
+  default = "%s"
 }
 
-// testAccResourceVSphereVirtualMachineCheckHostname is a check to check for a
-// VirtualMachine's hostname. The check uses guest info, so VMware tools needs
-// to be installed.
-func testAccResourceVSphereVirtualMachineCheckHostname(expected string) resource.TestCheckFunc {
-	return func(s *terraform.State) error {
-		props, err := testGetVirtualMachineProperties(s, "vm")
-		if err != nil {
-			return err
-		}
-		actual := props.Guest.HostName
-		if expected != actual {
-			return fmt.Errorf("expected hostname to be %s, got %s", expected, actual)
-		}
-		return nil
-	}
+variable "network_label" {
+  default = "%s"
 }
 
-// testAccResourceVSphereVirtualMachineCheckExtraDisks is a check for proper
-// parameters on the vsphere_virtual_machine extra disks test. This is a very
-// specific check that checks for the specific disk devices and respective
-// backings, and expects them in the exact order outlined in the function.
-func testAccResourceVSphereVirtualMachineCheckExtraDisks() resource.TestCheckFunc {
-	return func(s *terraform.State) error {
-		props, err := testGetVirtualMachineProperties(s, "vm")
-		if err != nil {
-			return err
-		}
+variable "ipv4_address" {
+  default = "%s"
+}
 
-		var foundEager, foundLazy, foundThin bool
-		expectedEagerName := testAccResourceVSphereVirtualMachineDiskNameEager + ".vmdk"
-		expectedLazyName := testAccResourceVSphereVirtualMachineDiskNameLazy + ".vmdk"
-		expectedThinName := testAccResourceVSphereVirtualMachineDiskNameThin + ".vmdk"
+variable "ipv4_prefix" {
+  default = "%s"
+}
 
-		for _, dev := range props.Config.Hardware.Device {
-			if disk, ok := dev.(*types.VirtualDisk); ok {
-				if info, ok := disk.Backing.(*types.VirtualDiskFlatVer2BackingInfo); ok {
-					var eager bool
-					if info.EagerlyScrub != nil {
-						eager = *info.EagerlyScrub
-					}
-					switch {
-					case strings.HasSuffix(info.FileName, expectedEagerName) && eager:
-						foundEager = true
-					case strings.HasSuffix(info.FileName, expectedLazyName) && !eager:
-						foundLazy = true
-					case strings.HasSuffix(info.FileName, expectedThinName) && *info.ThinProvisioned:
-						foundThin = true
-					}
-				}
-			}
-		}
+variable "ipv4_gateway" {
+  default = "%s"
+}
 
-		if !foundEager {
-			return fmt.Errorf("could not locate disk: %s", expectedEagerName)
-		}
-		if !foundLazy {
-			return fmt.Errorf("could not locate disk: %s", expectedLazyName)
-		}
-		if !foundThin {
-			return fmt.Errorf("could not locate disk: %s", expectedThinName)
-		}
+variable "datastore" {
+  default = "%s"
+}
+
+variable "template" {
+  default = "%s"
+}
+
+variable "linked_clone" {
+  default = "%s"
+}
+
+variable "annotation" {
+	default = "%s"
+}
+
+resource "vsphere_virtual_machine" "vm" {
+  name          = "terraform-test"
+  datacenter    = "${var.datacenter}"
+  cluster       = "${var.cluster}"
+  resource_pool = "${var.resource_pool}"
+  annotation    = "${var.annotation}"
+
+  vcpu   = 2
+  memory = 1024
+
+  network_interface {
+    label              = "${var.network_label}"
+    ipv4_address       = "${var.ipv4_address}"
+    ipv4_prefix_length = "${var.ipv4_prefix}"
+    ipv4_gateway       = "${var.ipv4_gateway}"
+  }
+
+  disk {
+    datastore = "${var.datastore}"
+    template  = "${var.template}"
+    iops      = 500
+  }
+
+  linked_clone = "${var.linked_clone != "" ? "true" : "false" }"
+}
+`,
+		os.Getenv("VSPHERE_DATACENTER"),
+		os.Getenv("VSPHERE_CLUSTER"),
+		os.Getenv("VSPHERE_RESOURCE_POOL"),
+		os.Getenv("VSPHERE_NETWORK_LABEL"),
+		os.Getenv("VSPHERE_IPV4_ADDRESS"),
+		os.Getenv("VSPHERE_IPV4_PREFIX"),
+		os.Getenv("VSPHERE_IPV4_GATEWAY"),
+		os.Getenv("VSPHERE_DATASTORE"),
+		os.Getenv("VSPHERE_TEMPLATE"),
+		os.Getenv("VSPHERE_USE_LINKED_CLONE"),
+		testAccResourceVSphereVirtualMachineAnnotation,
+	)
+}
+
+func testAccResourceVSphereVirtualMachineConfigWindows() string {
+	return fmt.Sprintf(`
+variable "datacenter" {
+  default = "%s"
+}
+
+variable "cluster" {
+  default = "%s"
+}
+
+variable "resource_pool" {
+  default = "%s"
+}
+
+variable "network_label" {
+  default = "%s"
+}
+
+variable "ipv4_address" {
+  default = "%s"
+}
+
+variable "ipv4_prefix" {
+  default = "%s"
+}
 
-		return nil
-	}
+variable "ipv4_gateway" {
+  default = "%s"
 }
 
-// testAccResourceVSphereVirtualMachineCheckFolder checks to make sure a
-// virtual machine's folder matches the folder supplied with expected.
-func testAccResourceVSphereVirtualMachineCheckFolder(expected string) resource.TestCheckFunc {
-	return func(s *terraform.State) error {
-		vm, err := testGetVirtualMachine(s, "vm")
-		if err != nil {
-			return fmt.Errorf("bad: %s", err)
-		}
-		expected, err := rootPathParticleVM.PathFromNewRoot(vm.InventoryPath, rootPathParticleVM, expected)
-		actual := path.Dir(vm.InventoryPath)
-		if err != nil {
-			return fmt.Errorf("bad: %s", err)
-		}
-		if expected != actual {
-			return fmt.Errorf("expected path to be %s, got %s", expected, actual)
-		}
-		return nil
-	}
+variable "datastore" {
+  default = "%s"
 }
 
-// testAccResourceVSphereVirtualMachineCheckExistingVmdk is a check to make
-// sure that the appropriate disk is attached in the existing VMDK test.
-func testAccResourceVSphereVirtualMachineCheckExistingVmdk() resource.TestCheckFunc {
-	return func(s *terraform.State) error {
-		props, err := testGetVirtualMachineProperties(s, "vm")
-		if err != nil {
-			return err
-		}
+variable "template" {
+  default = "%s"
+}
 
-		expected := testAccResourceVSphereVirtualMachineDiskNameExtraVmdk
+variable "linked_clone" {
+  default = "%s"
+}
 
-		for _, dev := range props.Config.Hardware.Device {
-			if disk, ok := dev.(*types.VirtualDisk); ok {
-				if info, ok := disk.Backing.(*types.VirtualDiskFlatVer2BackingInfo); ok {
-					if strings.HasSuffix(info.FileName, expected) {
-						return nil
-					}
-				}
-			}
-		}
+resource "vsphere_virtual_machine" "vm" {
+  name          = "terraform-test"
+  datacenter    = "${var.datacenter}"
+  cluster       = "${var.cluster}"
+  resource_pool = "${var.resource_pool}"
 
-		return fmt.Errorf("could not find attached disk matching %q", expected)
-	}
+  vcpu   = 4
+  memory = 4096
+
+  network_interface {
+    label              = "${var.network_label}"
+    ipv4_address       = "${var.ipv4_address}"
+    ipv4_prefix_length = "${var.ipv4_prefix}"
+    ipv4_gateway       = "${var.ipv4_gateway}"
+  }
+
+  disk {
+    datastore = "${var.datastore}"
+    template  = "${var.template}"
+    iops      = 500
+  }
+
+  windows_opt_config {
+    admin_password = "VMw4re"
+  }
+
+  linked_clone = "${var.linked_clone != "" ? "true" : "false" }"
+}
+`,
+		os.Getenv("VSPHERE_DATACENTER"),
+		os.Getenv("VSPHERE_CLUSTER"),
+		os.Getenv("VSPHERE_RESOURCE_POOL"),
+		os.Getenv("VSPHERE_NETWORK_LABEL"),
+		os.Getenv("VSPHERE_IPV4_ADDRESS"),
+		os.Getenv("VSPHERE_IPV4_PREFIX"),
+		os.Getenv("VSPHERE_IPV4_GATEWAY"),
+		os.Getenv("VSPHERE_DATASTORE"),
+		os.Getenv("VSPHERE_TEMPLATE_WINDOWS"),
+		os.Getenv("VSPHERE_USE_LINKED_CLONE"),
+	)
 }
 
-// testAccResourceVSphereVirtualMachineCheckCPUMem checks the CPU and RAM for a
-// VM.
-func testAccResourceVSphereVirtualMachineCheckCPUMem(expectedCPU, expectedMem int32) resource.TestCheckFunc {
-	return func(s *terraform.State) error {
-		props, err := testGetVirtualMachineProperties(s, "vm")
-		if err != nil {
-			return err
-		}
-		actualCPU := props.Summary.Config.NumCpu
-		actualMem := props.Summary.Config.MemorySizeMB
-		if expectedCPU != actualCPU {
-			return fmt.Errorf("expected CPU count to be %d, got %d", expectedCPU, actualCPU)
-		}
-		if expectedMem != actualMem {
-			return fmt.Errorf("expected memory size to be %d, got %d", expectedMem, actualMem)
-		}
-		return nil
-	}
+func testAccResourceVSphereVirtualMachineConfigDHCPNoWait() string {
+	return fmt.Sprintf(`
+variable "datacenter" {
+  default = "%s"
 }
 
-// testAccResourceVSphereVirtualMachineCheckNet checks to make sure a virtual
-// machine's primary NIC has the given IP address and netmask assigned to it,
-// and that the appropriate gateway is present.
-//
-// This uses VMware tools to check this, so it needs to be installed on the
-// guest.
-func testAccResourceVSphereVirtualMachineCheckNet(expectedAddr, expectedPrefix, expectedGW string) resource.TestCheckFunc {
-	return func(s *terraform.State) error {
-		res, err := strconv.Atoi(expectedPrefix)
-		if err != nil {
-			return fmt.Errorf("bad: %s", err)
-		}
-		expectedPrefixInt := int32(res)
+variable "cluster" {
+  default = "%s"
+}
 
-		props, err := testGetVirtualMachineProperties(s, "vm")
-		if err != nil {
-			return err
-		}
-		var v4gw, v6gw net.IP
-		for _, s := range props.Guest.IpStack {
-			if s.IpRouteConfig != nil {
-				for _, r := range s.IpRouteConfig.IpRoute {
-					switch r.Network {
-					case "0.0.0.0":
-						v4gw = net.ParseIP(r.Gateway.IpAddress)
-					case "::":
-						v6gw = net.ParseIP(r.Gateway.IpAddress)
-					}
-				}
-			}
-		}
-		for _, n := range props.Guest.Net {
-			if n.IpConfig != nil {
-				for _, addr := range n.IpConfig.IpAddress {
-					ip := net.ParseIP(addr.IpAddress)
-					if !ip.Equal(net.ParseIP(expectedAddr)) {
-						continue
-					}
-					if addr.PrefixLength != expectedPrefixInt {
-						continue
-					}
-					var mask net.IPMask
-					if ip.To4() != nil {
-						mask = net.CIDRMask(int(addr.PrefixLength), 32)
-					} else {
-						mask = net.CIDRMask(int(addr.PrefixLength), 128)
-					}
-					switch {
-					case ip.Mask(mask).Equal(v4gw.Mask(mask)):
-						if net.ParseIP(expectedGW).Equal(v4gw) {
-							return nil
-						}
-					case ip.Mask(mask).Equal(v6gw.Mask(mask)):
-						if net.ParseIP(expectedGW).Equal(v6gw) {
-							return nil
-						}
-					}
-				}
-			}
-		}
+variable "resource_pool" {
+  default = "%s"
+}
 
-		return fmt.Errorf("could not find IP %s/%s, gateway %s", expectedAddr, expectedPrefix, expectedGW)
-	}
+variable "network_label" {
+  default = "%s"
 }
 
-// testAccResourceVSphereVirtualMachineCheckStaticMACAddr is a check to look
-// for the MAC address defined in the static MAC address test on the first
-// network interface.
-func testAccResourceVSphereVirtualMachineCheckStaticMACAddr() resource.TestCheckFunc {
-	return func(s *terraform.State) error {
-		props, err := testGetVirtualMachineProperties(s, "vm")
-		if err != nil {
-			return err
-		}
-		actual := props.Guest.Net[0].MacAddress
-		expected := testAccResourceVSphereVirtualMachineStaticMacAddr
-		if expected != actual {
-			return fmt.Errorf("expected MAC address to be %s, got %s", expected, actual)
-		}
-		return nil
-	}
+variable "datastore" {
+  default = "%s"
 }
 
-// testAccResourceVSphereVirtualMachineCheckAnnotation is a check to ensure
-// that a VM's annotation is correctly set in the annotation test.
-func testAccResourceVSphereVirtualMachineCheckAnnotation() resource.TestCheckFunc {
-	return func(s *terraform.State) error {
-		props, err := testGetVirtualMachineProperties(s, "vm")
-		if err != nil {
-			return err
-		}
-		expected := testAccResourceVSphereVirtualMachineAnnotation
-		actual := props.Config.Annotation
-		if expected != actual {
-			return fmt.Errorf("expected annotation to be %q, got %q", expected, actual)
-		}
-		return nil
-	}
+variable "template" {
+  default = "%s"
 }
 
-// testAccResourceVSphereVirtualMachineCheckCustomizationSucceeded is a check
-// to ensure that events have been received for customization success on a VM.
-func testAccResourceVSphereVirtualMachineCheckCustomizationSucceeded() resource.TestCheckFunc {
-	return func(s *terraform.State) error {
-		vm, err := testGetVirtualMachine(s, "vm")
-		if err != nil {
-			return err
-		}
-		client := testAccProvider.Meta().(*VSphereClient).vimClient
-		actual, err := selectEventsForReference(client, vm.Reference(), []string{eventTypeCustomizationSucceeded})
-		if err != nil {
-			return err
-		}
-		if len(actual) < 1 {
-			return errors.New("customization success event was not received")
-		}
-		return nil
-	}
+variable "linked_clone" {
+  default = "%s"
 }
 
-// testAccResourceVSphereVirtualMachineCheckTags is a check to ensure that any
-// tags that have been created with supplied resource name have been attached
-// to the virtual machine.
-func testAccResourceVSphereVirtualMachineCheckTags(tagResName string) resource.TestCheckFunc {
-	return func(s *terraform.State) error {
-		vm, err := testGetVirtualMachine(s, "vm")
-		if err != nil {
-			return err
-		}
-		tagsClient, err := testAccProvider.Meta().(*VSphereClient).TagsClient()
-		if err != nil {
-			return err
-		}
-		return testObjectHasTags(s, tagsClient, vm, tagResName)
-	}
+resource "vsphere_virtual_machine" "vm" {
+  name          = "terraform-test"
+  datacenter    = "${var.datacenter}"
+  cluster       = "${var.cluster}"
+  resource_pool = "${var.resource_pool}"
+
+  vcpu   = 2
+  memory = 1024
+
+  network_interface {
+    label = "${var.network_label}"
+  }
+
+  wait_for_guest_net = false
+
+  disk {
+    datastore = "${var.datastore}"
+    template  = "${var.template}"
+    iops      = 500
+  }
+
+  linked_clone = "${var.linked_clone != "" ? "true" : "false" }"
+}
+`,
+		os.Getenv("VSPHERE_DATACENTER"),
+		os.Getenv("VSPHERE_CLUSTER"),
+		os.Getenv("VSPHERE_RESOURCE_POOL"),
+		os.Getenv("VSPHERE_NETWORK_LABEL"),
+		os.Getenv("VSPHERE_DATASTORE"),
+		os.Getenv("VSPHERE_TEMPLATE"),
+		os.Getenv("VSPHERE_USE_LINKED_CLONE"),
+	)
 }
 
-func testAccResourceVSphereVirtualMachineConfigBasic() string {
+func testAccResourceVSphereVirtualMachineConfigWithTag() string {
 	return fmt.Sprintf(`
 variable "datacenter" {
   default = "%s"
@@ -853,6 +2871,20 @@ variable "linked_clone" {
   default = "%s"
 }
 
+resource "vsphere_tag_category" "terraform-test-category" {
+  name        = "terraform-test-tag-category"
+  cardinality = "MULTIPLE"
+
+  associable_types = [
+    "VirtualMachine",
+  ]
+}
+
+resource "vsphere_tag" "terraform-test-tag" {
+  name        = "terraform-test-tag"
+  category_id = "${vsphere_tag_category.terraform-test-category.id}"
+}
+
 resource "vsphere_virtual_machine" "vm" {
   name          = "terraform-test"
   datacenter    = "${var.datacenter}"
@@ -876,6 +2908,10 @@ resource "vsphere_virtual_machine" "vm" {
   }
 
   linked_clone = "${var.linked_clone != "" ? "true" : "false" }"
+
+  tags = [
+    "${vsphere_tag.terraform-test-tag.id}",
+  ]
 }
 `,
 		os.Getenv("VSPHERE_DATACENTER"),
@@ -891,7 +2927,7 @@ resource "vsphere_virtual_machine" "vm" {
 	)
 }
 
-func testAccResourceVSphereVirtualMachineConfigBeefy() string {
+func testAccResourceVSphereVirtualMachineConfigWithMultiTags() string {
 	return fmt.Sprintf(`
 variable "datacenter" {
   default = "%s"
@@ -933,14 +2969,41 @@ variable "linked_clone" {
   default = "%s"
 }
 
+variable "extra_tags" {
+  default = [
+    "terraform-test-thing1",
+    "terraform-test-thing2",
+  ]
+}
+
+resource "vsphere_tag_category" "terraform-test-category" {
+  name        = "terraform-test-tag-category"
+  cardinality = "MULTIPLE"
+
+  associable_types = [
+    "VirtualMachine",
+  ]
+}
+
+resource "vsphere_tag" "terraform-test-tag" {
+  name        = "terraform-test-tag"
+  category_id = "${vsphere_tag_category.terraform-test-category.id}"
+}
+
+resource "vsphere_tag" "terraform-test-tags-alt" {
+  count       = "${length(var.extra_tags)}"
+  name        = "${var.extra_tags[count.index]}"
+  category_id = "${vsphere_tag_category.terraform-test-category.id}"
+}
+
 resource "vsphere_virtual_machine" "vm" {
   name          = "terraform-test"
   datacenter    = "${var.datacenter}"
   cluster       = "${var.cluster}"
   resource_pool = "${var.resource_pool}"
 
-  vcpu   = 4
-  memory = 8192
+  vcpu   = 2
+  memory = 1024
 
   network_interface {
     label              = "${var.network_label}"
@@ -956,6 +3019,8 @@ resource "vsphere_virtual_machine" "vm" {
   }
 
   linked_clone = "${var.linked_clone != "" ? "true" : "false" }"
+
+  tags = ["${vsphere_tag.terraform-test-tags-alt.*.id}"]
 }
 `,
 		os.Getenv("VSPHERE_DATACENTER"),
@@ -971,12 +3036,24 @@ resource "vsphere_virtual_machine" "vm" {
 	)
 }
 
-func testAccResourceVSphereVirtualMachineConfigSeparateHostname() string {
+func testAccResourceVSphereVirtualMachineConfigSlashNetwork() string {
 	return fmt.Sprintf(`
 variable "datacenter" {
   default = "%s"
 }
 
+variable "hosts" {
+  default = [
+    "%s",
+    "%s",
+    "%s",
+  ]
+}
+
+variable "switch_nic" {
+  default = "%s"
+}
+
 variable "cluster" {
   default = "%s"
 }
@@ -1013,6 +3090,34 @@ variable "linked_clone" {
   default = "%s"
 }
 
+data "vsphere_datacenter" "datacenter" {
+  name = "${var.datacenter}"
+}
+
+data "vsphere_host" "host" {
+  count         = "${length(var.hosts)}"
+  name          = "${var.hosts[count.index]}"
+  datacenter_id = "${data.vsphere_datacenter.datacenter.id}"
+}
+
+resource "vsphere_host_virtual_switch" "switch" {
+  count          = "${length(data.vsphere_host.host.*.id)}"
+  name           = "vSwitchTerraformTest"
+  host_system_id = "${data.vsphere_host.host.*.id[count.index]}"
+
+  network_adapters = ["${var.switch_nic}"]
+
+  active_nics  = ["${var.switch_nic}"]
+  standby_nics = []
+}
+
+resource "vsphere_host_port_group" "pg" {
+  count               = "${length(data.vsphere_host.host.*.id)}"
+  name                = "${var.network_label}"
+  host_system_id      = "${data.vsphere_host.host.*.id[count.index]}"
+  virtual_switch_name = "${vsphere_host_virtual_switch.switch.*.name[count.index]}"
+}
+
 resource "vsphere_virtual_machine" "vm" {
   name          = "terraform-test"
   datacenter    = "${var.datacenter}"
@@ -1022,10 +3127,8 @@ resource "vsphere_virtual_machine" "vm" {
   vcpu   = 2
   memory = 1024
 
-  hostname = "terraform-test-renamed"
-
   network_interface {
-    label              = "${var.network_label}"
+    label              = "${vsphere_host_port_group.pg.0.name}"
     ipv4_address       = "${var.ipv4_address}"
     ipv4_prefix_length = "${var.ipv4_prefix}"
     ipv4_gateway       = "${var.ipv4_gateway}"
@@ -1037,13 +3140,19 @@ resource "vsphere_virtual_machine" "vm" {
     iops      = 500
   }
 
+  depends_on = ["vsphere_host_port_group.pg"]
+
   linked_clone = "${var.linked_clone != "" ? "true" : "false" }"
 }
 `,
 		os.Getenv("VSPHERE_DATACENTER"),
+		os.Getenv("VSPHERE_ESXI_HOST"),
+		os.Getenv("VSPHERE_ESXI_HOST2"),
+		os.Getenv("VSPHERE_ESXI_HOST3"),
+		os.Getenv("VSPHERE_HOST_NIC0"),
 		os.Getenv("VSPHERE_CLUSTER"),
 		os.Getenv("VSPHERE_RESOURCE_POOL"),
-		os.Getenv("VSPHERE_NETWORK_LABEL"),
+		testAccResourceVSphereVirtualMachineSlashNetLabel,
 		os.Getenv("VSPHERE_IPV4_ADDRESS"),
 		os.Getenv("VSPHERE_IPV4_PREFIX"),
 		os.Getenv("VSPHERE_IPV4_GATEWAY"),
@@ -1053,7 +3162,52 @@ resource "vsphere_virtual_machine" "vm" {
 	)
 }
 
-func testAccResourceVSphereVirtualMachineConfigExtraDisks() string {
+func testAccResourceVSphereVirtualMachineConfigContentLibraryItem() string {
+	return fmt.Sprintf(`
+%s
+
+variable "cluster" {
+  default = "%s"
+}
+
+variable "resource_pool" {
+  default = "%s"
+}
+
+variable "network_label" {
+  default = "%s"
+}
+
+resource "vsphere_virtual_machine" "vm" {
+  name          = "terraform-test"
+  datacenter    = "${data.vsphere_datacenter.dc.name}"
+  cluster       = "${var.cluster}"
+  resource_pool = "${var.resource_pool}"
+
+  vcpu   = 2
+  memory = 1024
+
+  network_interface {
+    label = "${var.network_label}"
+  }
+
+  disk {
+    datastore               = "${vsphere_content_library.library.datastore}"
+    content_library_item_id = "${vsphere_content_library_item.item.id}"
+    type                    = "thin"
+  }
+
+  skip_customization = true
+}
+`,
+		testAccResourceVSphereContentLibraryItemConfig("terraform-test-item"),
+		os.Getenv("VSPHERE_CLUSTER"),
+		os.Getenv("VSPHERE_RESOURCE_POOL"),
+		os.Getenv("VSPHERE_NETWORK_LABEL"),
+	)
+}
+
+func testAccResourceVSphereVirtualMachineConfigOvfFile() string {
 	return fmt.Sprintf(`
 variable "datacenter" {
   default = "%s"
@@ -1071,39 +3225,84 @@ variable "network_label" {
   default = "%s"
 }
 
-variable "ipv4_address" {
+variable "datastore" {
   default = "%s"
 }
 
-variable "ipv4_prefix" {
+variable "ovf_file" {
   default = "%s"
 }
 
-variable "ipv4_gateway" {
+resource "vsphere_virtual_machine" "vm" {
+  name          = "terraform-test"
+  datacenter    = "${var.datacenter}"
+  cluster       = "${var.cluster}"
+  resource_pool = "${var.resource_pool}"
+
+  vcpu   = 2
+  memory = 1024
+
+  network_interface {
+    label = "${var.network_label}"
+  }
+
+  disk {
+    datastore = "${var.datastore}"
+    ovf_file  = "${var.ovf_file}"
+    type      = "thin"
+  }
+
+  skip_customization = true
+}
+`,
+		os.Getenv("VSPHERE_DATACENTER"),
+		os.Getenv("VSPHERE_CLUSTER"),
+		os.Getenv("VSPHERE_RESOURCE_POOL"),
+		os.Getenv("VSPHERE_NETWORK_LABEL"),
+		os.Getenv("VSPHERE_DATASTORE"),
+		os.Getenv("VSPHERE_OVF_FILE"),
+	)
+}
+
+func testAccResourceVSphereVirtualMachineConfigHotAdd(vcpu, memory int) string {
+	return fmt.Sprintf(`
+variable "datacenter" {
   default = "%s"
 }
 
-variable "datastore" {
+variable "cluster" {
   default = "%s"
 }
 
-variable "template" {
+variable "resource_pool" {
   default = "%s"
 }
 
-variable "linked_clone" {
+variable "network_label" {
   default = "%s"
 }
 
-variable "disk_name_eager" {
+variable "ipv4_address" {
   default = "%s"
 }
 
-variable "disk_name_lazy" {
+variable "ipv4_prefix" {
   default = "%s"
 }
 
-variable "disk_name_thin" {
+variable "ipv4_gateway" {
+  default = "%s"
+}
+
+variable "datastore" {
+  default = "%s"
+}
+
+variable "template" {
+  default = "%s"
+}
+
+variable "linked_clone" {
   default = "%s"
 }
 
@@ -1113,8 +3312,11 @@ resource "vsphere_virtual_machine" "vm" {
   cluster       = "${var.cluster}"
   resource_pool = "${var.resource_pool}"
 
-  vcpu   = 2
-  memory = 1024
+  vcpu   = %d
+  memory = %d
+
+  cpu_hot_add_enabled    = true
+  memory_hot_add_enabled = true
 
   network_interface {
     label              = "${var.network_label}"
@@ -1129,24 +3331,6 @@ resource "vsphere_virtual_machine" "vm" {
     iops      = 500
   }
 
-  disk {
-    size = 1
-    type = "eager_zeroed"
-    name = "${var.disk_name_eager}"
-  }
-
-  disk {
-    size = 1
-    type = "lazy"
-    name = "${var.disk_name_lazy}"
-  }
-  
-	disk {
-    size = 1
-    type = "thin"
-    name = "${var.disk_name_thin}"
-  }
-
   linked_clone = "${var.linked_clone != "" ? "true" : "false" }"
 }
 `,
@@ -1159,14 +3343,13 @@ resource "vsphere_virtual_machine" "vm" {
 		os.Getenv("VSPHERE_IPV4_GATEWAY"),
 		os.Getenv("VSPHERE_DATASTORE"),
 		os.Getenv("VSPHERE_TEMPLATE"),
-		os.Getenv("VSPHERE_USE_LINKED_CLONE"),
-		testAccResourceVSphereVirtualMachineDiskNameEager,
-		testAccResourceVSphereVirtualMachineDiskNameLazy,
-		testAccResourceVSphereVirtualMachineDiskNameThin,
+		os.Getenv("VSPHERE_USE_LINKED_CLONE"),
+		vcpu,
+		memory,
 	)
 }
 
-func testAccResourceVSphereVirtualMachineConfigCustomConfig() string {
+func testAccResourceVSphereVirtualMachineConfigGracefulShutdown(vcpu int) string {
 	return fmt.Sprintf(`
 variable "datacenter" {
   default = "%s"
@@ -1214,9 +3397,12 @@ resource "vsphere_virtual_machine" "vm" {
   cluster       = "${var.cluster}"
   resource_pool = "${var.resource_pool}"
 
-  vcpu   = 2
+  vcpu   = %d
   memory = 1024
 
+  shutdown_wait_timeout = 5
+  force_power_off       = false
+
   network_interface {
     label              = "${var.network_label}"
     ipv4_address       = "${var.ipv4_address}"
@@ -1224,11 +3410,6 @@ resource "vsphere_virtual_machine" "vm" {
     ipv4_gateway       = "${var.ipv4_gateway}"
   }
 
-  custom_configuration_parameters {
-    "foo" = "bar"
-    "baz" = "qux"
-  }
-
   disk {
     datastore = "${var.datastore}"
     template  = "${var.template}"
@@ -1248,10 +3429,11 @@ resource "vsphere_virtual_machine" "vm" {
 		os.Getenv("VSPHERE_DATASTORE"),
 		os.Getenv("VSPHERE_TEMPLATE"),
 		os.Getenv("VSPHERE_USE_LINKED_CLONE"),
+		vcpu,
 	)
 }
 
-func testAccResourceVSphereVirtualMachineConfigInFolder() string {
+func testAccResourceVSphereVirtualMachineConfigPowerState(powerState types.VirtualMachinePowerState) string {
 	return fmt.Sprintf(`
 variable "datacenter" {
   default = "%s"
@@ -1293,26 +3475,18 @@ variable "linked_clone" {
   default = "%s"
 }
 
-data "vsphere_datacenter" "datacenter" {
-  name = "${var.datacenter}"
-}
-
-resource "vsphere_folder" "folder" {
-  path          = "terraform-test-vms"
-  type          = "vm"
-  datacenter_id = "${data.vsphere_datacenter.datacenter.id}"
-}
-
 resource "vsphere_virtual_machine" "vm" {
   name          = "terraform-test"
   datacenter    = "${var.datacenter}"
   cluster       = "${var.cluster}"
   resource_pool = "${var.resource_pool}"
-  folder        = "${vsphere_folder.folder.path}"
 
   vcpu   = 2
   memory = 1024
 
+  power_state        = "%s"
+  wait_for_guest_net = false
+
   network_interface {
     label              = "${var.network_label}"
     ipv4_address       = "${var.ipv4_address}"
@@ -1339,10 +3513,11 @@ resource "vsphere_virtual_machine" "vm" {
 		os.Getenv("VSPHERE_DATASTORE"),
 		os.Getenv("VSPHERE_TEMPLATE"),
 		os.Getenv("VSPHERE_USE_LINKED_CLONE"),
+		powerState,
 	)
 }
 
-func testAccResourceVSphereVirtualMachineConfigExistingVmdk() string {
+func testAccResourceVSphereVirtualMachineConfigResourceAllocation(cpuReservation int, cpuShareLevel string, memoryLimit int) string {
 	return fmt.Sprintf(`
 variable "datacenter" {
   default = "%s"
@@ -1384,19 +3559,6 @@ variable "linked_clone" {
   default = "%s"
 }
 
-variable "extra_vmdk_name" {
-  default = "%s"
-}
-
-resource "vsphere_virtual_disk" "disk" {
-  size         = 1
-  vmdk_path    = "${var.extra_vmdk_name}"
-  datacenter   = "${var.datacenter}"
-  datastore    = "${var.datastore}"
-  type         = "thin"
-  adapter_type = "lsiLogic"
-}
-
 resource "vsphere_virtual_machine" "vm" {
   name          = "terraform-test"
   datacenter    = "${var.datacenter}"
@@ -1406,6 +3568,10 @@ resource "vsphere_virtual_machine" "vm" {
   vcpu   = 2
   memory = 1024
 
+  cpu_reservation = %d
+  cpu_share_level = "%s"
+  memory_limit    = %d
+
   network_interface {
     label              = "${var.network_label}"
     ipv4_address       = "${var.ipv4_address}"
@@ -1419,12 +3585,6 @@ resource "vsphere_virtual_machine" "vm" {
     iops      = 500
   }
 
-  disk {
-    datastore      = "${var.datastore}"
-    vmdk           = "${vsphere_virtual_disk.disk.vmdk_path}"
-    keep_on_remove = true
-  }
-
   linked_clone = "${var.linked_clone != "" ? "true" : "false" }"
 }
 `,
@@ -1438,11 +3598,13 @@ resource "vsphere_virtual_machine" "vm" {
 		os.Getenv("VSPHERE_DATASTORE"),
 		os.Getenv("VSPHERE_TEMPLATE"),
 		os.Getenv("VSPHERE_USE_LINKED_CLONE"),
-		testAccResourceVSphereVirtualMachineDiskNameExtraVmdk,
+		cpuReservation,
+		cpuShareLevel,
+		memoryLimit,
 	)
 }
 
-func testAccResourceVSphereVirtualMachineConfigDualStack() string {
+func testAccResourceVSphereVirtualMachineConfigBootOptions(bootDelay int, nestedHV bool) string {
 	return fmt.Sprintf(`
 variable "datacenter" {
   default = "%s"
@@ -1493,14 +3655,15 @@ resource "vsphere_virtual_machine" "vm" {
   vcpu   = 2
   memory = 1024
 
+  boot_delay        = %d
+  boot_order        = ["disk", "ethernet"]
+  nested_hv_enabled = %t
+
   network_interface {
     label              = "${var.network_label}"
     ipv4_address       = "${var.ipv4_address}"
     ipv4_prefix_length = "${var.ipv4_prefix}"
     ipv4_gateway       = "${var.ipv4_gateway}"
-    ipv6_address       = "fd00::2"
-    ipv6_prefix_length = "32"
-    ipv6_gateway       = "fd00::1"
   }
 
   disk {
@@ -1522,10 +3685,12 @@ resource "vsphere_virtual_machine" "vm" {
 		os.Getenv("VSPHERE_DATASTORE"),
 		os.Getenv("VSPHERE_TEMPLATE"),
 		os.Getenv("VSPHERE_USE_LINKED_CLONE"),
+		bootDelay,
+		nestedHV,
 	)
 }
 
-func testAccResourceVSphereVirtualMachineConfigStaticMAC() string {
+func testAccResourceVSphereVirtualMachineConfigSecureBootBIOS() string {
 	return fmt.Sprintf(`
 variable "datacenter" {
   default = "%s"
@@ -1567,10 +3732,6 @@ variable "linked_clone" {
   default = "%s"
 }
 
-variable "static_mac_addr" {
-  default = "%s"
-}
-
 resource "vsphere_virtual_machine" "vm" {
   name          = "terraform-test"
   datacenter    = "${var.datacenter}"
@@ -1580,9 +3741,11 @@ resource "vsphere_virtual_machine" "vm" {
   vcpu   = 2
   memory = 1024
 
+  firmware                = "bios"
+  efi_secure_boot_enabled = true
+
   network_interface {
     label              = "${var.network_label}"
-    mac_address        = "${var.static_mac_addr}"
     ipv4_address       = "${var.ipv4_address}"
     ipv4_prefix_length = "${var.ipv4_prefix}"
     ipv4_gateway       = "${var.ipv4_gateway}"
@@ -1607,11 +3770,10 @@ resource "vsphere_virtual_machine" "vm" {
 		os.Getenv("VSPHERE_DATASTORE"),
 		os.Getenv("VSPHERE_TEMPLATE"),
 		os.Getenv("VSPHERE_USE_LINKED_CLONE"),
-		testAccResourceVSphereVirtualMachineStaticMacAddr,
 	)
 }
 
-func testAccResourceVSphereVirtualMachineConfigWithAnnotation() string {
+func testAccResourceVSphereVirtualMachineConfigNetworkAdapterType(adapterType string) string {
 	return fmt.Sprintf(`
 variable "datacenter" {
   default = "%s"
@@ -1653,25 +3815,22 @@ variable "linked_clone" {
   default = "%s"
 }
 
-variable "annotation" {
-	default = "%s"
-}
-
 resource "vsphere_virtual_machine" "vm" {
   name          = "terraform-test"
   datacenter    = "${var.datacenter}"
   cluster       = "${var.cluster}"
   resource_pool = "${var.resource_pool}"
-  annotation    = "${var.annotation}"
 
   vcpu   = 2
   memory = 1024
 
   network_interface {
-    label              = "${var.network_label}"
-    ipv4_address       = "${var.ipv4_address}"
-    ipv4_prefix_length = "${var.ipv4_prefix}"
-    ipv4_gateway       = "${var.ipv4_gateway}"
+    label                = "${var.network_label}"
+    adapter_type         = "%s"
+    preserve_mac_address = true
+    ipv4_address         = "${var.ipv4_address}"
+    ipv4_prefix_length   = "${var.ipv4_prefix}"
+    ipv4_gateway         = "${var.ipv4_gateway}"
   }
 
   disk {
@@ -1693,11 +3852,11 @@ resource "vsphere_virtual_machine" "vm" {
 		os.Getenv("VSPHERE_DATASTORE"),
 		os.Getenv("VSPHERE_TEMPLATE"),
 		os.Getenv("VSPHERE_USE_LINKED_CLONE"),
-		testAccResourceVSphereVirtualMachineAnnotation,
+		adapterType,
 	)
 }
 
-func testAccResourceVSphereVirtualMachineConfigWindows() string {
+func testAccResourceVSphereVirtualMachineConfigDiskControllers() string {
 	return fmt.Sprintf(`
 variable "datacenter" {
   default = "%s"
@@ -1739,14 +3898,41 @@ variable "linked_clone" {
   default = "%s"
 }
 
+variable "disk_name_scsi" {
+  default = "%s"
+}
+
+variable "disk_name_sata" {
+  default = "%s"
+}
+
+data "vsphere_datacenter" "dc" {
+  name = "${var.datacenter}"
+}
+
+data "vsphere_virtual_machine" "template" {
+  name          = "${var.template}"
+  datacenter_id = "${data.vsphere_datacenter.dc.id}"
+}
+
 resource "vsphere_virtual_machine" "vm" {
   name          = "terraform-test"
   datacenter    = "${var.datacenter}"
   cluster       = "${var.cluster}"
   resource_pool = "${var.resource_pool}"
 
-  vcpu   = 4
-  memory = 4096
+  vcpu   = 2
+  memory = 1024
+
+  scsi_controller {
+    type = "${data.vsphere_virtual_machine.template.scsi_type}"
+  }
+
+  scsi_controller {
+    type = "pvscsi"
+  }
+
+  sata_controller_count = 1
 
   network_interface {
     label              = "${var.network_label}"
@@ -1761,8 +3947,20 @@ resource "vsphere_virtual_machine" "vm" {
     iops      = 500
   }
 
-  windows_opt_config {
-    admin_password = "VMw4re"
+  disk {
+    size                  = 1
+    type                  = "thin"
+    name                  = "${var.disk_name_scsi}"
+    controller_type       = "scsi-paravirtual"
+    controller_bus_number = 1
+    unit_number           = 3
+  }
+
+  disk {
+    size            = 1
+    type            = "thin"
+    name            = "${var.disk_name_sata}"
+    controller_type = "sata"
   }
 
   linked_clone = "${var.linked_clone != "" ? "true" : "false" }"
@@ -1776,12 +3974,14 @@ resource "vsphere_virtual_machine" "vm" {
 		os.Getenv("VSPHERE_IPV4_PREFIX"),
 		os.Getenv("VSPHERE_IPV4_GATEWAY"),
 		os.Getenv("VSPHERE_DATASTORE"),
-		os.Getenv("VSPHERE_TEMPLATE_WINDOWS"),
+		os.Getenv("VSPHERE_TEMPLATE"),
 		os.Getenv("VSPHERE_USE_LINKED_CLONE"),
+		testAccResourceVSphereVirtualMachineDiskNameSCSI,
+		testAccResourceVSphereVirtualMachineDiskNameSATA,
 	)
 }
 
-func testAccResourceVSphereVirtualMachineConfigDHCPNoWait() string {
+func testAccResourceVSphereVirtualMachineConfigSharedDisk() string {
 	return fmt.Sprintf(`
 variable "datacenter" {
   default = "%s"
@@ -1799,6 +3999,18 @@ variable "network_label" {
   default = "%s"
 }
 
+variable "ipv4_address" {
+  default = "%s"
+}
+
+variable "ipv4_prefix" {
+  default = "%s"
+}
+
+variable "ipv4_gateway" {
+  default = "%s"
+}
+
 variable "datastore" {
   default = "%s"
 }
@@ -1811,6 +4023,10 @@ variable "linked_clone" {
   default = "%s"
 }
 
+variable "disk_name_shared" {
+  default = "%s"
+}
+
 resource "vsphere_virtual_machine" "vm" {
   name          = "terraform-test"
   datacenter    = "${var.datacenter}"
@@ -1821,31 +4037,78 @@ resource "vsphere_virtual_machine" "vm" {
   memory = 1024
 
   network_interface {
-    label = "${var.network_label}"
+    label              = "${var.network_label}"
+    ipv4_address       = "${var.ipv4_address}"
+    ipv4_prefix_length = "${var.ipv4_prefix}"
+    ipv4_gateway       = "${var.ipv4_gateway}"
   }
 
-  wait_for_guest_net = false
-
   disk {
     datastore = "${var.datastore}"
     template  = "${var.template}"
     iops      = 500
   }
 
+  disk {
+    size      = 1
+    type      = "eager_zeroed"
+    name      = "${var.disk_name_shared}"
+    datastore = "${var.datastore}"
+    disk_mode = "independent_persistent"
+    sharing   = "sharingMultiWriter"
+  }
+
   linked_clone = "${var.linked_clone != "" ? "true" : "false" }"
 }
+
+resource "vsphere_virtual_machine" "vm2" {
+  name          = "terraform-test-2"
+  datacenter    = "${var.datacenter}"
+  cluster       = "${var.cluster}"
+  resource_pool = "${var.resource_pool}"
+
+  vcpu   = 2
+  memory = 1024
+
+  network_interface {
+    label = "${var.network_label}"
+  }
+
+  disk {
+    datastore = "${var.datastore}"
+    template  = "${var.template}"
+    iops      = 500
+  }
+
+  disk {
+    vmdk      = "terraform-test/${var.disk_name_shared}.vmdk"
+    datastore = "${var.datastore}"
+    attach    = true
+    disk_mode = "independent_persistent"
+    sharing   = "sharingMultiWriter"
+  }
+
+  linked_clone       = "${var.linked_clone != "" ? "true" : "false" }"
+  wait_for_guest_net = false
+
+  depends_on = ["vsphere_virtual_machine.vm"]
+}
 `,
 		os.Getenv("VSPHERE_DATACENTER"),
 		os.Getenv("VSPHERE_CLUSTER"),
 		os.Getenv("VSPHERE_RESOURCE_POOL"),
 		os.Getenv("VSPHERE_NETWORK_LABEL"),
+		os.Getenv("VSPHERE_IPV4_ADDRESS"),
+		os.Getenv("VSPHERE_IPV4_PREFIX"),
+		os.Getenv("VSPHERE_IPV4_GATEWAY"),
 		os.Getenv("VSPHERE_DATASTORE"),
 		os.Getenv("VSPHERE_TEMPLATE"),
 		os.Getenv("VSPHERE_USE_LINKED_CLONE"),
+		testAccResourceVSphereVirtualMachineDiskNameShared,
 	)
 }
 
-func testAccResourceVSphereVirtualMachineConfigWithTag() string {
+func testAccResourceVSphereVirtualMachineConfigRDM() string {
 	return fmt.Sprintf(`
 variable "datacenter" {
   default = "%s"
@@ -1887,18 +4150,8 @@ variable "linked_clone" {
   default = "%s"
 }
 
-resource "vsphere_tag_category" "terraform-test-category" {
-  name        = "terraform-test-tag-category"
-  cardinality = "MULTIPLE"
-
-  associable_types = [
-    "VirtualMachine",
-  ]
-}
-
-resource "vsphere_tag" "terraform-test-tag" {
-  name        = "terraform-test-tag"
-  category_id = "${vsphere_tag_category.terraform-test-category.id}"
+variable "rdm_lun" {
+  default = "%s"
 }
 
 resource "vsphere_virtual_machine" "vm" {
@@ -1923,11 +4176,13 @@ resource "vsphere_virtual_machine" "vm" {
     iops      = 500
   }
 
-  linked_clone = "${var.linked_clone != "" ? "true" : "false" }"
+  disk {
+    rdm_lun                = "${var.rdm_lun}"
+    rdm_compatibility_mode = "virtualMode"
+    datastore              = "${var.datastore}"
+  }
 
-  tags = [
-    "${vsphere_tag.terraform-test-tag.id}",
-  ]
+  linked_clone = "${var.linked_clone != "" ? "true" : "false" }"
 }
 `,
 		os.Getenv("VSPHERE_DATACENTER"),
@@ -1940,10 +4195,11 @@ resource "vsphere_virtual_machine" "vm" {
 		os.Getenv("VSPHERE_DATASTORE"),
 		os.Getenv("VSPHERE_TEMPLATE"),
 		os.Getenv("VSPHERE_USE_LINKED_CLONE"),
+		os.Getenv("VSPHERE_RDM_LUN"),
 	)
 }
 
-func testAccResourceVSphereVirtualMachineConfigWithMultiTags() string {
+func testAccResourceVSphereVirtualMachineConfigGrowDisk(size, iops int, diskType string) string {
 	return fmt.Sprintf(`
 variable "datacenter" {
   default = "%s"
@@ -1985,31 +4241,8 @@ variable "linked_clone" {
   default = "%s"
 }
 
-variable "extra_tags" {
-  default = [
-    "terraform-test-thing1",
-    "terraform-test-thing2",
-  ]
-}
-
-resource "vsphere_tag_category" "terraform-test-category" {
-  name        = "terraform-test-tag-category"
-  cardinality = "MULTIPLE"
-
-  associable_types = [
-    "VirtualMachine",
-  ]
-}
-
-resource "vsphere_tag" "terraform-test-tag" {
-  name        = "terraform-test-tag"
-  category_id = "${vsphere_tag_category.terraform-test-category.id}"
-}
-
-resource "vsphere_tag" "terraform-test-tags-alt" {
-  count       = "${length(var.extra_tags)}"
-  name        = "${var.extra_tags[count.index]}"
-  category_id = "${vsphere_tag_category.terraform-test-category.id}"
+variable "disk_name_grow" {
+  default = "%s"
 }
 
 resource "vsphere_virtual_machine" "vm" {
@@ -2034,9 +4267,15 @@ resource "vsphere_virtual_machine" "vm" {
     iops      = 500
   }
 
-  linked_clone = "${var.linked_clone != "" ? "true" : "false" }"
+  disk {
+    size      = %d
+    iops      = %d
+    type      = "%s"
+    name      = "${var.disk_name_grow}"
+    datastore = "${var.datastore}"
+  }
 
-  tags = ["${vsphere_tag.terraform-test-tags-alt.*.id}"]
+  linked_clone = "${var.linked_clone != "" ? "true" : "false" }"
 }
 `,
 		os.Getenv("VSPHERE_DATACENTER"),
@@ -2049,27 +4288,19 @@ resource "vsphere_virtual_machine" "vm" {
 		os.Getenv("VSPHERE_DATASTORE"),
 		os.Getenv("VSPHERE_TEMPLATE"),
 		os.Getenv("VSPHERE_USE_LINKED_CLONE"),
+		testAccResourceVSphereVirtualMachineDiskNameGrow,
+		size,
+		iops,
+		diskType,
 	)
 }
 
-func testAccResourceVSphereVirtualMachineConfigSlashNetwork() string {
+func testAccResourceVSphereVirtualMachineConfigMigrate(host, diskDatastore string) string {
 	return fmt.Sprintf(`
 variable "datacenter" {
   default = "%s"
 }
 
-variable "hosts" {
-  default = [
-    "%s",
-    "%s",
-    "%s",
-  ]
-}
-
-variable "switch_nic" {
-  default = "%s"
-}
-
 variable "cluster" {
   default = "%s"
 }
@@ -2106,32 +4337,16 @@ variable "linked_clone" {
   default = "%s"
 }
 
-data "vsphere_datacenter" "datacenter" {
-  name = "${var.datacenter}"
-}
-
-data "vsphere_host" "host" {
-  count         = "${length(var.hosts)}"
-  name          = "${var.hosts[count.index]}"
-  datacenter_id = "${data.vsphere_datacenter.datacenter.id}"
+variable "disk_name_migrate" {
+  default = "%s"
 }
 
-resource "vsphere_host_virtual_switch" "switch" {
-  count          = "${length(data.vsphere_host.host.*.id)}"
-  name           = "vSwitchTerraformTest"
-  host_system_id = "${data.vsphere_host.host.*.id[count.index]}"
-
-  network_adapters = ["${var.switch_nic}"]
-
-  active_nics  = ["${var.switch_nic}"]
-  standby_nics = []
+variable "host" {
+  default = "%s"
 }
 
-resource "vsphere_host_port_group" "pg" {
-  count               = "${length(data.vsphere_host.host.*.id)}"
-  name                = "${var.network_label}"
-  host_system_id      = "${data.vsphere_host.host.*.id[count.index]}"
-  virtual_switch_name = "${vsphere_host_virtual_switch.switch.*.name[count.index]}"
+variable "disk_datastore" {
+  default = "%s"
 }
 
 resource "vsphere_virtual_machine" "vm" {
@@ -2139,12 +4354,13 @@ resource "vsphere_virtual_machine" "vm" {
   datacenter    = "${var.datacenter}"
   cluster       = "${var.cluster}"
   resource_pool = "${var.resource_pool}"
+  host          = "${var.host}"
 
   vcpu   = 2
   memory = 1024
 
   network_interface {
-    label              = "${vsphere_host_port_group.pg.0.name}"
+    label              = "${var.network_label}"
     ipv4_address       = "${var.ipv4_address}"
     ipv4_prefix_length = "${var.ipv4_prefix}"
     ipv4_gateway       = "${var.ipv4_gateway}"
@@ -2156,24 +4372,28 @@ resource "vsphere_virtual_machine" "vm" {
     iops      = 500
   }
 
-  depends_on = ["vsphere_host_port_group.pg"]
+  disk {
+    size      = 1
+    type      = "thin"
+    name      = "${var.disk_name_migrate}"
+    datastore = "${var.disk_datastore}"
+  }
 
   linked_clone = "${var.linked_clone != "" ? "true" : "false" }"
 }
 `,
 		os.Getenv("VSPHERE_DATACENTER"),
-		os.Getenv("VSPHERE_ESXI_HOST"),
-		os.Getenv("VSPHERE_ESXI_HOST2"),
-		os.Getenv("VSPHERE_ESXI_HOST3"),
-		os.Getenv("VSPHERE_HOST_NIC0"),
 		os.Getenv("VSPHERE_CLUSTER"),
 		os.Getenv("VSPHERE_RESOURCE_POOL"),
-		testAccResourceVSphereVirtualMachineSlashNetLabel,
+		os.Getenv("VSPHERE_NETWORK_LABEL"),
 		os.Getenv("VSPHERE_IPV4_ADDRESS"),
 		os.Getenv("VSPHERE_IPV4_PREFIX"),
 		os.Getenv("VSPHERE_IPV4_GATEWAY"),
 		os.Getenv("VSPHERE_DATASTORE"),
 		os.Getenv("VSPHERE_TEMPLATE"),
 		os.Getenv("VSPHERE_USE_LINKED_CLONE"),
+		testAccResourceVSphereVirtualMachineDiskNameMigrate,
+		host,
+		diskDatastore,
 	)
 }
@@ -9,6 +9,7 @@ import (
 
 	"github.com/hashicorp/terraform/helper/resource"
 	"github.com/hashicorp/terraform/helper/schema"
+	"github.com/hashicorp/terraform/helper/validation"
 	"github.com/vmware/govmomi/vim25/types"
 )
 
@@ -81,7 +82,20 @@ func resourceVSphereVmfsDatastore() *schema.Resource {
 			MinItems:    1,
 			Elem:        &schema.Schema{Type: schema.TypeString},
 		},
+		"maintenance_mode_enabled": &schema.Schema{
+			Type:        schema.TypeBool,
+			Description: "Put the datastore into maintenance mode. Entering maintenance mode waits for all virtual machines to be evacuated from the datastore.",
+			Optional:    true,
+		},
+		"maintenance_mode_timeout": &schema.Schema{
+			Type:         schema.TypeInt,
+			Description:  "The time, in minutes, to wait for the datastore to enter or exit maintenance mode.",
+			Optional:     true,
+			Default:      defaultDatastoreMaintenanceModeTimeout,
+			ValidateFunc: validation.IntAtLeast(1),
+		},
 	}
+	mergeSchema(s, schemaStorageIORMConfigSpec())
 	mergeSchema(s, schemaDatastoreSummary())
 
 	// Add tags schema
@@ -181,6 +195,15 @@ func resourceVSphereVmfsDatastoreCreate(d *schema.ResourceData, meta interface{}
 
 	d.SetId(ds.Reference().Value)
 
+	// Apply Storage I/O Control settings and maintenance mode last, as entering
+	// maintenance mode makes the datastore unavailable for any further use.
+	if err := processDatastoreIORMDiff(client, d, ds); err != nil {
+		return err
+	}
+	if err := processDatastoreMaintenanceModeDiff(client, d, ds); err != nil {
+		return err
+	}
+
 	// Done
 	return resourceVSphereVmfsDatastoreRead(d, meta)
 }
@@ -199,6 +222,12 @@ func resourceVSphereVmfsDatastoreRead(d *schema.ResourceData, meta interface{})
 	if err := flattenDatastoreSummary(d, &props.Summary); err != nil {
 		return err
 	}
+	d.Set("maintenance_mode_enabled", datastoreInMaintenanceMode(&props.Summary))
+	if props.IormConfiguration != nil {
+		if err := flattenStorageIORMInfo(d, props.IormConfiguration); err != nil {
+			return err
+		}
+	}
 
 	// Set the folder
 	folder, err := rootPathParticleDatastore.SplitRelativeFolder(ds.InventoryPath)
@@ -308,6 +337,14 @@ func resourceVSphereVmfsDatastoreUpdate(d *schema.ResourceData, meta interface{}
 		}
 	}
 
+	// Process Storage I/O Control and maintenance mode changes.
+	if err := processDatastoreIORMDiff(client, d, ds); err != nil {
+		return err
+	}
+	if err := processDatastoreMaintenanceModeDiff(client, d, ds); err != nil {
+		return err
+	}
+
 	// Should be done with the update here.
 	return resourceVSphereVmfsDatastoreRead(d, meta)
 }
@@ -433,6 +470,7 @@ func resourceVSphereVmfsDatastoreImport(d *schema.ResourceData, meta interface{}
 	}
 	d.SetId(id)
 	d.Set("host_system_id", hsID)
+	d.Set("maintenance_mode_timeout", defaultDatastoreMaintenanceModeTimeout)
 
 	return []*schema.ResourceData{d}, nil
 }
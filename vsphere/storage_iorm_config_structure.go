@@ -0,0 +1,93 @@
+package vsphere
+
+import (
+	"github.com/hashicorp/terraform/helper/schema"
+	"github.com/hashicorp/terraform/helper/validation"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+var storageIORMThresholdModeAllowedValues = []string{
+	string(types.StorageIORMThresholdModeAutomatic),
+	string(types.StorageIORMThresholdModeManual),
+}
+
+// schemaStorageIORMConfigSpec returns schema items for resources that need to
+// work with a StorageIORMConfigSpec.
+func schemaStorageIORMConfigSpec() map[string]*schema.Schema {
+	return map[string]*schema.Schema{
+		// StorageIORMConfigSpec
+		// Skipped attributes: reservationEnabled, statsAggregationDisabled,
+		// reservableIopsThreshold (these are either deprecated or only apply to
+		// Storage DRS)
+		"sioc_enabled": &schema.Schema{
+			Type:        schema.TypeBool,
+			Description: "Enable Storage I/O Control on this datastore.",
+			Optional:    true,
+			Computed:    true,
+		},
+		"sioc_congestion_threshold_mode": &schema.Schema{
+			Type:         schema.TypeString,
+			Description:  "The method used to determine the congestion threshold. Can be one of automatic or manual.",
+			Optional:     true,
+			Computed:     true,
+			ValidateFunc: validation.StringInSlice(storageIORMThresholdModeAllowedValues, false),
+		},
+		"sioc_congestion_threshold": &schema.Schema{
+			Type:         schema.TypeInt,
+			Description:  "The latency threshold, in milliseconds, used when sioc_congestion_threshold_mode is manual.",
+			Optional:     true,
+			Computed:     true,
+			ValidateFunc: validation.IntBetween(5, 100),
+		},
+		"sioc_percent_of_peak_throughput": &schema.Schema{
+			Type:         schema.TypeInt,
+			Description:  "The percentage of peak throughput used to determine the congestion threshold when sioc_congestion_threshold_mode is automatic.",
+			Optional:     true,
+			Computed:     true,
+			ValidateFunc: validation.IntBetween(50, 100),
+		},
+		"sioc_stats_collection_enabled": &schema.Schema{
+			Type:        schema.TypeBool,
+			Description: "Enable I/O statistics collection for this datastore, even if Storage I/O Control is disabled.",
+			Optional:    true,
+			Computed:    true,
+		},
+	}
+}
+
+// expandStorageIORMConfigSpec reads certain ResourceData keys and returns a
+// StorageIORMConfigSpec.
+func expandStorageIORMConfigSpec(d *schema.ResourceData) *types.StorageIORMConfigSpec {
+	obj := &types.StorageIORMConfigSpec{
+		Enabled:                 getBoolPtr(d, "sioc_enabled"),
+		CongestionThresholdMode: d.Get("sioc_congestion_threshold_mode").(string),
+		CongestionThreshold:     int32(d.Get("sioc_congestion_threshold").(int)),
+		PercentOfPeakThroughput: int32(d.Get("sioc_percent_of_peak_throughput").(int)),
+		StatsCollectionEnabled:  getBoolPtr(d, "sioc_stats_collection_enabled"),
+	}
+	return obj
+}
+
+// flattenStorageIORMInfo reads various fields from a StorageIORMInfo into
+// the passed in ResourceData.
+func flattenStorageIORMInfo(d *schema.ResourceData, obj *types.StorageIORMInfo) error {
+	d.Set("sioc_enabled", obj.Enabled)
+	d.Set("sioc_congestion_threshold_mode", obj.CongestionThresholdMode)
+	d.Set("sioc_congestion_threshold", obj.CongestionThreshold)
+	d.Set("sioc_percent_of_peak_throughput", obj.PercentOfPeakThroughput)
+	if err := setBoolPtr(d, "sioc_stats_collection_enabled", obj.StatsCollectionEnabled); err != nil {
+		return err
+	}
+	return nil
+}
+
+// storageIORMConfigSpecChanged returns true if any of the Storage I/O Control
+// keys in the resource have a pending change.
+func storageIORMConfigSpecChanged(d *schema.ResourceData) bool {
+	for k := range schemaStorageIORMConfigSpec() {
+		if d.HasChange(k) {
+			return true
+		}
+	}
+	return false
+}
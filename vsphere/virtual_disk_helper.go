@@ -0,0 +1,157 @@
+package vsphere
+
+import (
+	"context"
+	"fmt"
+	"path"
+
+	"github.com/vmware/govmomi"
+	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/vim25/methods"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// virtualDiskAdapterTypes maps the controller types reported by the datastore
+// browser for a virtual disk to the adapter types used by the
+// vsphere_virtual_disk resource.
+var virtualDiskAdapterTypes = map[string]string{
+	"VirtualIDEController":      "ide",
+	"VirtualBusLogicController": "busLogic",
+	"VirtualLsiLogicController": "lsiLogic",
+}
+
+// virtualDiskType returns the VirtualDiskType for the supplied value of the
+// type attribute in the vsphere_virtual_disk resource.
+func virtualDiskType(t string) string {
+	switch t {
+	case "thin":
+		return string(types.VirtualDiskTypeThin)
+	case "eagerZeroedThick":
+		return string(types.VirtualDiskTypeEagerZeroedThick)
+	case "lazy":
+		return string(types.VirtualDiskTypePreallocated)
+	}
+	return ""
+}
+
+// queryVirtualDiskType returns the value of the type attribute in the
+// vsphere_virtual_disk resource for the virtual disk at the supplied datastore
+// path, as reported by the virtual disk manager. An empty string is returned
+// if the type of the disk is not one the resource supports.
+func queryVirtualDiskType(client *govmomi.Client, name string, dc *object.Datacenter) (string, error) {
+	m := object.NewVirtualDiskManager(client.Client)
+	ctx, cancel := context.WithTimeout(context.Background(), defaultAPITimeout)
+	defer cancel()
+	info, err := m.QueryVirtualDiskInfo(ctx, name, dc, false)
+	if err != nil {
+		return "", err
+	}
+	if len(info) < 1 {
+		return "", nil
+	}
+	switch types.VirtualDiskType(info[0].DiskType) {
+	case types.VirtualDiskTypeThin:
+		return "thin", nil
+	case types.VirtualDiskTypeEagerZeroedThick:
+		return "eagerZeroedThick", nil
+	case types.VirtualDiskTypePreallocated:
+		return "lazy", nil
+	}
+	return "", nil
+}
+
+// extendVirtualDisk is a stop-gap method that implements
+// ExtendVirtualDisk_Task and waits for the task to complete. It will be
+// removed once the VirtualDiskManager object in govmomi supports extending
+// disks.
+//
+// The wait is not bound by a timeout, as zeroing out the new space on large
+// eagerly zeroed disks can take a long time.
+func extendVirtualDisk(client *govmomi.Client, name string, dc *object.Datacenter, capacityKb int64, eagerZero bool) error {
+	req := types.ExtendVirtualDisk_Task{
+		This:          *client.ServiceContent.VirtualDiskManager,
+		Name:          name,
+		NewCapacityKb: capacityKb,
+		EagerZero:     &eagerZero,
+	}
+	if dc != nil {
+		ref := dc.Reference()
+		req.Datacenter = &ref
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), defaultAPITimeout)
+	defer cancel()
+	res, err := methods.ExtendVirtualDisk_Task(ctx, client.Client, &req)
+	if err != nil {
+		return err
+	}
+
+	t := object.NewTask(client.Client, res.Returnval)
+	return t.Wait(context.Background())
+}
+
+// moveVirtualDisk moves a virtual disk from one datastore path to another,
+// possibly on a different datastore, and waits for the operation to complete.
+func moveVirtualDisk(client *govmomi.Client, src string, dst string, dc *object.Datacenter) error {
+	m := object.NewVirtualDiskManager(client.Client)
+	ctx, cancel := context.WithTimeout(context.Background(), defaultAPITimeout)
+	defer cancel()
+	task, err := m.MoveVirtualDisk(ctx, src, dc, dst, dc, false)
+	if err != nil {
+		return err
+	}
+	return task.Wait(context.Background())
+}
+
+// copyVirtualDisk copies a virtual disk from one datastore path to another,
+// converting it according to the supplied spec, and waits for the operation to
+// complete.
+//
+// The wait is not bound by a timeout, as copying large disks can take a long
+// time.
+func copyVirtualDisk(client *govmomi.Client, src string, dst string, dc *object.Datacenter, spec *types.VirtualDiskSpec) error {
+	m := object.NewVirtualDiskManager(client.Client)
+	ctx, cancel := context.WithTimeout(context.Background(), defaultAPITimeout)
+	defer cancel()
+	task, err := m.CopyVirtualDisk(ctx, src, dc, dst, dc, spec, false)
+	if err != nil {
+		return err
+	}
+	return task.Wait(context.Background())
+}
+
+// virtualDiskFileInfo searches the supplied datastore for the virtual disk at
+// the supplied path, and returns its VmDiskFileInfo.
+func virtualDiskFileInfo(ds *object.Datastore, vmdkPath string) (*types.VmDiskFileInfo, error) {
+	spec := &types.HostDatastoreBrowserSearchSpec{
+		Query: []types.BaseFileQuery{
+			&types.VmDiskFileQuery{
+				Details: &types.VmDiskFileQueryFlags{
+					CapacityKb:     true,
+					DiskType:       true,
+					ControllerType: boolPtr(true),
+					Thin:           boolPtr(true),
+				},
+			},
+		},
+		Details: &types.FileQueryFlags{
+			FileSize:     true,
+			FileType:     true,
+			Modification: true,
+			FileOwner:    boolPtr(false),
+		},
+		MatchPattern: []string{path.Base(vmdkPath)},
+	}
+	results, err := searchDatastore(ds, ds.Path(path.Dir(vmdkPath)), spec, false)
+	if err != nil {
+		return nil, err
+	}
+	for _, result := range results {
+		for _, f := range result.File {
+			if fi, ok := f.(*types.VmDiskFileInfo); ok {
+				return fi, nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("virtual disk %s not found", ds.Path(vmdkPath))
+}
@@ -4,7 +4,9 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"log"
 	"net"
+	"time"
 
 	"github.com/vmware/govmomi"
 	"github.com/vmware/govmomi/find"
@@ -14,6 +16,24 @@ import (
 	"github.com/vmware/govmomi/vim25/types"
 )
 
+// virtualMachineNotFoundError is returned by virtualMachineFromUUID when no
+// virtual machine with the supplied UUID exists.
+type virtualMachineNotFoundError struct {
+	uuid string
+}
+
+// Error implements error for virtualMachineNotFoundError.
+func (e virtualMachineNotFoundError) Error() string {
+	return fmt.Sprintf("virtual machine with UUID %q not found", e.uuid)
+}
+
+// isVirtualMachineNotFoundError checks an error to see if it's of the
+// virtualMachineNotFoundError type.
+func isVirtualMachineNotFoundError(err error) bool {
+	_, ok := err.(virtualMachineNotFoundError)
+	return ok
+}
+
 // virtualMachineFromUUID locates a virtualMachine by its UUID.
 func virtualMachineFromUUID(client *govmomi.Client, uuid string) (*object.VirtualMachine, error) {
 	search := object.NewSearchIndex(client.Client)
@@ -26,7 +46,7 @@ func virtualMachineFromUUID(client *govmomi.Client, uuid string) (*object.Virtua
 	}
 
 	if result == nil {
-		return nil, fmt.Errorf("virtual machine with UUID %q not found", uuid)
+		return nil, virtualMachineNotFoundError{uuid: uuid}
 	}
 
 	// We need to filter our object through finder to ensure that the
@@ -69,6 +89,20 @@ func virtualMachineFromManagedObjectID(client *govmomi.Client, id string) (*obje
 	return vm.(*object.VirtualMachine), nil
 }
 
+// virtualMachineFromPath locates a virtual machine or template by its name or
+// inventory path. If a datacenter is supplied, relative paths are searched for
+// in that datacenter.
+func virtualMachineFromPath(client *govmomi.Client, p string, dc *object.Datacenter) (*object.VirtualMachine, error) {
+	finder := find.NewFinder(client.Client, false)
+	if dc != nil {
+		finder.SetDatacenter(dc)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), defaultAPITimeout)
+	defer cancel()
+	return finder.VirtualMachine(ctx, p)
+}
+
 // virtualMachineProperties is a convenience method that wraps fetching the
 // VirtualMachine MO from its higher-level object.
 func virtualMachineProperties(vm *object.VirtualMachine) (*mo.VirtualMachine, error) {
@@ -145,3 +179,144 @@ func waitForGuestVMNet(client *govmomi.Client, vm *object.VirtualMachine) error
 
 	return nil
 }
+
+// powerOnVirtualMachine powers on a virtual machine and waits for the
+// operation to complete.
+func powerOnVirtualMachine(vm *object.VirtualMachine) error {
+	ctx, cancel := context.WithTimeout(context.Background(), defaultAPITimeout)
+	defer cancel()
+	task, err := vm.PowerOn(ctx)
+	if err != nil {
+		return err
+	}
+	return task.Wait(ctx)
+}
+
+// powerOffVirtualMachine powers off a virtual machine and waits for the
+// operation to complete.
+func powerOffVirtualMachine(vm *object.VirtualMachine) error {
+	ctx, cancel := context.WithTimeout(context.Background(), defaultAPITimeout)
+	defer cancel()
+	task, err := vm.PowerOff(ctx)
+	if err != nil {
+		return err
+	}
+	return task.Wait(ctx)
+}
+
+// suspendVirtualMachine suspends a virtual machine and waits for the operation
+// to complete.
+func suspendVirtualMachine(vm *object.VirtualMachine) error {
+	ctx, cancel := context.WithTimeout(context.Background(), defaultAPITimeout)
+	defer cancel()
+	task, err := vm.Suspend(ctx)
+	if err != nil {
+		return err
+	}
+	return task.Wait(ctx)
+}
+
+// shutdownVirtualMachine gracefully shuts down the guest of a virtual machine
+// through VMware Tools, and waits up to timeout minutes for the virtual
+// machine to power off. If tools are not running, or the timeout is reached,
+// the virtual machine is powered off if force is true, otherwise an error is
+// returned.
+func shutdownVirtualMachine(vm *object.VirtualMachine, timeout int, force bool) error {
+	ctx, cancel := context.WithTimeout(context.Background(), defaultAPITimeout)
+	defer cancel()
+	toolsRunning, err := vm.IsToolsRunning(ctx)
+	if err != nil {
+		return fmt.Errorf("error checking VMware Tools status: %s", err)
+	}
+	if toolsRunning {
+		log.Printf("[DEBUG] Shutting down guest of virtual machine %q", vm.InventoryPath)
+		if err := vm.ShutdownGuest(ctx); err != nil {
+			return fmt.Errorf("error shutting down guest: %s", err)
+		}
+		wctx, wcancel := context.WithTimeout(context.Background(), time.Minute*time.Duration(timeout))
+		defer wcancel()
+		err := vm.WaitForPowerState(wctx, types.VirtualMachinePowerStatePoweredOff)
+		if err == nil {
+			log.Printf("[INFO] Guest of virtual machine %q shut down gracefully", vm.InventoryPath)
+			return nil
+		}
+		if wctx.Err() != context.DeadlineExceeded {
+			return fmt.Errorf("error waiting for guest shutdown: %s", err)
+		}
+		log.Printf("[WARN] Timed out after %d minute(s) waiting for guest of virtual machine %q to shut down", timeout, vm.InventoryPath)
+	} else {
+		log.Printf("[WARN] VMware Tools are not running on virtual machine %q, cannot shut down guest", vm.InventoryPath)
+	}
+	if !force {
+		return fmt.Errorf("virtual machine %q could not be shut down gracefully, and forced power off is disabled", vm.InventoryPath)
+	}
+	log.Printf("[WARN] Forcing power off of virtual machine %q", vm.InventoryPath)
+	if err := powerOffVirtualMachine(vm); err != nil {
+		return fmt.Errorf("error powering off virtual machine: %s", err)
+	}
+	log.Printf("[INFO] Virtual machine %q powered off", vm.InventoryPath)
+	return nil
+}
+
+// setVirtualMachinePowerState transitions a virtual machine from its current
+// power state to the desired one. Powering off a running virtual machine goes
+// through shutdownVirtualMachine, so that the guest is shut down gracefully
+// where possible, with timeout and force passed through. A powered off virtual
+// machine is powered on before it is suspended.
+func setVirtualMachinePowerState(vm *object.VirtualMachine, current, desired types.VirtualMachinePowerState, timeout int, force bool) error {
+	if current == desired {
+		return nil
+	}
+	log.Printf("[INFO] Changing power state of virtual machine %q from %s to %s", vm.InventoryPath, current, desired)
+	switch desired {
+	case types.VirtualMachinePowerStatePoweredOn:
+		return powerOnVirtualMachine(vm)
+	case types.VirtualMachinePowerStatePoweredOff:
+		if current == types.VirtualMachinePowerStateSuspended {
+			return powerOffVirtualMachine(vm)
+		}
+		return shutdownVirtualMachine(vm, timeout, force)
+	case types.VirtualMachinePowerStateSuspended:
+		if current == types.VirtualMachinePowerStatePoweredOff {
+			if err := powerOnVirtualMachine(vm); err != nil {
+				return err
+			}
+		}
+		return suspendVirtualMachine(vm)
+	}
+	return fmt.Errorf("unsupported power state %q", desired)
+}
+
+// waitForVirtualMachineTasks waits for any queued or running tasks on a
+// virtual machine to complete, such as power operations started outside of
+// Terraform. The results of the tasks are not checked.
+func waitForVirtualMachineTasks(vm *object.VirtualMachine) error {
+	var props mo.VirtualMachine
+	ctx, cancel := context.WithTimeout(context.Background(), defaultAPITimeout)
+	defer cancel()
+	if err := vm.Properties(ctx, vm.Reference(), []string{"recentTask"}, &props); err != nil {
+		return fmt.Errorf("error fetching recent tasks: %s", err)
+	}
+	for _, ref := range props.RecentTask {
+		log.Printf("[DEBUG] Waiting for task %q on virtual machine %q", ref.Value, vm.InventoryPath)
+		if _, err := object.NewTask(vm.Client(), ref).WaitForResult(ctx, nil); err != nil {
+			if ctx.Err() == context.DeadlineExceeded {
+				return fmt.Errorf("timeout waiting for task %q on virtual machine %q", ref.Value, vm.InventoryPath)
+			}
+			log.Printf("[DEBUG] Task %q on virtual machine %q finished with error: %s", ref.Value, vm.InventoryPath, err)
+		}
+	}
+	return nil
+}
+
+// relocateVirtualMachine migrates a virtual machine with the supplied relocate
+// spec and waits for the migration to complete. Storage migrations copy the
+// disks of the virtual machine and can take a long time, so no timeout is
+// applied.
+func relocateVirtualMachine(vm *object.VirtualMachine, spec types.VirtualMachineRelocateSpec) error {
+	task, err := vm.Relocate(context.TODO(), spec, types.VirtualMachineMovePriorityDefaultPriority)
+	if err != nil {
+		return err
+	}
+	return task.Wait(context.TODO())
+}